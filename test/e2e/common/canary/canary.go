@@ -0,0 +1,157 @@
+// Package canary keeps one long-lived volume with a trickle IO workload
+// running across suite boundaries (install, feature suites,
+// pre-uninstall). The kubernetes objects persist between test processes;
+// each suite re-attaches a background prober which continuously asserts
+// availability, so a regression introduced by any suite is detected at
+// the moment it occurs rather than by a later suite.
+package canary
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const nameSpace = "default"
+const scName = "e2e-canary-sc"
+const volName = "e2e-canary-vol"
+const podName = "e2e-canary-fio"
+const volSizeMb = 256
+const deployTimeoutSecs = 240
+
+var mutex sync.Mutex
+var started bool
+var stopChan chan struct{}
+var failures []string
+
+// deploy creates the canary storage class, volume and pod if they do
+// not exist yet, so the first suite of a run creates them and later
+// suites re-attach.
+func deploy() error {
+	cfg := e2e_config.GetConfig()
+	replicas := cfg.Canary.ReplicaCount
+	if replicas == 0 {
+		replicas = cfg.DefaultReplicaCount
+	}
+	if err := k8stest.MkStorageClass(scName, replicas, "nvmf"); err != nil && !alreadyExists(err) {
+		return err
+	}
+	if _, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	if _, err := k8stest.CreateFioPod(podName, volName, nameSpace); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	return k8stest.WaitPodRunning(podName, nameSpace, deployTimeoutSecs)
+}
+
+// MkStorageClass wraps the creation error, so inspect the message too.
+func alreadyExists(err error) bool {
+	return k8serrors.IsAlreadyExists(err) ||
+		(err != nil && strings.Contains(err.Error(), "already exists"))
+}
+
+// probe performs one trickle write and read back on the canary volume.
+func probe() error {
+	stamp := fmt.Sprintf("%d", time.Now().UnixNano())
+	out, err := k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		fmt.Sprintf("echo %s >> /volume/canary.log && sync && tail -n 1 /volume/canary.log", stamp))
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(out, stamp) {
+		return fmt.Errorf("canary read back %q, expected %q", out, stamp)
+	}
+	return nil
+}
+
+func run(intervalSecs int) {
+	ticker := time.NewTicker(time.Duration(intervalSecs) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			if err := probe(); err != nil {
+				logf.Log.Info("canary probe failed", "error", err)
+				mutex.Lock()
+				failures = append(failures, fmt.Sprintf("%s: %v", time.Now().Format(time.RFC3339), err))
+				mutex.Unlock()
+			}
+		}
+	}
+}
+
+// EnsureStarted deploys the canary objects if needed and starts the
+// background prober, once per test process. A no-op unless the canary
+// is enabled in the e2e configuration.
+func EnsureStarted() error {
+	cfg := e2e_config.GetConfig()
+	if !cfg.Canary.Enabled {
+		return nil
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if started {
+		return nil
+	}
+	if err := deploy(); err != nil {
+		return fmt.Errorf("failed to deploy the canary volume, error %v", err)
+	}
+	stopChan = make(chan struct{})
+	go run(cfg.Canary.IntervalSecs)
+	started = true
+	return nil
+}
+
+// TakeFailures returns the canary failures observed since the last call
+// and clears them.
+func TakeFailures() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	taken := failures
+	failures = nil
+	return taken
+}
+
+// Stop halts the prober in this process, leaving the canary objects in
+// place for the next suite.
+func Stop() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if started {
+		close(stopChan)
+		started = false
+	}
+}
+
+// Remove stops the prober and deletes the canary objects; called by the
+// pre-uninstall phase. Observed failures are appended to the run
+// manifest first.
+func Remove() error {
+	Stop()
+	if taken := TakeFailures(); len(taken) != 0 {
+		if err := manifest.AppendSection("canaryFailures", taken); err != nil {
+			return err
+		}
+	}
+	if err := k8stest.DeletePod(podName, nameSpace); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	if err := k8stest.DeletePVC(volName, nameSpace); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	if err := k8stest.RmStorageClass(scName); err != nil && !strings.Contains(err.Error(), "not found") {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+// Package chaos injects faults at random intervals over a soak window,
+// driven by the weighted schedule in the e2e configuration: component
+// pod kills, transient network loss, node reboots and pool device
+// errors. Every injected fault is logged with its timestamp, through the
+// log merger as well, so volume state changes during the soak can be
+// correlated with the fault that caused them.
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/faults"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// networkLossSecs is how long an injected network loss lasts before the
+// node is restored.
+const networkLossSecs = 30
+
+// deviceFaultSecs is how long a pool device error injection lasts.
+const deviceFaultSecs = 60
+
+// deviceErrPercent is the error rate of an injected flakey device.
+const deviceErrPercent = 50
+
+// rebootReadyTimeoutSecs bounds the wait for a rebooted node.
+const rebootReadyTimeoutSecs = 600
+
+// podKillSelectors are the component pods eligible for a pod kill.
+var podKillSelectors = []string{"app=mayastor", "app=mayastor-csi", "app=moac"}
+
+// Fault is one injected fault, recorded for correlation.
+type Fault struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Target string    `json:"target"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Engine runs the chaos schedule until stopped.
+type Engine struct {
+	minInterval time.Duration
+	maxInterval time.Duration
+	actions     []e2e_config.ChaosAction
+	rng         *rand.Rand
+	stop        chan struct{}
+	done        chan struct{}
+
+	mu        sync.Mutex
+	injected  []Fault
+	netFaults *faults.NetworkFaults
+	platform  faults.Platform
+}
+
+// Start begins injecting faults per the configured schedule; the caller
+// must call Stop before tearing the cluster state down.
+func Start() (*Engine, error) {
+	cfg := e2e_config.GetConfig().Chaos
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("chaos is not enabled in the e2e configuration")
+	}
+	if len(cfg.Actions) == 0 {
+		return nil, fmt.Errorf("chaos is enabled with no actions configured")
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	logf.Log.Info("starting the chaos engine", "seed", seed,
+		"minIntervalSecs", cfg.MinIntervalSecs, "maxIntervalSecs", cfg.MaxIntervalSecs)
+	engine := &Engine{
+		minInterval: time.Duration(cfg.MinIntervalSecs) * time.Second,
+		maxInterval: time.Duration(cfg.MaxIntervalSecs) * time.Second,
+		actions:     cfg.Actions,
+		rng:         rand.New(rand.NewSource(seed)),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go engine.run()
+	return engine, nil
+}
+
+// Stop halts the schedule, restores any fault left in place and returns
+// the log of injected faults.
+func (e *Engine) Stop() []Fault {
+	close(e.stop)
+	<-e.done
+	if e.netFaults != nil {
+		if err := e.netFaults.Stop(); err != nil {
+			logf.Log.Info("chaos: failed to stop network faults", "error", err)
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Fault(nil), e.injected...)
+}
+
+// run is the schedule loop: a random pause, then a random fault.
+func (e *Engine) run() {
+	defer close(e.done)
+	for {
+		interval := e.minInterval
+		if e.maxInterval > e.minInterval {
+			interval += time.Duration(e.rng.Int63n(int64(e.maxInterval - e.minInterval)))
+		}
+		select {
+		case <-e.stop:
+			return
+		case <-time.After(interval):
+		}
+		e.injectOne()
+	}
+}
+
+// pickAction draws an action from the weighted mix.
+func (e *Engine) pickAction() e2e_config.ChaosAction {
+	total := 0
+	for _, action := range e.actions {
+		total += action.Weight
+	}
+	draw := e.rng.Intn(total)
+	for _, action := range e.actions {
+		draw -= action.Weight
+		if draw < 0 {
+			return action
+		}
+	}
+	return e.actions[len(e.actions)-1]
+}
+
+// record logs an injected fault with its timestamp.
+func (e *Engine) record(faultType string, target string, err error) {
+	fault := Fault{Time: time.Now(), Type: faultType, Target: target}
+	if err != nil {
+		fault.Error = err.Error()
+	}
+	logmerge.RecordAction("chaos: %s on %s, error %v", faultType, target, err)
+	logf.Log.Info("chaos: injected fault", "type", faultType, "target", target, "error", err)
+	e.mu.Lock()
+	e.injected = append(e.injected, fault)
+	e.mu.Unlock()
+}
+
+// randomNode returns a random mayastor node.
+func (e *Engine) randomNode() (string, error) {
+	nodeNames, err := k8stest.GetMayastorNodeNames()
+	if err != nil {
+		return "", err
+	}
+	if len(nodeNames) == 0 {
+		return "", fmt.Errorf("no mayastor nodes to fault")
+	}
+	return nodeNames[e.rng.Intn(len(nodeNames))], nil
+}
+
+// injectOne applies one fault drawn from the schedule. Faults which hold
+// state (network loss, device errors) are restored before the next draw,
+// so at most one fault is in place at a time.
+func (e *Engine) injectOne() {
+	action := e.pickAction()
+	switch action.Type {
+	case "pod-kill":
+		e.killRandomPod()
+	case "network-loss":
+		e.injectNetworkLoss()
+	case "node-reboot":
+		e.rebootRandomNode()
+	case "pool-device-error":
+		e.injectDeviceError()
+	default:
+		e.record(action.Type, "", fmt.Errorf("unknown chaos action type"))
+	}
+}
+
+func (e *Engine) killRandomPod() {
+	selector := podKillSelectors[e.rng.Intn(len(podKillSelectors))]
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, selector)
+	if err != nil || len(pods.Items) == 0 {
+		e.record("pod-kill", selector, fmt.Errorf("no pods matching %s, error %v", selector, err))
+		return
+	}
+	podName := pods.Items[e.rng.Intn(len(pods.Items))].Name
+	// The other chaos actions reach the run report through the faults
+	// package; pod kills are injected directly, so note them here.
+	runreport.RecordFault("pod-kill", podName)
+	e.record("pod-kill", podName, k8stest.DeletePod(podName, k8stest.MayastorNamespace))
+}
+
+func (e *Engine) injectNetworkLoss() {
+	nodeName, err := e.randomNode()
+	if err != nil {
+		e.record("network-loss", "", err)
+		return
+	}
+	if e.netFaults == nil {
+		e.netFaults, err = faults.StartNetworkFaults()
+		if err != nil {
+			e.netFaults = nil
+			e.record("network-loss", nodeName, err)
+			return
+		}
+	}
+	if err := e.netFaults.AddPacketLoss(nodeName, 100); err != nil {
+		e.record("network-loss", nodeName, err)
+		return
+	}
+	e.record("network-loss", nodeName, nil)
+	select {
+	case <-e.stop:
+	case <-time.After(networkLossSecs * time.Second):
+	}
+	if err := e.netFaults.RestoreNode(nodeName); err != nil {
+		logf.Log.Info("chaos: failed to restore network", "node", nodeName, "error", err)
+	}
+}
+
+func (e *Engine) rebootRandomNode() {
+	nodeName, err := e.randomNode()
+	if err != nil {
+		e.record("node-reboot", "", err)
+		return
+	}
+	if e.platform == nil {
+		e.platform, err = faults.NewPlatform()
+		if err != nil {
+			e.platform = nil
+			e.record("node-reboot", nodeName, err)
+			return
+		}
+	}
+	if err := e.platform.RebootNode(nodeName); err != nil {
+		e.record("node-reboot", nodeName, err)
+		return
+	}
+	e.record("node-reboot", nodeName, nil)
+	if err := faults.WaitNodeReady(nodeName, rebootReadyTimeoutSecs); err != nil {
+		logf.Log.Info("chaos: rebooted node not ready", "node", nodeName, "error", err)
+	}
+}
+
+func (e *Engine) injectDeviceError() {
+	nodeName, err := e.randomNode()
+	if err != nil {
+		e.record("pool-device-error", "", err)
+		return
+	}
+	device := e2e_config.GetConfig().PoolDevice
+	// Wrapping fails when the device is open, e.g. already holding an
+	// active pool; the failed attempt is still recorded.
+	if _, err := faults.MakeDeviceFlaky(nodeName, device, deviceErrPercent); err != nil {
+		e.record("pool-device-error", nodeName, err)
+		return
+	}
+	e.record("pool-device-error", nodeName, nil)
+	select {
+	case <-e.stop:
+	case <-time.After(deviceFaultSecs * time.Second):
+	}
+	if err := faults.RestoreDevice(nodeName); err != nil {
+		logf.Log.Info("chaos: failed to restore device", "node", nodeName, "error", err)
+	}
+}
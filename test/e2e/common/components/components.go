@@ -0,0 +1,59 @@
+// Package components is the registry of deployed mayastor components
+// and the scheduling configuration each is required to carry. Suites
+// check installed pods against this registry so install manifest
+// regressions, e.g. the io-engine becoming evictable under node
+// pressure, are caught by CI rather than by users.
+package components
+
+// Component describes one deployed mayastor component and its required
+// scheduling configuration. Empty fields are not checked.
+type Component struct {
+	// Name of the component, for reporting
+	Name string
+	// LabelSelector identifying the component's pods
+	LabelSelector string
+	// PriorityClassName the pods must run with
+	PriorityClassName string
+	// RequiredTolerationKeys which must appear in the pod tolerations
+	RequiredTolerationKeys []string
+	// RequiredNodeSelector entries which must appear in the pod node
+	// selector
+	RequiredNodeSelector map[string]string
+	// Optional marks components which may legitimately be absent from
+	// an installation
+	Optional bool
+}
+
+// Registry returns the component registry. The io-engine runs pinned to
+// labelled storage nodes and must be protected from eviction under node
+// pressure; the csi node plugin runs everywhere mayastor volumes may be
+// published.
+func Registry() []Component {
+	return []Component{
+		{
+			Name:              "mayastor",
+			LabelSelector:     "app=mayastor",
+			PriorityClassName: "system-node-critical",
+			RequiredNodeSelector: map[string]string{
+				"openebs.io/engine":  "mayastor",
+				"kubernetes.io/arch": "amd64",
+			},
+		},
+		{
+			Name:              "mayastor-csi",
+			LabelSelector:     "app=mayastor-csi",
+			PriorityClassName: "system-node-critical",
+			RequiredNodeSelector: map[string]string{
+				"kubernetes.io/arch": "amd64",
+			},
+		},
+		{
+			Name:          "moac",
+			LabelSelector: "app=moac",
+		},
+		{
+			Name:          "nats",
+			LabelSelector: "app=nats",
+		},
+	}
+}
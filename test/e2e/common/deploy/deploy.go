@@ -0,0 +1,197 @@
+// Package deploy generates the mayastor deployment yamls natively in Go
+// from the e2e configuration - registry, tag, cores, pools - replacing
+// the shell based generate-deploy-yamls.sh invocation with string built
+// arguments in the install and uninstall paths.
+package deploy
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// PoolSpec names a node and the backing device of the pool to create on
+// it. Device is a bare path or a pool URI with an explicit bdev scheme
+// - "aio://", "uring://", "nvme://" - passed through as given.
+type PoolSpec struct {
+	// Name of the pool resource; pool-on-<node> when empty.
+	Name   string
+	Node   string
+	Device string
+}
+
+// name returns the pool resource name, defaulting to the historic
+// single-pool-per-node name.
+func (pool PoolSpec) name() string {
+	if pool.Name != "" {
+		return pool.Name
+	}
+	return "pool-on-" + pool.Node
+}
+
+// Params holds all values substituted into the deployment templates.
+type Params struct {
+	Registry     string
+	ImageTag     string
+	PullPolicy   string
+	Cores        int
+	HugePagesGiB int
+	Namespace    string
+	MoacDebug    bool
+	Pools        []PoolSpec
+	// IoEngineEnv and IoEngineArgs inject additional environment
+	// variables and command line arguments into the io-engine
+	// container, enabling configuration-matrix runs (nvmf port,
+	// reactor mask, log level, ...) from the e2e configuration.
+	IoEngineEnv  map[string]string
+	IoEngineArgs []string
+}
+
+// ParamsFromConfig returns template parameters derived from the e2e
+// configuration. As in the shell script, hugepage memory in GiB equals
+// the core count.
+func ParamsFromConfig() Params {
+	cfg := e2e_config.GetConfig()
+	registry := cfg.Registry
+	if cfg.AirGap.Enabled {
+		// Air-gapped installs pull everything from the in-cluster
+		// registry into which the images were mirrored.
+		registry = cfg.AirGap.Registry
+	}
+	return Params{
+		Registry:     registry,
+		ImageTag:     cfg.ImageTag,
+		PullPolicy:   cfg.ImagePullPolicy,
+		Cores:        cfg.Cores,
+		HugePagesGiB: cfg.Cores,
+		Namespace:    "mayastor",
+		IoEngineEnv:  cfg.IoEngine.Env,
+		IoEngineArgs: cfg.IoEngine.Args,
+	}
+}
+
+// ImageName returns the full image name for the given mayastor image,
+// prefixed with the registry when one is configured.
+func (params Params) ImageName(image string) string {
+	name := fmt.Sprintf("mayadata/%s:%s", image, params.ImageTag)
+	if params.Registry != "" {
+		name = params.Registry + "/" + name
+	}
+	return name
+}
+
+// CoreList returns the cpu core list argument for the io-engine, cores
+// 1 up to the configured count.
+func (params Params) CoreList() string {
+	var cores []string
+	for core := 1; core <= params.Cores; core++ {
+		cores = append(cores, fmt.Sprintf("%d", core))
+	}
+	return strings.Join(cores, ",")
+}
+
+func renderTemplate(templateName string, data interface{}) ([]byte, error) {
+	contents, err := templatesFS.ReadFile(path.Join("templates", templateName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s, error %v", templateName, err)
+	}
+	tmpl, err := template.New(templateName).Parse(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s, error %v", templateName, err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s, error %v", templateName, err)
+	}
+	return rendered.Bytes(), nil
+}
+
+// PoolsForNodes returns the pool specs the install creates on the
+// given nodes: one pool per device configured for the node, falling
+// back to the single PoolDevice. The first pool on a node keeps the
+// pool-on-<node> name; further pools append their index, so a node
+// with several devices yields distinct resources.
+func PoolsForNodes(nodeNames []string) []PoolSpec {
+	cfg := e2e_config.GetConfig()
+	var pools []PoolSpec
+	for _, nodeName := range nodeNames {
+		for ix, device := range cfg.PoolDevicesForNode(nodeName) {
+			name := "pool-on-" + nodeName
+			if ix > 0 {
+				name = fmt.Sprintf("%s-%d", name, ix)
+			}
+			pools = append(pools, PoolSpec{Name: name, Node: nodeName, Device: device})
+		}
+	}
+	return pools
+}
+
+// NodePoolMap returns the pool names per node, for suites reasoning
+// about capacity or placement across the created pools.
+func NodePoolMap(pools []PoolSpec) map[string][]string {
+	byNode := map[string][]string{}
+	for _, pool := range pools {
+		byNode[pool.Node] = append(byNode[pool.Node], pool.name())
+	}
+	return byNode
+}
+
+// poolTemplateData carries per pool values plus the namespace into the
+// pool template.
+type poolTemplateData struct {
+	Name      string
+	Node      string
+	Device    string
+	Namespace string
+}
+
+// GenerateYamls renders all deployment yamls into outputDir, one yaml
+// file per component plus one per configured pool.
+func GenerateYamls(outputDir string, params Params) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s, error %v", outputDir, err)
+	}
+	entries, err := templatesFS.ReadDir("templates")
+	if err != nil {
+		return fmt.Errorf("failed to enumerate templates, error %v", err)
+	}
+	for _, entry := range entries {
+		templateName := entry.Name()
+		if templateName == "pool.yaml.tmpl" {
+			continue
+		}
+		rendered, err := renderTemplate(templateName, params)
+		if err != nil {
+			return err
+		}
+		outputFile := path.Join(outputDir, strings.TrimSuffix(templateName, ".tmpl"))
+		if err := os.WriteFile(outputFile, rendered, 0644); err != nil {
+			return fmt.Errorf("failed to write %s, error %v", outputFile, err)
+		}
+	}
+	for _, pool := range params.Pools {
+		rendered, err := renderTemplate("pool.yaml.tmpl", poolTemplateData{
+			Name:      pool.name(),
+			Node:      pool.Node,
+			Device:    pool.Device,
+			Namespace: params.Namespace,
+		})
+		if err != nil {
+			return err
+		}
+		outputFile := path.Join(outputDir, pool.name()+".yaml")
+		if err := os.WriteFile(outputFile, rendered, 0644); err != nil {
+			return fmt.Errorf("failed to write %s, error %v", outputFile, err)
+		}
+	}
+	return nil
+}
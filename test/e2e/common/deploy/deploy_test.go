@@ -0,0 +1,222 @@
+package deploy
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	"gopkg.in/yaml.v3"
+)
+
+func testParams() Params {
+	return Params{
+		Registry:     "registry.example.com",
+		ImageTag:     "v1.2.3",
+		PullPolicy:   "IfNotPresent",
+		Cores:        3,
+		HugePagesGiB: 3,
+		Namespace:    "mayastor",
+		Pools: []PoolSpec{
+			{Node: "node-1", Device: "/dev/sdb"},
+			{Node: "node-2", Device: "/dev/sdc"},
+		},
+	}
+}
+
+// every rendered document must be parseable yaml
+func checkYaml(t *testing.T, contents []byte, filename string) {
+	for _, doc := range strings.Split(string(contents), "\n---") {
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &parsed); err != nil {
+			t.Errorf("%s is not valid yaml: %v", filename, err)
+		}
+	}
+}
+
+func TestGenerateYamls(t *testing.T) {
+	outputDir := t.TempDir()
+	params := testParams()
+	if err := GenerateYamls(outputDir, params); err != nil {
+		t.Fatalf("GenerateYamls failed: %v", err)
+	}
+
+	wantFiles := []string{
+		"namespace.yaml",
+		"mayastor-daemonset.yaml",
+		"moac-deployment.yaml",
+		"pool-on-node-1.yaml",
+		"pool-on-node-2.yaml",
+	}
+	for _, filename := range wantFiles {
+		contents, err := os.ReadFile(path.Join(outputDir, filename))
+		if err != nil {
+			t.Fatalf("expected generated file %s: %v", filename, err)
+		}
+		checkYaml(t, contents, filename)
+	}
+
+	daemonset, _ := os.ReadFile(path.Join(outputDir, "mayastor-daemonset.yaml"))
+	if !strings.Contains(string(daemonset), "registry.example.com/mayadata/mayastor:v1.2.3") {
+		t.Errorf("daemonset does not reference the configured registry and tag:\n%s", daemonset)
+	}
+	if !strings.Contains(string(daemonset), "-l1,2,3") {
+		t.Errorf("daemonset does not carry the configured core list:\n%s", daemonset)
+	}
+	if !strings.Contains(string(daemonset), `hugepages-2Mi: "3Gi"`) {
+		t.Errorf("daemonset does not carry the configured hugepage allocation:\n%s", daemonset)
+	}
+
+	pool, _ := os.ReadFile(path.Join(outputDir, "pool-on-node-2.yaml"))
+	if !strings.Contains(string(pool), "name: pool-on-node-2") ||
+		!strings.Contains(string(pool), "node: node-2") || !strings.Contains(string(pool), `disks: ["/dev/sdc"]`) {
+		t.Errorf("pool yaml does not carry the configured name, node and device:\n%s", pool)
+	}
+}
+
+func TestGenerateYamlsMultiplePoolsPerNode(t *testing.T) {
+	outputDir := t.TempDir()
+	params := testParams()
+	params.Pools = []PoolSpec{
+		{Name: "pool-on-node-1", Node: "node-1", Device: "/dev/sdb"},
+		{Name: "pool-on-node-1-1", Node: "node-1", Device: "aio:///var/tmp/pool.img"},
+	}
+	if err := GenerateYamls(outputDir, params); err != nil {
+		t.Fatalf("GenerateYamls failed: %v", err)
+	}
+	pool, err := os.ReadFile(path.Join(outputDir, "pool-on-node-1-1.yaml"))
+	if err != nil {
+		t.Fatalf("expected a manifest per pool on the node: %v", err)
+	}
+	checkYaml(t, pool, "pool-on-node-1-1.yaml")
+	if !strings.Contains(string(pool), "name: pool-on-node-1-1") ||
+		!strings.Contains(string(pool), `disks: ["aio:///var/tmp/pool.img"]`) {
+		t.Errorf("pool yaml does not carry the pool name and URI device:\n%s", pool)
+	}
+}
+
+func TestPoolsForNodes(t *testing.T) {
+	e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+		cfg.PoolDevice = "/dev/sdb"
+		cfg.PoolDevices = map[string][]string{
+			"node-2": {"/dev/sdc", "uring:///dev/sdd"},
+		}
+	})
+	defer e2e_config.PopOverrideScope()
+
+	pools := PoolsForNodes([]string{"node-1", "node-2"})
+	want := []PoolSpec{
+		{Name: "pool-on-node-1", Node: "node-1", Device: "/dev/sdb"},
+		{Name: "pool-on-node-2", Node: "node-2", Device: "/dev/sdc"},
+		{Name: "pool-on-node-2-1", Node: "node-2", Device: "uring:///dev/sdd"},
+	}
+	if len(pools) != len(want) {
+		t.Fatalf("unexpected pool specs %v, want %v", pools, want)
+	}
+	for ix, pool := range pools {
+		if pool != want[ix] {
+			t.Errorf("unexpected pool spec %v, want %v", pool, want[ix])
+		}
+	}
+
+	byNode := NodePoolMap(pools)
+	if strings.Join(byNode["node-2"], ",") != "pool-on-node-2,pool-on-node-2-1" {
+		t.Errorf("unexpected node pool map %v", byNode)
+	}
+	if strings.Join(byNode["node-1"], ",") != "pool-on-node-1" {
+		t.Errorf("unexpected node pool map %v", byNode)
+	}
+}
+
+func TestIoEngineConfigInjection(t *testing.T) {
+	params := testParams()
+	params.IoEngineEnv = map[string]string{"RUST_LOG": "mayastor=debug"}
+	params.IoEngineArgs = []string{"-p8430"}
+	rendered, err := renderTemplate("mayastor-daemonset.yaml.tmpl", params)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(string(rendered), "name: RUST_LOG") ||
+		!strings.Contains(string(rendered), `value: "mayastor=debug"`) {
+		t.Errorf("io-engine env var not rendered:\n%s", rendered)
+	}
+	if !strings.Contains(string(rendered), `- "-p8430"`) {
+		t.Errorf("io-engine extra argument not rendered:\n%s", rendered)
+	}
+	checkYaml(t, rendered, "mayastor-daemonset.yaml")
+}
+
+func TestImageNameWithoutRegistry(t *testing.T) {
+	params := testParams()
+	params.Registry = ""
+	if got := params.ImageName("moac"); got != "mayadata/moac:v1.2.3" {
+		t.Errorf("unexpected image name %q", got)
+	}
+}
+
+func TestHelmValues(t *testing.T) {
+	e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+		cfg.Install.Helm.EtcdReplicas = 3
+		cfg.Install.Helm.IoEngineCpuLimit = "4"
+		cfg.Install.Helm.IoEngineMemoryLimit = "4Gi"
+	})
+	defer e2e_config.PopOverrideScope()
+
+	values := strings.Join(HelmValues(testParams()), " ")
+	for _, want := range []string{
+		"image.tag=v1.2.3",
+		"image.pullPolicy=IfNotPresent",
+		"image.registry=registry.example.com",
+		"etcd.replicaCount=3",
+		"mayastor.resources.limits.cpu=4",
+		"mayastor.resources.limits.memory=4Gi",
+	} {
+		if !strings.Contains(values, want) {
+			t.Errorf("helm values missing %q: %s", want, values)
+		}
+	}
+
+	params := testParams()
+	params.Registry = ""
+	if strings.Contains(strings.Join(HelmValues(params), " "), "image.registry") {
+		t.Errorf("registry override emitted with no registry configured")
+	}
+}
+
+func TestInstallFileOrder(t *testing.T) {
+	dir := t.TempDir()
+	for _, filename := range []string{
+		"moac-deployment.yaml", "pool-node-1.yaml", "namespace.yaml",
+		"mayastor-daemonset.yaml", "pool-node-2.yaml", "notes.txt",
+	} {
+		if err := os.WriteFile(path.Join(dir, filename), []byte("kind: ConfigMap\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", filename, err)
+		}
+	}
+	files, err := installFiles(dir)
+	if err != nil {
+		t.Fatalf("installFiles failed: %v", err)
+	}
+	want := []string{
+		"namespace.yaml", "mayastor-daemonset.yaml", "moac-deployment.yaml",
+		"pool-node-1.yaml", "pool-node-2.yaml",
+	}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected apply order %v, want %v", files, want)
+	}
+}
+
+func TestMoacDebugFlag(t *testing.T) {
+	params := testParams()
+	params.MoacDebug = true
+	rendered, err := renderTemplate("moac-deployment.yaml.tmpl", params)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(string(rendered), `- "-vv"`) {
+		t.Errorf("moac debug flag not rendered:\n%s", rendered)
+	}
+	checkYaml(t, rendered, "moac-deployment.yaml")
+}
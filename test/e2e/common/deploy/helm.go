@@ -0,0 +1,81 @@
+package deploy
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+	"github.com/openebs/mayastor/test/e2e/common/timeouts"
+)
+
+// helmTimeout bounds a single helm install invocation.
+const helmTimeout = "15m"
+
+// runHelm runs the helm command line tool, recording the invocation in
+// the test action stream and returning its combined output on failure.
+func runHelm(args ...string) (string, error) {
+	logmerge.RecordAction("running helm %v", args)
+	out, err := exec.Command("helm", args...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("helm %v failed, error %v, output %s", args, err, string(out))
+	}
+	return string(out), nil
+}
+
+// HelmValues returns the chart value overrides derived from the
+// deployment parameters and the helm install configuration, as
+// key=value strings in a fixed order.
+func HelmValues(params Params) []string {
+	helmCfg := e2e_config.GetConfig().Install.Helm
+	values := []string{
+		"image.tag=" + params.ImageTag,
+		"image.pullPolicy=" + params.PullPolicy,
+	}
+	if params.Registry != "" {
+		values = append(values, "image.registry="+params.Registry)
+	}
+	if helmCfg.EtcdReplicas != 0 {
+		values = append(values, "etcd.replicaCount="+strconv.Itoa(helmCfg.EtcdReplicas))
+	}
+	if helmCfg.IoEngineCpuLimit != "" {
+		values = append(values, "mayastor.resources.limits.cpu="+helmCfg.IoEngineCpuLimit)
+	}
+	if helmCfg.IoEngineMemoryLimit != "" {
+		values = append(values, "mayastor.resources.limits.memory="+helmCfg.IoEngineMemoryLimit)
+	}
+	return values
+}
+
+// HelmInstall installs the mayastor helm chart configured in the e2e
+// configuration, with value overrides derived from the deployment
+// parameters, and waits for the release to become ready. An existing
+// release is upgraded in place, so a failed install can be retried.
+func HelmInstall(params Params) error {
+	helmCfg := e2e_config.GetConfig().Install.Helm
+	if helmCfg.Chart == "" {
+		return fmt.Errorf("helm install requested but no chart is configured")
+	}
+	args := []string{
+		"upgrade", "--install", helmCfg.Release, helmCfg.Chart,
+		"--namespace", params.Namespace,
+		"--create-namespace",
+		"--wait", "--timeout", helmTimeout,
+	}
+	for _, value := range HelmValues(params) {
+		args = append(args, "--set", value)
+	}
+	_, err := runHelm(args...)
+	return err
+}
+
+// HelmUninstall removes the mayastor helm release and waits for its
+// resources to go away.
+func HelmUninstall(nameSpace string) error {
+	helmCfg := e2e_config.GetConfig().Install.Helm
+	_, err := runHelm("uninstall", helmCfg.Release,
+		"--namespace", nameSpace,
+		"--wait", "--timeout", timeouts.UninstallDuration())
+	return err
+}
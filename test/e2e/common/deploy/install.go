@@ -0,0 +1,143 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+)
+
+// installFiles returns the generated yaml filenames in apply order: the
+// namespace first, pool manifests last - their CRD is only registered
+// once the control plane runs - and everything else alphabetically in
+// between.
+func installFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s, error %v", dir, err)
+	}
+	var first, middle, last []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		switch {
+		case name == "namespace.yaml":
+			first = append(first, name)
+		case strings.HasPrefix(name, "pool-"):
+			last = append(last, name)
+		default:
+			middle = append(middle, name)
+		}
+	}
+	sort.Strings(middle)
+	sort.Strings(last)
+	return append(append(first, middle...), last...), nil
+}
+
+// ParamsWithPools returns ParamsFromConfig with Pools populated for
+// the mayastor nodes of the cluster, one pool per device configured
+// for each node. NodePoolMap over the result gives suites the pool
+// names created per node.
+func ParamsWithPools() (Params, error) {
+	nodeNames, err := k8stest.GetMayastorNodeNames()
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to list mayastor nodes, error %v", err)
+	}
+	params := ParamsFromConfig()
+	params.Pools = PoolsForNodes(nodeNames)
+	return params, nil
+}
+
+// componentTimeoutSecs bounds the rollout of each component applied
+// during an install before the next one is applied.
+const componentTimeoutSecs = 540
+
+// Install deploys mayastor using the method selected by the e2e
+// configuration: "yaml" generates the deployment manifests and applies
+// them server-side, "helm" installs the chart. Both methods are
+// idempotent, so a failed install can be retried without uninstalling
+// first.
+func Install(params Params) error {
+	switch method := e2e_config.GetConfig().Install.Method; method {
+	case "yaml":
+		dir, err := os.MkdirTemp("", "e2e-install-yamls-")
+		if err != nil {
+			return fmt.Errorf("failed to create yaml directory, error %v", err)
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+		if err := GenerateYamls(dir, params); err != nil {
+			return err
+		}
+		files, err := installFiles(dir)
+		if err != nil {
+			return err
+		}
+		for _, filename := range files {
+			if err := k8stest.ApplyManifestFile(filename, dir, nil); err != nil {
+				return err
+			}
+			// Each component rolls out before the next is applied, so
+			// a failure is attributed to the component at fault rather
+			// than surfacing as a collective timeout at the end.
+			if err := k8stest.WaitManifestWorkloadsReady(filename, dir, nil, componentTimeoutSecs); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "helm":
+		return HelmInstall(params)
+	default:
+		return fmt.Errorf("unknown install method %q", method)
+	}
+}
+
+// residualTimeoutSecs bounds the wait for the hosts to release the
+// state of an uninstalled deployment.
+const residualTimeoutSecs = 360
+
+// uninstallYamls deletes the generated deployment manifests, in reverse
+// apply order so dependents go before their dependencies.
+func uninstallYamls(params Params) error {
+	dir, err := os.MkdirTemp("", "e2e-install-yamls-")
+	if err != nil {
+		return fmt.Errorf("failed to create yaml directory, error %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	if err := GenerateYamls(dir, params); err != nil {
+		return err
+	}
+	files, err := installFiles(dir)
+	if err != nil {
+		return err
+	}
+	for ix := len(files) - 1; ix >= 0; ix-- {
+		if err := k8stest.DeleteManifestFile(files[ix], dir, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Uninstall removes the mayastor installation deployed by Install, then
+// verifies the hosts released the state it held - the k8s resources
+// going away only proves the API objects are gone.
+func Uninstall(params Params) error {
+	switch method := e2e_config.GetConfig().Install.Method; method {
+	case "yaml":
+		if err := uninstallYamls(params); err != nil {
+			return err
+		}
+	case "helm":
+		if err := HelmUninstall(params.Namespace); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown install method %q", method)
+	}
+	return k8stest.VerifyUninstalledHostState(residualTimeoutSecs)
+}
@@ -0,0 +1,254 @@
+// Package disks prepares and reclaims pool backing storage on the
+// cluster nodes, through a privileged daemonset which chroots into the
+// host filesystem - the same mechanism the fault injection uses. It
+// can wipe devices, partition them, and create loop devices or LVM
+// logical volumes for pool backing, making a run self-sufficient on
+// fresh clusters, and it verifies after an uninstall that the devices
+// were really released.
+package disks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const diskPrepDsName = "e2e-disk-prep"
+const diskPrepSelector = "app=" + diskPrepDsName
+const diskPrepTimeoutSecs = 120
+
+// execTimeoutSecs bounds one host command; discarding a large device
+// can take a while.
+const execTimeoutSecs = 300
+
+// DiskPrep manages the disk preparation daemonset.
+type DiskPrep struct {
+	nameSpace string
+}
+
+// StartDiskPrep deploys the disk preparation daemonset and waits for it
+// to be ready on every node. The caller must call Stop when done.
+func StartDiskPrep() (*DiskPrep, error) {
+	nameSpace := k8stest.TestNamespace()
+	isPrivileged := true
+	hostPathType := coreV1.HostPathDirectory
+	labels := map[string]string{"app": diskPrepDsName}
+	dsDef := &appsV1.DaemonSet{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: diskPrepDsName,
+		},
+		Spec: appsV1.DaemonSetSpec{
+			Selector: &metaV1.LabelSelector{MatchLabels: labels},
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: labels},
+				Spec: coreV1.PodSpec{
+					HostPID: true,
+					Tolerations: []coreV1.Toleration{
+						{Operator: coreV1.TolerationOpExists},
+					},
+					Containers: []coreV1.Container{
+						{
+							Name:    diskPrepDsName,
+							Image:   e2e_config.GetConfig().E2eFioImage,
+							Command: []string{"sleep", "1000000"},
+							SecurityContext: &coreV1.SecurityContext{
+								Privileged: &isPrivileged,
+							},
+							VolumeMounts: []coreV1.VolumeMount{
+								{
+									Name:      "host-root",
+									MountPath: "/host",
+								},
+							},
+						},
+					},
+					Volumes: []coreV1.Volume{
+						{
+							Name: "host-root",
+							VolumeSource: coreV1.VolumeSource{
+								HostPath: &coreV1.HostPathVolumeSource{
+									Path: "/",
+									Type: &hostPathType,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if _, err := k8stest.CreateDaemonSet(dsDef, nameSpace); err != nil {
+		return nil, fmt.Errorf("failed to create disk prep daemonset, error %v", err)
+	}
+	if err := k8stest.WaitDaemonSetReady(diskPrepDsName, nameSpace, diskPrepTimeoutSecs); err != nil {
+		return nil, err
+	}
+	return &DiskPrep{nameSpace: nameSpace}, nil
+}
+
+// Stop removes the disk preparation daemonset. Created loop devices and
+// LVM volumes are not reclaimed here; callers remove what they created.
+func (d *DiskPrep) Stop() error {
+	if err := k8stest.DeleteDaemonSet(diskPrepDsName, d.nameSpace); err != nil {
+		return fmt.Errorf("failed to delete disk prep daemonset, error %v", err)
+	}
+	return nil
+}
+
+// execOnNode runs a shell script on the host of the given node through
+// its disk prep pod, returning the script output.
+func (d *DiskPrep) execOnNode(nodeName string, script string) (string, error) {
+	pods, err := k8stest.ListPodsBySelector(d.nameSpace, diskPrepSelector)
+	if err != nil {
+		return "", fmt.Errorf("failed to list disk prep pods, error %v", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == nodeName {
+			out, _, err := k8stest.ExecInPod(d.nameSpace, pod.Name, "",
+				[]string{"chroot", "/host", "sh", "-c", script}, execTimeoutSecs)
+			return out, err
+		}
+	}
+	return "", fmt.Errorf("no disk prep pod on node %s", nodeName)
+}
+
+// WipeDevice removes any signatures from the device and discards its
+// contents, so a pool can be created on it as if it were pristine.
+// Devices which do not support discard have their first MiB zeroed
+// instead, which removes the superblocks pool creation would trip on.
+func (d *DiskPrep) WipeDevice(nodeName string, device string) error {
+	logmerge.RecordAction("wiping device %s on node %s", device, nodeName)
+	script := fmt.Sprintf(`
+set -e
+test -b %[1]s
+wipefs -a %[1]s
+blkdiscard %[1]s 2>/dev/null || dd if=/dev/zero of=%[1]s bs=1M count=1 conv=fsync
+`, device)
+	_, err := d.execOnNode(nodeName, script)
+	return err
+}
+
+// PartitionDevice replaces the partition table of the device with a GPT
+// one holding the given number of equally sized partitions, and returns
+// once the kernel has re-read it.
+func (d *DiskPrep) PartitionDevice(nodeName string, device string, partitions int) error {
+	if partitions < 1 {
+		return fmt.Errorf("invalid partition count %d for device %s", partitions, device)
+	}
+	logmerge.RecordAction("partitioning device %s on node %s into %d", device, nodeName, partitions)
+	script := fmt.Sprintf(`
+set -e
+test -b %[1]s
+parted -s %[1]s mklabel gpt
+step=$((100 / %[2]d))
+start=0
+for ix in $(seq 1 %[2]d); do
+	end=$((start + step))
+	parted -s %[1]s mkpart p$ix "$start%%" "$end%%"
+	start=$end
+done
+partprobe %[1]s
+`, device, partitions)
+	_, err := d.execOnNode(nodeName, script)
+	return err
+}
+
+// CreateLoopDevice creates a sparse backing file of the given size on
+// the node and attaches it to a free loop device, returning the loop
+// device path for use as a pool disk.
+func (d *DiskPrep) CreateLoopDevice(nodeName string, filePath string, sizeMb int) (string, error) {
+	logmerge.RecordAction("creating %dMiB loop device backed by %s on node %s", sizeMb, filePath, nodeName)
+	script := fmt.Sprintf(`
+set -e
+truncate -s %[2]dM %[1]s
+losetup -f --show %[1]s
+`, filePath, sizeMb)
+	out, err := d.execOnNode(nodeName, script)
+	if err != nil {
+		return "", err
+	}
+	device := strings.TrimSpace(out)
+	if !strings.HasPrefix(device, "/dev/loop") {
+		return "", fmt.Errorf("unexpected losetup output %q on node %s", out, nodeName)
+	}
+	return device, nil
+}
+
+// RemoveLoopDevice detaches the loop device and removes its backing
+// file.
+func (d *DiskPrep) RemoveLoopDevice(nodeName string, device string, filePath string) error {
+	logmerge.RecordAction("removing loop device %s on node %s", device, nodeName)
+	script := fmt.Sprintf(`
+losetup -d %s 2>/dev/null || true
+rm -f %s
+`, device, filePath)
+	_, err := d.execOnNode(nodeName, script)
+	return err
+}
+
+// CreateAioFile creates a file of the given size on the node for use
+// behind an aio:// or uring:// pool URI, and returns the file path.
+func (d *DiskPrep) CreateAioFile(nodeName string, filePath string, sizeMb int) (string, error) {
+	logmerge.RecordAction("creating %dMiB aio file %s on node %s", sizeMb, filePath, nodeName)
+	_, err := d.execOnNode(nodeName, fmt.Sprintf("set -e; truncate -s %dM %s", sizeMb, filePath))
+	if err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// CreateLvmVolume creates a volume group on the device and a logical
+// volume of the given size in it, returning the mapper path for use as
+// a pool disk.
+func (d *DiskPrep) CreateLvmVolume(nodeName string, device string, vgName string, lvName string, sizeMb int) (string, error) {
+	logmerge.RecordAction("creating LV %s/%s on device %s on node %s", vgName, lvName, device, nodeName)
+	script := fmt.Sprintf(`
+set -e
+test -b %[1]s
+pvcreate -f %[1]s
+vgcreate %[2]s %[1]s
+lvcreate -y -L %[4]dM -n %[3]s %[2]s
+`, device, vgName, lvName, sizeMb)
+	if _, err := d.execOnNode(nodeName, script); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/dev/%s/%s", vgName, lvName), nil
+}
+
+// RemoveLvmVolume removes the volume group and its physical volume,
+// releasing the device.
+func (d *DiskPrep) RemoveLvmVolume(nodeName string, device string, vgName string) error {
+	logmerge.RecordAction("removing VG %s on node %s", vgName, nodeName)
+	script := fmt.Sprintf(`
+vgremove -f %[2]s 2>/dev/null || true
+pvremove -f %[1]s 2>/dev/null || true
+`, device, vgName)
+	_, err := d.execOnNode(nodeName, script)
+	return err
+}
+
+// VerifyDeviceReleased verifies nothing on the node holds the device
+// open any more - no process, no kernel holder such as a device mapper
+// target - so an uninstall really released the pool disks.
+func (d *DiskPrep) VerifyDeviceReleased(nodeName string, device string) error {
+	script := fmt.Sprintf(`
+set -e
+test -b %[1]s
+holders=$(ls /sys/class/block/$(basename %[1]s)/holders 2>/dev/null)
+test -z "$holders" || { echo "held by: $holders"; exit 1; }
+fuser -s %[1]s && { echo "open by a process"; exit 1; } || true
+`, device)
+	out, err := d.execOnNode(nodeName, script)
+	if err != nil {
+		return fmt.Errorf("device %s on node %s not released: %s, error %v",
+			device, nodeName, strings.TrimSpace(out), err)
+	}
+	return nil
+}
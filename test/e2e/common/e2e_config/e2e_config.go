@@ -0,0 +1,637 @@
+package e2e_config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// E2EConfig is the top level configuration object for an e2e test run.
+// Values are loaded from a yaml configuration file, the location of which
+// may be overridden with the e2e_config_file environment variable.
+// Fields may in turn be overridden individually from the environment.
+// IoSoakJobClass defines one class of soak workload: the storage class
+// parameters its volumes are provisioned with and how many volumes of
+// the class run concurrently.
+type IoSoakJobClass struct {
+	Name     string `yaml:"name"`
+	Protocol string `yaml:"protocol"`
+	Replicas int    `yaml:"replicas"`
+	FsType   string `yaml:"fsType"`
+	Thin     bool   `yaml:"thin"`
+	Volumes  int    `yaml:"volumes"`
+}
+
+// FioDutyCycle throttles one soak fio job: fio pauses for
+// ThinkTimeUsecs microseconds after every ThinkTimeBlocks blocks.
+type FioDutyCycle struct {
+	ThinkTimeUsecs  int `yaml:"thinkTimeUsecs"`
+	ThinkTimeBlocks int `yaml:"thinkTimeBlocks"`
+}
+
+// RestartAllowance tolerates container restarts of the mayastor pods
+// whose names match the Pods regular expression, up to Count restarts
+// per spec.
+type RestartAllowance struct {
+	Pods  string `yaml:"pods"`
+	Count int    `yaml:"count"`
+}
+
+// ChaosAction is one fault type in the chaos schedule with its relative
+// weight in the random draw: "pod-kill", "network-loss", "node-reboot"
+// or "pool-device-error".
+type ChaosAction struct {
+	Type   string `yaml:"type"`
+	Weight int    `yaml:"weight"`
+}
+
+// ClusterConfig names one additional cluster the harness can target
+// besides the default one, for suites driving more than one cluster
+// from a single test process. An empty KubeConfig falls back to the
+// standard kubeconfig lookup, an empty Context to the current context
+// of the kubeconfig.
+type ClusterConfig struct {
+	Name       string `yaml:"name"`
+	KubeConfig string `yaml:"kubeConfig"`
+	Context    string `yaml:"context"`
+}
+
+type E2EConfig struct {
+	// Registry from which mayastor images are retrieved
+	Registry string `yaml:"registry" env:"e2e_docker_registry"`
+	// KubeConfig and KubeContext select the kubeconfig file and context
+	// the harness targets; empty values fall back to the standard
+	// kubeconfig lookup and its current context.
+	KubeConfig  string `yaml:"kubeConfig" env:"e2e_kubeconfig"`
+	KubeContext string `yaml:"kubeContext" env:"e2e_kube_context"`
+	// Clusters names additional clusters the harness can connect to, see
+	// ClusterConfig.
+	Clusters []ClusterConfig `yaml:"clusters"`
+	// ImageTag is the tag applied to mayastor images under test
+	ImageTag string `yaml:"imageTag" env:"e2e_image_tag"`
+	// PoolDevice is the block device used when creating mayastor pools
+	PoolDevice string `yaml:"poolDevice" env:"e2e_pool_device"`
+	// PoolDevices lists pool backing devices per node, overriding
+	// PoolDevice for the listed nodes; a node with several entries gets
+	// one pool per entry. Entries are bare device paths or pool URIs
+	// with an explicit bdev scheme - "aio://", "uring://", "nvme://" -
+	// passed through to the pool as given.
+	PoolDevices map[string][]string `yaml:"poolDevices"`
+	// E2eFioImage is the fio image used by tests that generate IO
+	E2eFioImage string `yaml:"e2eFioImage" env:"e2e_fio_image"`
+	// E2eCscImage is the image carrying the csc CSI command line client,
+	// used to probe the node plugin over its socket
+	E2eCscImage string `yaml:"e2eCscImage" env:"e2e_csc_image"`
+	// E2eSqliteImage is the image carrying sqlite, used by the database
+	// style volume workload
+	E2eSqliteImage string `yaml:"e2eSqliteImage" env:"e2e_sqlite_image"`
+	// DefaultReplicaCount is the replica count used when a test does
+	// not specify one explicitly
+	DefaultReplicaCount int `yaml:"defaultReplicaCount" env:"e2e_default_replica_count"`
+	// DefaultWorkload names the volume workload generator used by suites
+	// which do not mandate a specific one: "fio", "dd" or "sqlite"
+	DefaultWorkload string `yaml:"defaultWorkload" env:"e2e_default_workload"`
+	// FsTypes is the list of filesystems the volume I/O matrix is run
+	// against
+	FsTypes []string `yaml:"fsTypes" env:"e2e_fs_types"`
+	// Protocols is the list of share protocols the volume I/O matrix is
+	// run against: "nvmf" and/or "iscsi"
+	Protocols []string `yaml:"protocols" env:"e2e_protocols"`
+	// BindingModes is the list of volume binding modes the volume I/O
+	// matrix is run against: "immediate" and/or "late"
+	BindingModes []string `yaml:"bindingModes" env:"e2e_binding_modes"`
+	// Platform describes the infrastructure hosting the cluster nodes,
+	// used by node power fault injection: "libvirt", "aws" or "gcp".
+	// Suites needing power faults skip when no platform is configured.
+	Platform struct {
+		Type    string `yaml:"type" env:"e2e_platform_type"`
+		Libvirt struct {
+			// Uri is the libvirt connection URI, empty for the local
+			// hypervisor
+			Uri string `yaml:"uri"`
+			// DomainPrefix prepended to node names gives the domain
+			// names of the cluster virtual machines
+			DomainPrefix string `yaml:"domainPrefix"`
+		} `yaml:"libvirt"`
+		Aws struct {
+			Region string `yaml:"region"`
+		} `yaml:"aws"`
+		Gcp struct {
+			Project string `yaml:"project"`
+			Zone    string `yaml:"zone"`
+		} `yaml:"gcp"`
+	} `yaml:"platform"`
+	// Install selects how mayastor is deployed: "yaml" (the default)
+	// applies the generated deployment manifests, "helm" installs the
+	// mayastor helm chart, so CI can validate both install paths.
+	Install struct {
+		Method string `yaml:"method" env:"e2e_install_method"`
+		Helm   struct {
+			// Chart is the chart reference or local chart directory
+			Chart string `yaml:"chart" env:"e2e_install_helm_chart"`
+			// Release is the helm release name
+			Release string `yaml:"release"`
+			// EtcdReplicas overrides the chart etcd replica count,
+			// 0 => chart default
+			EtcdReplicas int `yaml:"etcdReplicas"`
+			// IoEngineCpuLimit and IoEngineMemoryLimit override the
+			// io-engine container resources, empty => chart default
+			IoEngineCpuLimit    string `yaml:"ioEngineCpuLimit"`
+			IoEngineMemoryLimit string `yaml:"ioEngineMemoryLimit"`
+		} `yaml:"helm"`
+	} `yaml:"install"`
+	// ImagePullPolicy applied to the mayastor deployment images
+	ImagePullPolicy string `yaml:"imagePullPolicy" env:"e2e_image_pull_policy"`
+	// AirGap configures air-gapped installs: all images, mayastor and
+	// test workloads alike, are pulled from the in-cluster registry
+	// into which CI mirrored them, and tests run with egress blocked.
+	AirGap struct {
+		Enabled bool `yaml:"enabled" env:"e2e_air_gap_enabled"`
+		// Registry is the in-cluster registry holding the mirrored
+		// images
+		Registry string `yaml:"registry"`
+	} `yaml:"airGap"`
+	// Cores is the number of cpu cores assigned to each io-engine
+	Cores int `yaml:"cores" env:"e2e_cores"`
+	// Uninstall cleanup behaviour, 0 => no cleanup on failure
+	Cleanup int `yaml:"cleanup" env:"e2e_uninstall_cleanup"`
+	// DataDirs are the host directories mayastor keeps local state
+	// under, verified clean after uninstall when cleanup is enabled
+	DataDirs []string `yaml:"dataDirs"`
+	// ReportsDir is the directory where test artefacts are written,
+	// empty => no artefacts are generated
+	ReportsDir string `yaml:"reportsDir" env:"e2e_reports_dir"`
+	// FlakeAttempts is the number of times a failed spec is run before
+	// it is reported as failed, 1 => no retries. Specs which pass on a
+	// retry are marked as flaky in the junit output.
+	FlakeAttempts int `yaml:"flakeAttempts" env:"e2e_flake_attempts"`
+	// Canary configures the background canary volume kept alive across
+	// suite boundaries with a trickle IO workload.
+	Canary struct {
+		Enabled bool `yaml:"enabled" env:"e2e_canary_enabled"`
+		// IntervalSecs is the period of the trickle IO probe
+		IntervalSecs int `yaml:"intervalSecs"`
+		// ReplicaCount for the canary volume, 0 => the default count
+		ReplicaCount int `yaml:"replicaCount"`
+	} `yaml:"canary"`
+	// Telemetry configures the call-home opt-out verification. The
+	// telemetry_opt_out suite is skipped unless PodSelector identifies
+	// a telemetry component.
+	Telemetry struct {
+		// PodSelector is a label selector matching the telemetry pods
+		PodSelector string `yaml:"podSelector"`
+		// Deployment is the name of the telemetry deployment
+		Deployment string `yaml:"deployment"`
+		// DisableEnvVar is the environment variable which, when set to
+		// "true" on the deployment, opts out of call-home
+		DisableEnvVar string `yaml:"disableEnvVar"`
+		// ProbeSeconds is the length of the window over which call-home
+		// attempts are counted
+		ProbeSeconds int `yaml:"probeSeconds"`
+	} `yaml:"telemetry"`
+	// BeforeEachCheck configures the pristine-cluster check run before
+	// each spec.
+	BeforeEachCheck struct {
+		// Strictness on finding leftovers from a previous suite:
+		// "strict" => fail, "remediate" => targeted cleanup with a
+		// bounded wait, "warn" => log and continue
+		Strictness string `yaml:"strictness" env:"e2e_before_each_check_strictness"`
+		// RemediateTimeoutSecs bounds the remediation wait
+		RemediateTimeoutSecs int `yaml:"remediateTimeoutSecs"`
+	} `yaml:"beforeEachCheck"`
+	// AfterEachCheck configures the per-spec resource leak accounting
+	// driven by the helper level ownership tracking.
+	AfterEachCheck struct {
+		// AutoClean deletes the resources a spec leaked instead of
+		// failing the spec
+		AutoClean bool `yaml:"autoClean" env:"e2e_after_each_check_auto_clean"`
+		// RestartTolerance lists mayastor container restarts tolerated
+		// by the per-spec restart accounting for every spec of a run,
+		// see RestartAllowance; suites which restart components
+		// deliberately install theirs with a configuration override
+		// scope. Per-spec budgets go through AllowRestarts instead.
+		RestartTolerance []RestartAllowance `yaml:"restartTolerance"`
+	} `yaml:"afterEachCheck"`
+	// ForceDeleteStuckPods allows DeleteStuckTerminatingPods to
+	// escalate to force deletion after diagnosing a stuck pod. Off by
+	// default so CI runs preserve the evidence of teardown hangs.
+	ForceDeleteStuckPods bool `yaml:"forceDeleteStuckPods" env:"e2e_force_delete_stuck_pods"`
+	// PoolConsistency configures the pool accounting consistency
+	// checker which compares CRD, REST API and Prometheus figures.
+	PoolConsistency struct {
+		// RestPort is the control plane REST API port on the moac pod
+		RestPort int `yaml:"restPort"`
+		// MetricsPort is the Prometheus port on the io-engine pods
+		MetricsPort int `yaml:"metricsPort"`
+		// TolerancePercent is the allowed disagreement between sources
+		TolerancePercent int `yaml:"tolerancePercent"`
+	} `yaml:"poolConsistency"`
+	// IoEngine injects additional configuration into the io-engine
+	// container at install time, for configuration-matrix testing.
+	IoEngine struct {
+		// Env are extra environment variables, e.g. log level
+		Env map[string]string `yaml:"env"`
+		// Args are extra command line arguments, e.g. the nvmf port
+		Args []string `yaml:"args"`
+	} `yaml:"ioEngine"`
+	// InstallRollout configures the per-node rollout tracking used
+	// when waiting for the io-engine daemonset on large clusters.
+	InstallRollout struct {
+		// PercentileNodes is the percentage of nodes which must be
+		// ready within PercentileTimeoutSecs
+		PercentileNodes int `yaml:"percentileNodes"`
+		// PercentileTimeoutSecs is the budget for the percentile
+		PercentileTimeoutSecs int `yaml:"percentileTimeoutSecs"`
+		// MaxTimeoutSecs is the budget for every node
+		MaxTimeoutSecs int `yaml:"maxTimeoutSecs"`
+	} `yaml:"installRollout"`
+	// Availability configures the per-volume availability accounting
+	// emitted to the run manifest by the availability monitor.
+	Availability struct {
+		// MinPercent gates CI: any volume below this availability
+		// fails the suite teardown, 0 => no gating
+		MinPercent float64 `yaml:"minPercent"`
+	} `yaml:"availability"`
+	// IoSoak configures the io_soak suite: how long the soak runs and
+	// the mix of job classes, each with its own storage class
+	// parameters, so one soak run can combine e.g. single replica thin
+	// volumes with three replica thick ones.
+	IoSoak struct {
+		DurationSecs int              `yaml:"durationSecs"`
+		JobClasses   []IoSoakJobClass `yaml:"jobClasses"`
+		// FioDutyCycles is the table of duty cycles soak jobs draw from
+		// at random, throttling each job to a different rate; an empty
+		// table runs every job unthrottled.
+		FioDutyCycles []FioDutyCycle `yaml:"fioDutyCycles"`
+		// Seed fixes the random duty cycle draw, to reproduce the job
+		// mix of an earlier run from its reported seed; 0 seeds from
+		// the clock.
+		Seed int64 `yaml:"seed" env:"e2e_io_soak_seed"`
+	} `yaml:"ioSoak"`
+	// Chaos configures the fault schedule applied during soak runs:
+	// faults are drawn at random from the weighted action list at random
+	// intervals within the configured bounds. Every injected fault is
+	// logged with a timestamp for correlation with volume state changes.
+	Chaos struct {
+		Enabled bool `yaml:"enabled" env:"e2e_chaos_enabled"`
+		// MinIntervalSecs and MaxIntervalSecs bound the random pause
+		// between consecutive faults
+		MinIntervalSecs int `yaml:"minIntervalSecs"`
+		MaxIntervalSecs int `yaml:"maxIntervalSecs"`
+		// Seed makes a schedule reproducible, 0 => time seeded
+		Seed int64 `yaml:"seed" env:"e2e_chaos_seed"`
+		// Actions is the weighted fault mix
+		Actions []ChaosAction `yaml:"actions"`
+	} `yaml:"chaos"`
+	// RestClient configures retry behaviour of the typed control plane
+	// REST API client.
+	RestClient struct {
+		// Retries is the number of repeat attempts after a failed
+		// request
+		Retries int `yaml:"retries"`
+		// BackoffMsecs is the initial backoff, doubled per attempt
+		BackoffMsecs int `yaml:"backoffMsecs"`
+		TimeoutSecs  int `yaml:"timeoutSecs"`
+	} `yaml:"restClient"`
+	// ApiChurn budgets the control plane noise generated by the
+	// mayastor components: kubernetes events and custom resource
+	// writes per minute, sustained over a suite. 0 disables a budget.
+	ApiChurn struct {
+		MaxEventsPerMinute float64 `yaml:"maxEventsPerMinute"`
+		MaxWritesPerMinute float64 `yaml:"maxWritesPerMinute"`
+	} `yaml:"apiChurn"`
+	// LeakCheck configures the harness self-check comparing goroutine
+	// and connection footprints at suite start and end.
+	LeakCheck struct {
+		// GoroutineSlack tolerates runtime-internal goroutine count
+		// variance between the snapshots
+		GoroutineSlack int `yaml:"goroutineSlack"`
+		// WarnOnly logs instead of failing the suite teardown
+		WarnOnly bool `yaml:"warnOnly" env:"e2e_leak_check_warn_only"`
+	} `yaml:"leakCheck"`
+	// Loki configures the log query client; with no endpoint configured
+	// log queries fall back to reading the pod logs directly.
+	Loki struct {
+		// Endpoint is the base URL of the Loki server, e.g.
+		// http://loki.monitoring:3100
+		Endpoint string `yaml:"endpoint" env:"e2e_loki_endpoint"`
+	} `yaml:"loki"`
+	// Upgrade configures the upgrade framework: the image tag the
+	// cluster is installed with and the tag it is upgraded to. The
+	// upgrade suite is skipped when either tag is empty.
+	Upgrade struct {
+		FromTag string `yaml:"fromTag" env:"e2e_upgrade_from_tag"`
+		ToTag   string `yaml:"toTag" env:"e2e_upgrade_to_tag"`
+	} `yaml:"upgrade"`
+	// Timeouts centralises the wait budgets of the common helpers, in
+	// seconds, so slow hardware can be accommodated by configuration
+	// rather than by editing suite sources. The common/timeouts package
+	// is the accessor.
+	Timeouts struct {
+		// PvcBindSecs is the wait for a claim to bind
+		PvcBindSecs int `yaml:"pvcBindSecs" env:"e2e_timeout_pvc_bind_secs"`
+		// PodReadySecs is the wait for a test pod to run
+		PodReadySecs int `yaml:"podReadySecs" env:"e2e_timeout_pod_ready_secs"`
+		// UninstallSecs bounds a mayastor uninstall
+		UninstallSecs int `yaml:"uninstallSecs" env:"e2e_timeout_uninstall_secs"`
+	} `yaml:"timeouts"`
+	// Rebuild configures the time budget for replica rebuilds.
+	Rebuild struct {
+		// SecsPerGib is the rebuild time allowance per GiB of volume
+		// size; suites derive their rebuild timeouts from it.
+		SecsPerGib int `yaml:"secsPerGib" env:"e2e_rebuild_secs_per_gib"`
+	} `yaml:"rebuild"`
+	// HaFailover configures the nexus failover suite.
+	HaFailover struct {
+		// SwitchoverTimeoutSecs is the SLO for the volume target to be
+		// republished on another node after the nexus node goes down
+		SwitchoverTimeoutSecs int `yaml:"switchoverTimeoutSecs" env:"e2e_ha_switchover_timeout_secs"`
+	} `yaml:"haFailover"`
+	// NvmfTls configures the nvmf transport security test hooks.
+	// The nvmf_tls suite is skipped unless Enabled is set, pending
+	// engine support for configurable transport security.
+	NvmfTls struct {
+		Enabled bool `yaml:"enabled" env:"e2e_nvmf_tls_enabled"`
+		// MaxOverheadPercent is the tolerated loss of fio bandwidth
+		// on an encrypted transport relative to a plain one
+		MaxOverheadPercent int `yaml:"maxOverheadPercent"`
+	} `yaml:"nvmfTls"`
+	// CsiSanity configures the csi-sanity conformance run of the
+	// csi_sanity suite.
+	CsiSanity struct {
+		// Image is the image carrying the csi-sanity binary.
+		Image string `yaml:"image" env:"e2e_csi_sanity_image"`
+		// ControllerSocketDir is the host directory holding the CSI
+		// controller socket published by moac.
+		ControllerSocketDir string `yaml:"controllerSocketDir"`
+		// TimeoutSecs bounds the whole csi-sanity run.
+		TimeoutSecs int `yaml:"timeoutSecs"`
+	} `yaml:"csiSanity"`
+}
+
+var loadOnce sync.Once
+var mutex sync.RWMutex
+var e2eConfig E2EConfig
+
+// overrideScopes is a stack of override layers applied on top of the
+// loaded configuration, innermost scope last. Scopes let a suite adjust
+// parameters programmatically, e.g. a longer fio run time, without
+// mutating the shared configuration; helpers reading the configuration
+// through GetConfig observe the overrides of the active scopes.
+var overrideScopes []func(*E2EConfig)
+
+// GetConfig returns the effective configuration: the loaded
+// configuration with the active override scopes applied, as a copy.
+// Safe for concurrent use.
+//
+// This function is called early from junit and various bits have not been initialised yet
+// so we cannot use logf or Expect instead we use fmt.Print... and panic.
+func GetConfig() E2EConfig {
+	loadOnce.Do(loadConfig)
+	mutex.RLock()
+	defer mutex.RUnlock()
+	cfg := e2eConfig
+	for _, scope := range overrideScopes {
+		scope(&cfg)
+	}
+	return cfg
+}
+
+// PoolDevicesForNode returns the pool backing devices for a node: the
+// per-node list when one is configured, the single PoolDevice
+// otherwise.
+func (cfg E2EConfig) PoolDevicesForNode(nodeName string) []string {
+	if devices := cfg.PoolDevices[nodeName]; len(devices) != 0 {
+		return devices
+	}
+	return []string{cfg.PoolDevice}
+}
+
+// PushOverrideScope applies an override layer on top of the current
+// configuration; typically called from BeforeSuite, paired with
+// PopOverrideScope in AfterSuite.
+func PushOverrideScope(modify func(*E2EConfig)) {
+	loadOnce.Do(loadConfig)
+	mutex.Lock()
+	defer mutex.Unlock()
+	overrideScopes = append(overrideScopes, modify)
+}
+
+// PopOverrideScope removes the innermost override layer.
+func PopOverrideScope() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(overrideScopes) == 0 {
+		panic("PopOverrideScope called with no active override scope")
+	}
+	overrideScopes = overrideScopes[:len(overrideScopes)-1]
+}
+
+func loadConfig() {
+	// Set defaults.
+	e2eConfig.PoolDevice = "/dev/sdb"
+	e2eConfig.ImageTag = "ci"
+	e2eConfig.Registry = "ci-registry.mayastor-ci.mayadata.io"
+	e2eConfig.E2eFioImage = "dmonakhov/alpine-fio"
+	e2eConfig.E2eCscImage = "mayadata/e2e-csc"
+	e2eConfig.E2eSqliteImage = "mayadata/e2e-sqlite"
+	e2eConfig.DefaultReplicaCount = 2
+	e2eConfig.DefaultWorkload = "fio"
+	e2eConfig.FsTypes = []string{"ext4", "xfs"}
+	e2eConfig.Protocols = []string{"nvmf"}
+	e2eConfig.BindingModes = []string{"immediate"}
+	e2eConfig.Cores = 2
+	e2eConfig.ImagePullPolicy = "Always"
+	e2eConfig.Install.Method = "yaml"
+	e2eConfig.Install.Helm.Release = "mayastor"
+	e2eConfig.Cleanup = 0
+	e2eConfig.DataDirs = []string{"/var/local/mayastor"}
+	e2eConfig.Timeouts.PvcBindSecs = 120
+	e2eConfig.Timeouts.PodReadySecs = 240
+	e2eConfig.Timeouts.UninstallSecs = 900
+	e2eConfig.Rebuild.SecsPerGib = 120
+	e2eConfig.HaFailover.SwitchoverTimeoutSecs = 120
+	e2eConfig.NvmfTls.MaxOverheadPercent = 20
+	e2eConfig.LeakCheck.GoroutineSlack = 12
+	e2eConfig.RestClient.Retries = 3
+	e2eConfig.RestClient.BackoffMsecs = 500
+	e2eConfig.RestClient.TimeoutSecs = 30
+	e2eConfig.IoSoak.DurationSecs = 120
+	e2eConfig.IoSoak.JobClasses = []IoSoakJobClass{
+		{Name: "nvmf-thick", Protocol: "nvmf", Replicas: 2, FsType: "ext4", Volumes: 2},
+		{Name: "nvmf-thin-single", Protocol: "nvmf", Replicas: 1, FsType: "ext4", Thin: true, Volumes: 2},
+	}
+	e2eConfig.IoSoak.FioDutyCycles = []FioDutyCycle{
+		{ThinkTimeUsecs: 500000, ThinkTimeBlocks: 1000},
+		{ThinkTimeUsecs: 750000, ThinkTimeBlocks: 1000},
+		{ThinkTimeUsecs: 1250000, ThinkTimeBlocks: 1500},
+		{ThinkTimeUsecs: 1500000, ThinkTimeBlocks: 2000},
+	}
+	e2eConfig.Chaos.MinIntervalSecs = 30
+	e2eConfig.Chaos.MaxIntervalSecs = 120
+	e2eConfig.Chaos.Actions = []ChaosAction{
+		{Type: "pod-kill", Weight: 3},
+		{Type: "network-loss", Weight: 1},
+	}
+	e2eConfig.FlakeAttempts = 1
+	e2eConfig.Canary.IntervalSecs = 30
+	e2eConfig.BeforeEachCheck.Strictness = "strict"
+	e2eConfig.BeforeEachCheck.RemediateTimeoutSecs = 120
+	e2eConfig.InstallRollout.PercentileNodes = 90
+	e2eConfig.InstallRollout.PercentileTimeoutSecs = 540
+	e2eConfig.InstallRollout.MaxTimeoutSecs = 900
+	e2eConfig.PoolConsistency.RestPort = 3000
+	e2eConfig.PoolConsistency.MetricsPort = 9502
+	e2eConfig.PoolConsistency.TolerancePercent = 5
+	e2eConfig.Telemetry.DisableEnvVar = "DISABLE_CALL_HOME"
+	e2eConfig.Telemetry.ProbeSeconds = 60
+	e2eConfig.CsiSanity.Image = "mayadata/e2e-csi-sanity"
+	e2eConfig.CsiSanity.ControllerSocketDir = "/var/lib/csi/sockets/pluginproxy"
+	e2eConfig.CsiSanity.TimeoutSecs = 600
+
+	configFile := os.Getenv("e2e_config_file")
+	if configFile == "" {
+		_, filename, _, _ := runtime.Caller(0)
+		configFile = path.Join(path.Dir(filename), "..", "..", "configurations", "default_config.yaml")
+	}
+	if _, err := os.Stat(configFile); err == nil {
+		contents, err := os.ReadFile(configFile)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to read configuration file %s, error %v", configFile, err))
+		}
+		if err := yaml.Unmarshal(contents, &e2eConfig); err != nil {
+			panic(fmt.Sprintf("Failed to parse configuration file %s, error %v", configFile, err))
+		}
+	}
+
+	// Environment variables take precedence over the configuration file.
+	if val := os.Getenv("e2e_docker_registry"); val != "" {
+		e2eConfig.Registry = val
+	}
+	if val := os.Getenv("e2e_kubeconfig"); val != "" {
+		e2eConfig.KubeConfig = val
+	}
+	if val := os.Getenv("e2e_kube_context"); val != "" {
+		e2eConfig.KubeContext = val
+	}
+	if val := os.Getenv("e2e_image_tag"); val != "" {
+		e2eConfig.ImageTag = val
+	}
+	if val := os.Getenv("e2e_pool_device"); val != "" {
+		e2eConfig.PoolDevice = val
+	}
+	if val := os.Getenv("e2e_image_pull_policy"); val != "" {
+		e2eConfig.ImagePullPolicy = val
+	}
+	if val := os.Getenv("e2e_air_gap_enabled"); val != "" {
+		e2eConfig.AirGap.Enabled = val == "true"
+	}
+	if val := os.Getenv("e2e_reports_dir"); val != "" {
+		e2eConfig.ReportsDir = val
+	}
+	if val := os.Getenv("e2e_before_each_check_strictness"); val != "" {
+		e2eConfig.BeforeEachCheck.Strictness = val
+	}
+	if val := os.Getenv("e2e_after_each_check_auto_clean"); val != "" {
+		e2eConfig.AfterEachCheck.AutoClean = val == "true"
+	}
+	if val := os.Getenv("e2e_force_delete_stuck_pods"); val != "" {
+		e2eConfig.ForceDeleteStuckPods = val == "true"
+	}
+	if val := os.Getenv("e2e_leak_check_warn_only"); val != "" {
+		e2eConfig.LeakCheck.WarnOnly = val == "true"
+	}
+	if val := os.Getenv("e2e_default_workload"); val != "" {
+		e2eConfig.DefaultWorkload = val
+	}
+	if val := os.Getenv("e2e_fs_types"); val != "" {
+		e2eConfig.FsTypes = strings.Split(val, ",")
+	}
+	if val := os.Getenv("e2e_protocols"); val != "" {
+		e2eConfig.Protocols = strings.Split(val, ",")
+	}
+	if val := os.Getenv("e2e_binding_modes"); val != "" {
+		e2eConfig.BindingModes = strings.Split(val, ",")
+	}
+	if val := os.Getenv("e2e_platform_type"); val != "" {
+		e2eConfig.Platform.Type = val
+	}
+	if val := os.Getenv("e2e_install_method"); val != "" {
+		e2eConfig.Install.Method = val
+	}
+	if val := os.Getenv("e2e_install_helm_chart"); val != "" {
+		e2eConfig.Install.Helm.Chart = val
+	}
+	// In air-gapped mode every image, test workloads included, is
+	// pulled from the in-cluster mirror registry.
+	if e2eConfig.AirGap.Enabled && e2eConfig.AirGap.Registry != "" {
+		parts := strings.Split(e2eConfig.E2eFioImage, "/")
+		e2eConfig.E2eFioImage = e2eConfig.AirGap.Registry + "/" + parts[len(parts)-1]
+	}
+	if val := os.Getenv("e2e_chaos_enabled"); val != "" {
+		e2eConfig.Chaos.Enabled = val == "true"
+	}
+	if val := os.Getenv("e2e_chaos_seed"); val != "" {
+		seed, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("invalid value %q for e2e_chaos_seed", val))
+		}
+		e2eConfig.Chaos.Seed = seed
+	}
+	if val := os.Getenv("e2e_io_soak_seed"); val != "" {
+		seed, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("invalid value %q for e2e_io_soak_seed", val))
+		}
+		e2eConfig.IoSoak.Seed = seed
+	}
+	if val := os.Getenv("e2e_loki_endpoint"); val != "" {
+		e2eConfig.Loki.Endpoint = val
+	}
+	if val := os.Getenv("e2e_upgrade_from_tag"); val != "" {
+		e2eConfig.Upgrade.FromTag = val
+	}
+	if val := os.Getenv("e2e_upgrade_to_tag"); val != "" {
+		e2eConfig.Upgrade.ToTag = val
+	}
+	for envVar, setting := range map[string]*int{
+		"e2e_timeout_pvc_bind_secs":  &e2eConfig.Timeouts.PvcBindSecs,
+		"e2e_timeout_pod_ready_secs": &e2eConfig.Timeouts.PodReadySecs,
+		"e2e_timeout_uninstall_secs": &e2eConfig.Timeouts.UninstallSecs,
+	} {
+		if val := os.Getenv(envVar); val != "" {
+			secs, err := strconv.Atoi(val)
+			if err != nil || secs < 1 {
+				panic(fmt.Sprintf("invalid value %q for %s", val, envVar))
+			}
+			*setting = secs
+		}
+	}
+	if val := os.Getenv("e2e_rebuild_secs_per_gib"); val != "" {
+		secs, err := strconv.Atoi(val)
+		if err != nil || secs < 1 {
+			panic(fmt.Sprintf("invalid value %q for e2e_rebuild_secs_per_gib", val))
+		}
+		e2eConfig.Rebuild.SecsPerGib = secs
+	}
+	if val := os.Getenv("e2e_ha_switchover_timeout_secs"); val != "" {
+		secs, err := strconv.Atoi(val)
+		if err != nil || secs < 1 {
+			panic(fmt.Sprintf("invalid value %q for e2e_ha_switchover_timeout_secs", val))
+		}
+		e2eConfig.HaFailover.SwitchoverTimeoutSecs = secs
+	}
+	if val := os.Getenv("e2e_flake_attempts"); val != "" {
+		attempts, err := strconv.Atoi(val)
+		if err != nil || attempts < 1 {
+			panic(fmt.Sprintf("invalid value %q for e2e_flake_attempts", val))
+		}
+		e2eConfig.FlakeAttempts = attempts
+	}
+}
@@ -0,0 +1,64 @@
+package faults
+
+import (
+	"fmt"
+	"strings"
+)
+
+// awsPlatform drives the aws command line client. Instances are located
+// by their Name tag, which CI sets to the kubernetes node name.
+type awsPlatform struct {
+	region string
+}
+
+func (p *awsPlatform) Name() string {
+	return "aws"
+}
+
+// instanceId resolves the EC2 instance carrying the given node.
+func (p *awsPlatform) instanceId(nodeName string) (string, error) {
+	out, err := runCommand("aws", "ec2", "describe-instances",
+		"--region", p.region,
+		"--filters", "Name=tag:Name,Values="+nodeName, "Name=instance-state-name,Values=running,stopped",
+		"--query", "Reservations[].Instances[].InstanceId",
+		"--output", "text")
+	if err != nil {
+		return "", err
+	}
+	instanceId := strings.TrimSpace(out)
+	if instanceId == "" || strings.ContainsAny(instanceId, " \t\n") {
+		return "", fmt.Errorf("no unique EC2 instance found for node %s, got %q", nodeName, instanceId)
+	}
+	return instanceId, nil
+}
+
+// instanceCommand resolves the node's instance and applies one ec2
+// subcommand to it.
+func (p *awsPlatform) instanceCommand(command string, nodeName string) error {
+	instanceId, err := p.instanceId(nodeName)
+	if err != nil {
+		return err
+	}
+	_, err = runCommand("aws", "ec2", command,
+		"--region", p.region, "--instance-ids", instanceId)
+	return err
+}
+
+func (p *awsPlatform) PowerOffNode(nodeName string) error {
+	instanceId, err := p.instanceId(nodeName)
+	if err != nil {
+		return err
+	}
+	// --force skips the orderly guest shutdown, as a crash would.
+	_, err = runCommand("aws", "ec2", "stop-instances",
+		"--region", p.region, "--instance-ids", instanceId, "--force")
+	return err
+}
+
+func (p *awsPlatform) PowerOnNode(nodeName string) error {
+	return p.instanceCommand("start-instances", nodeName)
+}
+
+func (p *awsPlatform) RebootNode(nodeName string) error {
+	return p.instanceCommand("reboot-instances", nodeName)
+}
@@ -0,0 +1,69 @@
+package faults
+
+import (
+	"fmt"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+)
+
+// Disk fault injection. The pool backing device is wrapped in a
+// dm-flakey target which periodically fails I/O, so pool and volume
+// fault handling can be exercised with real disk errors instead of
+// unplugging the device. The device must not be in use when it is
+// wrapped: suites recreate the pool on the flakey device.
+
+// flakyDmName is the device mapper name of the flakey wrapper; one per
+// node is supported.
+const flakyDmName = "e2e-flaky"
+
+// FlakyDevicePath is the block device path of the flakey wrapper, to be
+// used as the pool disk while the fault is in place.
+const FlakyDevicePath = "/dev/mapper/" + flakyDmName
+
+// flakeyPeriodSecs is the length of one up/down cycle of the flakey
+// target; the error percentage selects how much of it the device is
+// down for.
+const flakeyPeriodSecs = 10
+
+// MakeDeviceFlaky wraps the given block device on the node in a
+// dm-flakey target which fails I/O for errPercent of the time, and
+// returns the path of the flakey device to create the pool on. The
+// device must not be open; RestoreDevice removes the wrapper.
+func MakeDeviceFlaky(nodeName string, device string, errPercent int) (string, error) {
+	if errPercent <= 0 || errPercent >= 100 {
+		return "", fmt.Errorf("invalid error percentage %d, must be within 1 to 99", errPercent)
+	}
+	downSecs := errPercent * flakeyPeriodSecs / 100
+	if downSecs < 1 {
+		downSecs = 1
+	}
+	upSecs := flakeyPeriodSecs - downSecs
+	if upSecs < 1 {
+		upSecs = 1
+	}
+	logmerge.RecordAction("wrapping device %s on node %s in dm-flakey, %d%% errors", device, nodeName, errPercent)
+	runreport.RecordFault("dm-flakey", fmt.Sprintf("%s on %s", device, nodeName))
+	script := fmt.Sprintf(`
+set -e
+size=$(blockdev --getsz %[1]s)
+dmsetup create %[2]s --table "0 $size flakey %[1]s 0 %[3]d %[4]d"
+`, device, flakyDmName, upSecs, downSecs)
+	if _, err := k8stest.RunCommandOnNode(nodeName, script); err != nil {
+		return "", fmt.Errorf("failed to wrap device %s on node %s, error %v", device, nodeName, err)
+	}
+	return FlakyDevicePath, nil
+}
+
+// RestoreDevice removes the flakey wrapper from the node, exposing the
+// raw device again. The wrapper must no longer be in use.
+func RestoreDevice(nodeName string) error {
+	logmerge.RecordAction("removing dm-flakey wrapper on node %s", nodeName)
+	_, err := k8stest.RunCommandOnNode(nodeName,
+		fmt.Sprintf("dmsetup remove %[1]s || dmsetup remove --force %[1]s", flakyDmName))
+	if err != nil {
+		return fmt.Errorf("failed to remove flakey wrapper on node %s, error %v", nodeName, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Package faults injects infrastructure level faults into the cluster
+// under test. Node power faults are abstracted over the platform the
+// cluster runs on - libvirt virtual machines, AWS EC2 or GCP compute
+// instances - selected by the e2e configuration, so node-crash recovery
+// suites run unchanged against different infrastructures.
+package faults
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+)
+
+// Platform powers nodes of the cluster under test off and on again.
+type Platform interface {
+	// Name returns the platform name as used in the configuration.
+	Name() string
+	// PowerOffNode forcibly powers off the node, as a crash would.
+	PowerOffNode(nodeName string) error
+	// PowerOnNode powers the node back on.
+	PowerOnNode(nodeName string) error
+	// RebootNode forcibly resets the node without an orderly shutdown.
+	RebootNode(nodeName string) error
+}
+
+// NewPlatform returns the platform implementation selected by the e2e
+// configuration, wrapped so power faults appear in the run report.
+func NewPlatform() (Platform, error) {
+	cfg := e2e_config.GetConfig().Platform
+	var platform Platform
+	switch cfg.Type {
+	case "libvirt":
+		platform = &libvirtPlatform{uri: cfg.Libvirt.Uri, domainPrefix: cfg.Libvirt.DomainPrefix}
+	case "aws":
+		platform = &awsPlatform{region: cfg.Aws.Region}
+	case "gcp":
+		platform = &gcpPlatform{project: cfg.Gcp.Project, zone: cfg.Gcp.Zone}
+	case "":
+		return nil, fmt.Errorf("no fault platform configured")
+	default:
+		return nil, fmt.Errorf("unknown fault platform %q", cfg.Type)
+	}
+	return &recordingPlatform{inner: platform}, nil
+}
+
+// recordingPlatform notes power faults in the run report before
+// delegating to the real platform; powering a node back on is recovery
+// rather than a fault, so it is not recorded.
+type recordingPlatform struct {
+	inner Platform
+}
+
+func (p *recordingPlatform) Name() string {
+	return p.inner.Name()
+}
+
+func (p *recordingPlatform) PowerOffNode(nodeName string) error {
+	runreport.RecordFault("power-off", nodeName)
+	return p.inner.PowerOffNode(nodeName)
+}
+
+func (p *recordingPlatform) PowerOnNode(nodeName string) error {
+	return p.inner.PowerOnNode(nodeName)
+}
+
+func (p *recordingPlatform) RebootNode(nodeName string) error {
+	runreport.RecordFault("reboot", nodeName)
+	return p.inner.RebootNode(nodeName)
+}
+
+// WaitNodeReady waits for the node to rejoin the cluster and report
+// ready, the common step after PowerOnNode or RebootNode.
+func WaitNodeReady(nodeName string, timeoutSecs int) error {
+	return k8stest.WaitNodeReady(nodeName, timeoutSecs)
+}
+
+// runCommand runs a platform tool, recording the invocation in the test
+// action stream and returning its combined output on failure.
+func runCommand(name string, args ...string) (string, error) {
+	logmerge.RecordAction("running %s %v", name, args)
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("%s %v failed, error %v, output %s", name, args, err, string(out))
+	}
+	return string(out), nil
+}
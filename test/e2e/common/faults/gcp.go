@@ -0,0 +1,33 @@
+package faults
+
+// gcpPlatform drives the gcloud command line client. GCP instance names
+// match the kubernetes node names.
+type gcpPlatform struct {
+	project string
+	zone    string
+}
+
+func (p *gcpPlatform) Name() string {
+	return "gcp"
+}
+
+// gcloud applies one compute instances subcommand to the node.
+func (p *gcpPlatform) gcloud(nodeName string, command ...string) error {
+	args := append([]string{"compute", "instances"}, command...)
+	args = append(args, nodeName,
+		"--project", p.project, "--zone", p.zone, "--quiet")
+	_, err := runCommand("gcloud", args...)
+	return err
+}
+
+func (p *gcpPlatform) PowerOffNode(nodeName string) error {
+	return p.gcloud(nodeName, "stop")
+}
+
+func (p *gcpPlatform) PowerOnNode(nodeName string) error {
+	return p.gcloud(nodeName, "start")
+}
+
+func (p *gcpPlatform) RebootNode(nodeName string) error {
+	return p.gcloud(nodeName, "reset")
+}
@@ -0,0 +1,37 @@
+package faults
+
+// libvirtPlatform drives virsh against the hypervisor hosting the
+// cluster virtual machines. The domain name of a node is the configured
+// prefix followed by the node name.
+type libvirtPlatform struct {
+	uri          string
+	domainPrefix string
+}
+
+func (p *libvirtPlatform) Name() string {
+	return "libvirt"
+}
+
+// virsh composes a virsh invocation against one domain.
+func (p *libvirtPlatform) virsh(command string, nodeName string) error {
+	args := []string{}
+	if p.uri != "" {
+		args = append(args, "--connect", p.uri)
+	}
+	args = append(args, command, p.domainPrefix+nodeName)
+	_, err := runCommand("virsh", args...)
+	return err
+}
+
+func (p *libvirtPlatform) PowerOffNode(nodeName string) error {
+	// destroy is the hard power off, not an orderly shutdown.
+	return p.virsh("destroy", nodeName)
+}
+
+func (p *libvirtPlatform) PowerOnNode(nodeName string) error {
+	return p.virsh("start", nodeName)
+}
+
+func (p *libvirtPlatform) RebootNode(nodeName string) error {
+	return p.virsh("reset", nodeName)
+}
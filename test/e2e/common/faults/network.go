@@ -0,0 +1,232 @@
+package faults
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Network fault injection. Faults are applied by running iptables and tc
+// on the host of a node, through a privileged daemonset pod which
+// chroots into the host filesystem. Every node a fault was applied to is
+// registered for cleanup, and Stop restores them all, so a failed test
+// cannot leave nodes partitioned.
+
+// PartitionMode selects how partitioned traffic is treated: REJECT
+// answers with errors, DROP silently discards, exercising different
+// failure detection paths.
+type PartitionMode string
+
+const (
+	PartitionReject PartitionMode = "REJECT"
+	PartitionDrop   PartitionMode = "DROP"
+)
+
+const netFaultDsName = "e2e-net-fault"
+const netFaultSelector = "app=" + netFaultDsName
+const netFaultTimeoutSecs = 120
+
+// faultChain is the dedicated iptables chain holding the injected rules,
+// so cleanup removes exactly what was injected.
+const faultChain = "E2E-FAULT"
+
+// NetworkFaults manages the fault daemonset and tracks which nodes
+// carry injected faults.
+type NetworkFaults struct {
+	nameSpace string
+	mu        sync.Mutex
+	dirty     map[string]bool
+}
+
+// StartNetworkFaults deploys the fault daemonset and waits for it to be
+// ready on every node. The caller must call Stop when done.
+func StartNetworkFaults() (*NetworkFaults, error) {
+	nameSpace := k8stest.TestNamespace()
+	isPrivileged := true
+	hostPathType := coreV1.HostPathDirectory
+	labels := map[string]string{"app": netFaultDsName}
+	dsDef := &appsV1.DaemonSet{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: netFaultDsName,
+		},
+		Spec: appsV1.DaemonSetSpec{
+			Selector: &metaV1.LabelSelector{MatchLabels: labels},
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: labels},
+				Spec: coreV1.PodSpec{
+					HostNetwork: true,
+					HostPID:     true,
+					Tolerations: []coreV1.Toleration{
+						{Operator: coreV1.TolerationOpExists},
+					},
+					Containers: []coreV1.Container{
+						{
+							Name:    netFaultDsName,
+							Image:   e2e_config.GetConfig().E2eFioImage,
+							Command: []string{"sleep", "1000000"},
+							SecurityContext: &coreV1.SecurityContext{
+								Privileged: &isPrivileged,
+							},
+							VolumeMounts: []coreV1.VolumeMount{
+								{
+									Name:      "host-root",
+									MountPath: "/host",
+								},
+							},
+						},
+					},
+					Volumes: []coreV1.Volume{
+						{
+							Name: "host-root",
+							VolumeSource: coreV1.VolumeSource{
+								HostPath: &coreV1.HostPathVolumeSource{
+									Path: "/",
+									Type: &hostPathType,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if _, err := k8stest.CreateDaemonSet(dsDef, nameSpace); err != nil {
+		return nil, fmt.Errorf("failed to create fault daemonset, error %v", err)
+	}
+	if err := k8stest.WaitDaemonSetReady(netFaultDsName, nameSpace, netFaultTimeoutSecs); err != nil {
+		return nil, err
+	}
+	return &NetworkFaults{nameSpace: nameSpace, dirty: map[string]bool{}}, nil
+}
+
+// Stop restores every node a fault was applied to and removes the fault
+// daemonset. The first restore failure is returned; the daemonset is
+// removed regardless.
+func (n *NetworkFaults) Stop() error {
+	n.mu.Lock()
+	var nodes []string
+	for nodeName := range n.dirty {
+		nodes = append(nodes, nodeName)
+	}
+	n.mu.Unlock()
+	var firstErr error
+	for _, nodeName := range nodes {
+		if err := n.RestoreNode(nodeName); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := k8stest.DeleteDaemonSet(netFaultDsName, n.nameSpace); err != nil && firstErr == nil {
+		firstErr = fmt.Errorf("failed to delete fault daemonset, error %v", err)
+	}
+	return firstErr
+}
+
+// execOnNode runs a shell script on the host of the given node through
+// its fault daemonset pod.
+func (n *NetworkFaults) execOnNode(nodeName string, script string) error {
+	pods, err := k8stest.ListPodsBySelector(n.nameSpace, netFaultSelector)
+	if err != nil {
+		return fmt.Errorf("failed to list fault pods, error %v", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == nodeName {
+			_, err := k8stest.ExecPod(pod.Name, n.nameSpace, "chroot", "/host", "sh", "-c", script)
+			return err
+		}
+	}
+	return fmt.Errorf("no fault pod on node %s", nodeName)
+}
+
+// markDirty registers the node for cleanup.
+func (n *NetworkFaults) markDirty(nodeName string) {
+	n.mu.Lock()
+	n.dirty[nodeName] = true
+	n.mu.Unlock()
+}
+
+// PartitionNode blocks all traffic between the node and the target
+// address, in both directions, with the given mode.
+func (n *NetworkFaults) PartitionNode(nodeName string, targetIp string, mode PartitionMode) error {
+	if mode != PartitionReject && mode != PartitionDrop {
+		return fmt.Errorf("invalid partition mode %q", mode)
+	}
+	logmerge.RecordAction("partitioning node %s from %s with %s", nodeName, targetIp, mode)
+	runreport.RecordFault("network-partition", fmt.Sprintf("%s from %s", nodeName, targetIp))
+	n.markDirty(nodeName)
+	script := fmt.Sprintf(`
+set -e
+iptables -N %[1]s 2>/dev/null || true
+iptables -C INPUT -j %[1]s 2>/dev/null || iptables -I INPUT -j %[1]s
+iptables -C OUTPUT -j %[1]s 2>/dev/null || iptables -I OUTPUT -j %[1]s
+iptables -A %[1]s -s %[2]s -j %[3]s
+iptables -A %[1]s -d %[2]s -j %[3]s
+`, faultChain, targetIp, mode)
+	return n.execOnNode(nodeName, script)
+}
+
+// netemScript applies the given netem discipline to the node's default
+// route interface.
+const netemScript = `
+set -e
+dev=$(ip route show default | sed -n 's/.* dev \([^ ]*\).*/\1/p' | head -1)
+test -n "$dev"
+tc qdisc replace dev "$dev" root netem %s
+`
+
+// AddLatency injects the given delay, with optional jitter, into all
+// traffic leaving the node.
+func (n *NetworkFaults) AddLatency(nodeName string, delayMsecs int, jitterMsecs int) error {
+	logmerge.RecordAction("injecting %dms latency on node %s", delayMsecs, nodeName)
+	runreport.RecordFault("network-latency", fmt.Sprintf("%dms on %s", delayMsecs, nodeName))
+	n.markDirty(nodeName)
+	netem := fmt.Sprintf("delay %dms", delayMsecs)
+	if jitterMsecs > 0 {
+		netem = fmt.Sprintf("%s %dms", netem, jitterMsecs)
+	}
+	return n.execOnNode(nodeName, fmt.Sprintf(netemScript, netem))
+}
+
+// AddPacketLoss injects the given packet loss percentage into all
+// traffic leaving the node.
+func (n *NetworkFaults) AddPacketLoss(nodeName string, lossPercent float64) error {
+	if lossPercent <= 0 || lossPercent > 100 {
+		return fmt.Errorf("invalid packet loss percentage %g", lossPercent)
+	}
+	logmerge.RecordAction("injecting %g%% packet loss on node %s", lossPercent, nodeName)
+	runreport.RecordFault("network-loss", fmt.Sprintf("%g%% on %s", lossPercent, nodeName))
+	n.markDirty(nodeName)
+	return n.execOnNode(nodeName, fmt.Sprintf(netemScript, fmt.Sprintf("loss %g%%", lossPercent)))
+}
+
+// restoreScript removes the fault chain and any netem discipline; every
+// step is tolerant of the fault not being present.
+const restoreScript = `
+iptables -D INPUT -j %[1]s 2>/dev/null || true
+iptables -D OUTPUT -j %[1]s 2>/dev/null || true
+iptables -F %[1]s 2>/dev/null || true
+iptables -X %[1]s 2>/dev/null || true
+dev=$(ip route show default | sed -n 's/.* dev \([^ ]*\).*/\1/p' | head -1)
+if [ -n "$dev" ]; then
+	tc qdisc del dev "$dev" root 2>/dev/null || true
+fi
+`
+
+// RestoreNode removes all injected faults from the node.
+func (n *NetworkFaults) RestoreNode(nodeName string) error {
+	logmerge.RecordAction("restoring network of node %s", nodeName)
+	if err := n.execOnNode(nodeName, fmt.Sprintf(restoreScript, faultChain)); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	delete(n.dirty, nodeName)
+	n.mu.Unlock()
+	return nil
+}
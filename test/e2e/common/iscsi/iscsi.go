@@ -0,0 +1,113 @@
+// Package iscsi inspects the iSCSI initiator state of cluster nodes by
+// running iscsiadm inside the csi node plugin pod, which shares the
+// host namespaces the sessions live in. Protocol tests use it to assert
+// a volume really logged in on attach and logged out again on detach.
+package iscsi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+)
+
+// execTimeoutSecs bounds one iscsiadm invocation.
+const execTimeoutSecs = 60
+
+// Session is one iSCSI session as reported by iscsiadm -m session.
+type Session struct {
+	// Transport is the transport type, e.g. tcp
+	Transport string
+	// Sid is the session id
+	Sid string
+	// Portal is the target portal, e.g. 10.0.0.1:3260,1
+	Portal string
+	// Iqn is the target IQN; mayastor embeds the volume uuid in it
+	Iqn string
+}
+
+// ParseSessions parses the plain text output of iscsiadm -m session,
+// one session per line, e.g.
+//
+//	tcp: [1] 10.0.0.1:3260,1 iqn.2019-05.io.openebs:nexus-uuid (non-flash)
+//
+// Lines it does not recognise are skipped, so output format drift
+// degrades into missing detail rather than errors. The "no active
+// sessions" diagnostic iscsiadm emits is simply an empty result.
+func ParseSessions(output string) []Session {
+	var sessions []Session
+	for _, line := range strings.Split(output, "\n") {
+		transport, rest, found := strings.Cut(strings.TrimSpace(line), ": ")
+		if !found || strings.Contains(transport, " ") {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "[") {
+			continue
+		}
+		sessions = append(sessions, Session{
+			Transport: transport,
+			Sid:       strings.Trim(fields[0], "[]"),
+			Portal:    fields[1],
+			Iqn:       fields[2],
+		})
+	}
+	return sessions
+}
+
+// ListSessions returns the iSCSI sessions of the given node. A node
+// with no sessions is not an error.
+func ListSessions(nodeName string) ([]Session, error) {
+	podName, container, err := k8stest.CsiNodePod(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	stdout, stderr, err := k8stest.ExecInPod(k8stest.MayastorNamespace, podName, container,
+		[]string{"iscsiadm", "-m", "session"}, execTimeoutSecs)
+	if err != nil {
+		// iscsiadm exits non-zero when there are no sessions at all
+		if strings.Contains(stdout+stderr, "No active sessions") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to run iscsiadm on node %s, error %v", nodeName, err)
+	}
+	return ParseSessions(stdout), nil
+}
+
+// SessionForVolume returns the session of the given volume on the given
+// node; mayastor embeds the volume uuid in the target IQN.
+func SessionForVolume(nodeName string, uuid string) (Session, error) {
+	sessions, err := ListSessions(nodeName)
+	if err != nil {
+		return Session{}, err
+	}
+	for _, session := range sessions {
+		if strings.Contains(session.Iqn, uuid) {
+			return session, nil
+		}
+	}
+	return Session{}, fmt.Errorf("node %s has no iSCSI session for volume %s", nodeName, uuid)
+}
+
+// ValidateLogin verifies the given node holds an iSCSI session for the
+// volume, i.e. the attach logged in to the target.
+func ValidateLogin(nodeName string, uuid string) error {
+	_, err := SessionForVolume(nodeName, uuid)
+	return err
+}
+
+// ValidateLogout verifies the given node holds no iSCSI session for the
+// volume, i.e. the detach logged out of the target.
+func ValidateLogout(nodeName string, uuid string) error {
+	sessions, err := ListSessions(nodeName)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if strings.Contains(session.Iqn, uuid) {
+			return fmt.Errorf("node %s still holds session %s to %s for volume %s",
+				nodeName, session.Sid, session.Portal, uuid)
+		}
+	}
+	return nil
+}
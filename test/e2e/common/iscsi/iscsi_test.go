@@ -0,0 +1,44 @@
+package iscsi
+
+import "testing"
+
+const sampleSessions = `tcp: [1] 10.1.0.5:3260,1 iqn.2019-05.io.openebs:nexus-11aa22bb-9d14-4b43-9b87-d81d0d526f32 (non-flash)
+tcp: [2] 10.1.0.6:3260,1 iqn.2019-05.io.openebs:nexus-77cc88dd-1234-4b43-9b87-d81d0d526f32 (non-flash)
+`
+
+func TestParseSessions(t *testing.T) {
+	sessions := ParseSessions(sampleSessions)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	first := sessions[0]
+	if first.Transport != "tcp" {
+		t.Errorf("unexpected transport %q", first.Transport)
+	}
+	if first.Sid != "1" {
+		t.Errorf("unexpected sid %q", first.Sid)
+	}
+	if first.Portal != "10.1.0.5:3260,1" {
+		t.Errorf("unexpected portal %q", first.Portal)
+	}
+	if first.Iqn != "iqn.2019-05.io.openebs:nexus-11aa22bb-9d14-4b43-9b87-d81d0d526f32" {
+		t.Errorf("unexpected iqn %q", first.Iqn)
+	}
+}
+
+func TestParseSessionsNoSessions(t *testing.T) {
+	// the diagnostic iscsiadm prints on stderr must not parse as a session
+	if sessions := ParseSessions("iscsiadm: No active sessions.\n"); len(sessions) != 0 {
+		t.Errorf("expected no sessions, got %v", sessions)
+	}
+	if sessions := ParseSessions(""); len(sessions) != 0 {
+		t.Errorf("expected no sessions from empty output, got %v", sessions)
+	}
+}
+
+func TestParseSessionsSkipsMalformedLines(t *testing.T) {
+	output := "tcp: [3]\n" + sampleSessions + "garbage line without structure\n"
+	if sessions := ParseSessions(output); len(sessions) != 2 {
+		t.Errorf("expected malformed lines to be skipped, got %v", sessions)
+	}
+}
@@ -0,0 +1,48 @@
+package k8stest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The suite runs against kubernetes 1.21 through 1.29 and against
+// several generations of mayastor control plane, across which some of
+// the APIs it consumes moved between versions. Served-API discovery
+// backs the per-API flavour selection (pools, snapshots), so helpers
+// adapt to what the cluster serves instead of pinning one version.
+
+// ApiServed reports whether the cluster serves the given resource.
+func ApiServed(gvr schema.GroupVersionResource) bool {
+	resources, err := gTestEnv.KubeInt.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		return false
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == gvr.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerVersion returns the major and minor version of the cluster.
+// Some managed distributions suffix the reported minor with "+", which
+// is stripped.
+func ServerVersion() (int, int, error) {
+	info, err := gTestEnv.KubeInt.Discovery().ServerVersion()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read the server version, error %v", err)
+	}
+	major, err := strconv.Atoi(strings.TrimSuffix(info.Major, "+"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("unparseable server major version %q", info.Major)
+	}
+	minor, err := strconv.Atoi(strings.TrimSuffix(info.Minor, "+"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("unparseable server minor version %q", info.Minor)
+	}
+	return major, minor, nil
+}
@@ -0,0 +1,173 @@
+package k8stest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// AvailabilityReport accounts for one volume's availability over a
+// suite: total observed time, time spent in non-healthy states, and IO
+// stall time reported by fio based stall detection.
+type AvailabilityReport struct {
+	Volume              string  `json:"volume"`
+	ObservedSecs        int     `json:"observedSecs"`
+	UnhealthySecs       int     `json:"unhealthySecs"`
+	IoStallSecs         int     `json:"ioStallSecs"`
+	AvailabilityPercent float64 `json:"availabilityPercent"`
+}
+
+// volumeObservation is the accumulating state for one volume.
+type volumeObservation struct {
+	observed  time.Duration
+	unhealthy time.Duration
+	ioStalls  time.Duration
+}
+
+const availabilityPollSecs = 5
+
+var availabilityMutex sync.Mutex
+var availabilityObservations map[string]*volumeObservation
+var availabilityStop chan struct{}
+var availabilityDone chan struct{}
+
+// StartAvailabilityMonitor begins polling volume states; each volume's
+// time in a non-healthy state accumulates towards its unavailability.
+// Started by SetupTestEnv alongside the rebuild monitor.
+func StartAvailabilityMonitor() {
+	availabilityMutex.Lock()
+	defer availabilityMutex.Unlock()
+	if availabilityStop != nil {
+		return
+	}
+	availabilityObservations = map[string]*volumeObservation{}
+	availabilityStop = make(chan struct{})
+	availabilityDone = make(chan struct{})
+	go availabilityMonitor(availabilityStop, availabilityDone)
+}
+
+func availabilityMonitor(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(availabilityPollSecs * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			msvs, err := ListMsvs()
+			if err != nil {
+				continue
+			}
+			availabilityMutex.Lock()
+			for ix := range msvs {
+				recordObservation(&msvs[ix])
+			}
+			availabilityMutex.Unlock()
+		}
+	}
+}
+
+func recordObservation(msv *unstructured.Unstructured) {
+	observation, ok := availabilityObservations[msv.GetName()]
+	if !ok {
+		observation = &volumeObservation{}
+		availabilityObservations[msv.GetName()] = observation
+	}
+	observation.observed += availabilityPollSecs * time.Second
+	state, _, _ := unstructured.NestedString(msv.Object, "status", "state")
+	if state != "healthy" {
+		observation.unhealthy += availabilityPollSecs * time.Second
+	}
+}
+
+// RecordIoStall attributes a window of IO unavailability, e.g. from
+// fio stall detection, to the given volume.
+func RecordIoStall(volume string, duration time.Duration) {
+	availabilityMutex.Lock()
+	defer availabilityMutex.Unlock()
+	if availabilityObservations == nil {
+		return
+	}
+	observation, ok := availabilityObservations[volume]
+	if !ok {
+		observation = &volumeObservation{}
+		availabilityObservations[volume] = observation
+	}
+	observation.ioStalls += duration
+}
+
+// IoStallTimeFromLatency sums the time covered by latency samples at
+// or above the given threshold, fio's per-second averaged log making
+// each sample account for one second of wall time.
+func IoStallTimeFromLatency(samples []FioLatencySample, stallLatencyUsecs float64) time.Duration {
+	var stalled time.Duration
+	for _, sample := range samples {
+		if sample.LatencyUsecs >= stallLatencyUsecs {
+			stalled += time.Second
+		}
+	}
+	return stalled
+}
+
+// StopAvailabilityMonitor stops the monitor and emits per-volume
+// availability reports to the run manifest. If the availability
+// configuration sets a minimum percentage, an error is returned when
+// any volume fell below it, so disruption suites can gate CI on
+// availability.
+func StopAvailabilityMonitor() ([]AvailabilityReport, error) {
+	availabilityMutex.Lock()
+	if availabilityStop == nil {
+		availabilityMutex.Unlock()
+		return nil, nil
+	}
+	close(availabilityStop)
+	availabilityMutex.Unlock()
+	<-availabilityDone
+
+	availabilityMutex.Lock()
+	defer availabilityMutex.Unlock()
+	availabilityStop = nil
+
+	var reports []AvailabilityReport
+	for volume, observation := range availabilityObservations {
+		report := AvailabilityReport{
+			Volume:        volume,
+			ObservedSecs:  int(observation.observed.Seconds()),
+			UnhealthySecs: int(observation.unhealthy.Seconds()),
+			IoStallSecs:   int(observation.ioStalls.Seconds()),
+		}
+		unavailable := observation.unhealthy + observation.ioStalls
+		if observation.observed > 0 {
+			report.AvailabilityPercent = 100 * (1 - unavailable.Seconds()/observation.observed.Seconds())
+			if report.AvailabilityPercent < 0 {
+				report.AvailabilityPercent = 0
+			}
+		} else {
+			report.AvailabilityPercent = 100
+		}
+		reports = append(reports, report)
+	}
+	if len(reports) != 0 {
+		if err := manifest.AppendSection("availability", reports); err != nil {
+			logf.Log.Info("failed to record availability in the run manifest", "error", err)
+		}
+	}
+
+	minPercent := e2e_config.GetConfig().Availability.MinPercent
+	if minPercent > 0 {
+		for _, report := range reports {
+			if report.AvailabilityPercent < minPercent {
+				return reports, fmt.Errorf("volume %s availability %.2f%% below the required %.2f%%",
+					report.Volume, report.AvailabilityPercent, minPercent)
+			}
+		}
+	}
+	return reports, nil
+}
@@ -0,0 +1,115 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// testNamespace is where suites create their application pods and
+// claims; pristine-cluster checks look for leftovers here.
+const testNamespace = "default"
+
+// canaryPrefix identifies the canary objects, which are long lived by
+// design and must not be treated as leftovers.
+const canaryPrefix = "e2e-canary-"
+
+// clusterLeftovers lists what a previous suite failed to clean up.
+func clusterLeftovers() (pods []string, pvcs []string, err error) {
+	podList, err := gTestEnv.KubeInt.CoreV1().Pods(testNamespace).List(context.TODO(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods in %s, error %v", testNamespace, err)
+	}
+	for _, pod := range podList.Items {
+		if !strings.HasPrefix(pod.Name, canaryPrefix) {
+			pods = append(pods, pod.Name)
+		}
+	}
+	pvcList, err := ListPvcs(testNamespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pvc := range pvcList.Items {
+		if !strings.HasPrefix(pvc.Name, canaryPrefix) {
+			pvcs = append(pvcs, pvc.Name)
+		}
+	}
+	return pods, pvcs, nil
+}
+
+// remediateLeftovers deletes the given leftover pods and claims and
+// waits a bounded time for the cluster to become pristine again.
+func remediateLeftovers(pods []string, pvcs []string, timeoutSecs int) error {
+	for _, podName := range pods {
+		if err := DeletePod(podName, testNamespace); err != nil {
+			logf.Log.Info("remediation: failed to delete leftover pod", "pod", podName, "error", err)
+		}
+	}
+	for _, pvcName := range pvcs {
+		if err := DeletePVC(pvcName, testNamespace); err != nil {
+			logf.Log.Info("remediation: failed to delete leftover pvc", "pvc", pvcName, "error", err)
+		}
+	}
+	const sleepTime = 5
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		remainingPods, remainingPvcs, err := clusterLeftovers()
+		if err != nil {
+			return err
+		}
+		if len(remainingPods) == 0 && len(remainingPvcs) == 0 {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("leftovers remain after %d seconds of remediation", timeoutSecs)
+}
+
+// BeforeEachCheck verifies the cluster is pristine before a spec runs:
+// no leftover test pods or claims from a previous suite. Behaviour on
+// leftovers follows the beforeEachCheck.strictness configuration:
+//
+//	"strict"    fail immediately (the default)
+//	"remediate" delete the leftovers, wait a bounded time, then fail
+//	            only if they persist
+//	"warn"      log and carry on
+//
+// The check is re-entrant: remediation runs at most once per
+// invocation and an already pristine cluster returns immediately, so
+// it is safe to call from every spec of every suite.
+//
+// It also snapshots the mayastor pod restart counts, the baseline the
+// spec's restart accounting in AfterEachCheck compares against.
+func BeforeEachCheck() error {
+	if err := snapshotRestartBaseline(); err != nil {
+		return err
+	}
+	pods, pvcs, err := clusterLeftovers()
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 && len(pvcs) == 0 {
+		return nil
+	}
+
+	cfg := e2e_config.GetConfig().BeforeEachCheck
+	described := fmt.Sprintf("leftover pods %v, leftover pvcs %v", pods, pvcs)
+	switch cfg.Strictness {
+	case "warn":
+		logf.Log.Info("BeforeEachCheck: cluster not pristine, continuing", "leftovers", described)
+		return nil
+	case "remediate":
+		logf.Log.Info("BeforeEachCheck: cluster not pristine, remediating", "leftovers", described)
+		if err := remediateLeftovers(pods, pvcs, cfg.RemediateTimeoutSecs); err != nil {
+			return fmt.Errorf("cluster not pristine and remediation failed: %s, error %v", described, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("cluster not pristine: %s", described)
+	}
+}
@@ -0,0 +1,180 @@
+package k8stest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ChurnSample is one sampling interval of control plane noise: the
+// kubernetes events emitted in the mayastor namespace and the writes
+// observed on the mayastor custom resources, as per-minute rates.
+type ChurnSample struct {
+	OffsetSecs      int     `json:"offsetSecs"`
+	EventsPerMinute float64 `json:"eventsPerMinute"`
+	WritesPerMinute float64 `json:"writesPerMinute"`
+}
+
+const churnPollSecs = 15
+
+var churnMutex sync.Mutex
+var churnSamples []ChurnSample
+var churnStop chan struct{}
+var churnDone chan struct{}
+
+// countEvents sums the event counts in the mayastor namespace; the
+// delta between polls is the emission rate.
+func countEvents() (int, error) {
+	events, err := ListEvents(MayastorNamespace)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, event := range events.Items {
+		count := int(event.Count)
+		if count == 0 {
+			count = 1
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// crResourceVersions returns the resource version of every mayastor
+// volume and pool custom resource; a changed version between polls is
+// an API write by a component, e.g. an operator updating MSV status.
+func crResourceVersions() (map[string]string, error) {
+	versions := map[string]string{}
+	msvs, err := ListMsvs()
+	if err != nil {
+		return nil, err
+	}
+	for _, msv := range msvs {
+		versions["msv/"+msv.GetName()] = msv.GetResourceVersion()
+	}
+	pools, err := ListMsPools()
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		versions["msp/"+pool.GetName()] = pool.GetResourceVersion()
+	}
+	return versions, nil
+}
+
+// StartChurnMonitor begins sampling the event and API write rates of
+// the mayastor components. Started by SetupTestEnv alongside the other
+// monitors; the thresholds are only evaluated when the monitor is
+// stopped, so a burst during fault injection is visible in the samples
+// without failing the suite by itself.
+func StartChurnMonitor() {
+	churnMutex.Lock()
+	defer churnMutex.Unlock()
+	if churnStop != nil {
+		return
+	}
+	churnSamples = nil
+	churnStop = make(chan struct{})
+	churnDone = make(chan struct{})
+	go churnMonitor(churnStop, churnDone)
+}
+
+func churnMonitor(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+	start := time.Now()
+	lastEvents, err := countEvents()
+	haveBaseline := err == nil
+	lastVersions, err := crResourceVersions()
+	haveBaseline = haveBaseline && err == nil
+
+	ticker := time.NewTicker(churnPollSecs * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			events, err := countEvents()
+			if err != nil {
+				continue
+			}
+			versions, err := crResourceVersions()
+			if err != nil {
+				continue
+			}
+			if haveBaseline {
+				writes := 0
+				for name, version := range versions {
+					if previous, ok := lastVersions[name]; !ok || previous != version {
+						writes++
+					}
+				}
+				perMinute := 60.0 / churnPollSecs
+				churnMutex.Lock()
+				churnSamples = append(churnSamples, ChurnSample{
+					OffsetSecs:      int(time.Since(start).Seconds()),
+					EventsPerMinute: float64(events-lastEvents) * perMinute,
+					WritesPerMinute: float64(writes) * perMinute,
+				})
+				churnMutex.Unlock()
+			}
+			lastEvents = events
+			lastVersions = versions
+			haveBaseline = true
+		}
+	}
+}
+
+// StopChurnMonitor stops the monitor, records the samples in the run
+// manifest and evaluates the configured thresholds: an error is
+// returned when the sustained rate (the average over the suite) exceeds
+// them, catching components hot-looping updates; 0 disables a
+// threshold.
+func StopChurnMonitor() ([]ChurnSample, error) {
+	churnMutex.Lock()
+	if churnStop == nil {
+		churnMutex.Unlock()
+		return nil, nil
+	}
+	close(churnStop)
+	churnMutex.Unlock()
+	<-churnDone
+
+	churnMutex.Lock()
+	defer churnMutex.Unlock()
+	churnStop = nil
+
+	samples := churnSamples
+	if len(samples) == 0 {
+		return nil, nil
+	}
+	if err := manifest.AppendSection("apiChurn", samples); err != nil {
+		logf.Log.Info("failed to record api churn in the run manifest", "error", err)
+	}
+
+	var meanEvents, meanWrites float64
+	for _, sample := range samples {
+		meanEvents += sample.EventsPerMinute
+		meanWrites += sample.WritesPerMinute
+	}
+	meanEvents /= float64(len(samples))
+	meanWrites /= float64(len(samples))
+	logf.Log.Info("api churn over the suite",
+		"meanEventsPerMinute", meanEvents, "meanWritesPerMinute", meanWrites)
+
+	cfg := e2e_config.GetConfig().ApiChurn
+	if cfg.MaxEventsPerMinute > 0 && meanEvents > cfg.MaxEventsPerMinute {
+		return samples, fmt.Errorf("sustained event rate %.1f/min exceeds the budget of %.1f/min",
+			meanEvents, cfg.MaxEventsPerMinute)
+	}
+	if cfg.MaxWritesPerMinute > 0 && meanWrites > cfg.MaxWritesPerMinute {
+		return samples, fmt.Errorf("sustained CR write rate %.1f/min exceeds the budget of %.1f/min",
+			meanWrites, cfg.MaxWritesPerMinute)
+	}
+	return samples, nil
+}
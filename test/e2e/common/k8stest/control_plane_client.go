@@ -0,0 +1,25 @@
+package k8stest
+
+import (
+	"fmt"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/restclient"
+)
+
+// ControlPlaneRestClient returns a typed REST API client backed by a
+// port-forward to the moac pod, and a function tearing the forward
+// down. Callers must call the teardown function when done with the
+// client.
+func ControlPlaneRestClient() (*restclient.Client, func(), error) {
+	pods, err := ListPodsBySelector(MayastorNamespace, "app=moac")
+	if err != nil || len(pods.Items) == 0 {
+		return nil, nil, fmt.Errorf("failed to find the moac pod, error %v", err)
+	}
+	port := e2e_config.GetConfig().PoolConsistency.RestPort
+	localPort, stop, err := forwardToPod(pods.Items[0].Name, MayastorNamespace, port)
+	if err != nil {
+		return nil, nil, err
+	}
+	return restclient.New(fmt.Sprintf("http://127.0.0.1:%d", localPort)), stop, nil
+}
@@ -0,0 +1,285 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// namespace in which the mayastor custom resources live
+const MayastorNamespace = "mayastor"
+
+var msvGVR = schema.GroupVersionResource{
+	Group:    "openebs.io",
+	Version:  "v1alpha1",
+	Resource: "mayastorvolumes",
+}
+
+var mspGVR = schema.GroupVersionResource{
+	Group:    "openebs.io",
+	Version:  "v1alpha1",
+	Resource: "mayastorpools",
+}
+
+// GetMSV retrieves a mayastor volume custom resource by uuid.
+func GetMSV(uuid string) (*unstructured.Unstructured, error) {
+	return gTestEnv.DynamicClient.Resource(msvGVR).Namespace(MayastorNamespace).Get(context.TODO(), uuid, metaV1.GetOptions{})
+}
+
+// GetMsvState returns the state reported in the status of a mayastor
+// volume custom resource, e.g. "healthy" or "degraded".
+func GetMsvState(uuid string) (string, error) {
+	msv, err := GetMsv(uuid)
+	if err != nil {
+		return "", err
+	}
+	return msv.Status.State, nil
+}
+
+// GetMsvNexusChildren returns the URIs of the nexus children of a
+// mayastor volume.
+func GetMsvNexusChildren(uuid string) ([]string, error) {
+	msv, err := GetMsv(uuid)
+	if err != nil {
+		return nil, err
+	}
+	var uris []string
+	for _, child := range msv.Status.Nexus.Children {
+		uris = append(uris, child.Uri)
+	}
+	return uris, nil
+}
+
+// DeleteMsv deletes a mayastor volume custom resource by uuid.
+func DeleteMsv(uuid string) error {
+	return gTestEnv.DynamicClient.Resource(msvGVR).Namespace(MayastorNamespace).Delete(context.TODO(), uuid, metaV1.DeleteOptions{})
+}
+
+// crdListPageSize bounds each list request; larger resource
+// populations are retrieved page by page with continuation tokens
+// rather than in one oversized response.
+const crdListPageSize = 100
+
+// listPaged retrieves all custom resources of the given type, following
+// continuation tokens so no items are truncated on large clusters.
+func listPaged(gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	var items []unstructured.Unstructured
+	opts := metaV1.ListOptions{Limit: crdListPageSize}
+	for {
+		page, err := gTestEnv.DynamicClient.Resource(gvr).Namespace(MayastorNamespace).List(context.TODO(), opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		if page.GetContinue() == "" {
+			return items, nil
+		}
+		opts.Continue = page.GetContinue()
+	}
+}
+
+// ListMsvs retrieves all mayastor volume custom resources.
+func ListMsvs() ([]unstructured.Unstructured, error) {
+	msvs, err := listPaged(msvGVR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mayastor volumes, error %v", err)
+	}
+	return msvs, nil
+}
+
+// ListMsPools retrieves all pool custom resources, of whichever CRD
+// flavour the cluster serves.
+func ListMsPools() ([]unstructured.Unstructured, error) {
+	pools, err := listPaged(currentPoolApi().gvr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mayastor pools, error %v", err)
+	}
+	return pools, nil
+}
+
+// CreateMsvRecord creates a bare mayastor volume custom resource with
+// the given uuid, bypassing CSI provisioning. Only of use to tests
+// which exercise control plane behaviour over volume records, e.g.
+// listing at scale; the control plane will report such volumes as
+// pending since no replicas back them.
+func CreateMsvRecord(uuid string, replicaCount int, requiredBytes int64) error {
+	msv := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": msvGVR.Group + "/" + msvGVR.Version,
+			"kind":       "MayastorVolume",
+			"metadata": map[string]interface{}{
+				"name":      uuid,
+				"namespace": MayastorNamespace,
+			},
+			"spec": map[string]interface{}{
+				"replicaCount":  int64(replicaCount),
+				"requiredBytes": requiredBytes,
+			},
+		},
+	}
+	_, err := gTestEnv.DynamicClient.Resource(msvGVR).Namespace(MayastorNamespace).Create(context.TODO(), msv, metaV1.CreateOptions{})
+	return err
+}
+
+// CreateMsPool creates a mayastor pool custom resource on the given
+// node backed by the given disk; the control plane picks it up and
+// creates the pool on the io-engine.
+func CreateMsPool(poolName string, nodeName string, disk string) error {
+	return CreateMsPoolWithDisks(poolName, nodeName, []string{disk})
+}
+
+// CreateMsPoolWithDisks creates a mayastor pool custom resource backed
+// by the given set of disks.
+func CreateMsPoolWithDisks(poolName string, nodeName string, disks []string) error {
+	if len(disks) == 0 {
+		return fmt.Errorf("pool %s requires at least one disk", poolName)
+	}
+	api := currentPoolApi()
+	var diskList []interface{}
+	for _, disk := range disks {
+		diskList = append(diskList, disk)
+	}
+	pool := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": api.gvr.GroupVersion().String(),
+			"kind":       api.kind,
+			"metadata": map[string]interface{}{
+				"name":      poolName,
+				"namespace": MayastorNamespace,
+			},
+			"spec": map[string]interface{}{
+				"node":  nodeName,
+				"disks": diskList,
+			},
+		},
+	}
+	_, err := gTestEnv.DynamicClient.Resource(api.gvr).Namespace(MayastorNamespace).Create(context.TODO(), pool, metaV1.CreateOptions{})
+	if err == nil {
+		trackResource("pool", poolName, "")
+	}
+	return err
+}
+
+// GetMsPoolUsage returns the validated capacity accounting of a pool:
+// the status must carry a positive capacity and a usage within it, so
+// callers can rely on the figures rather than silently computing with
+// zeroes from a pool that has not come up.
+func GetMsPoolUsage(poolName string) (PoolRecord, error) {
+	pool, err := gTestEnv.DynamicClient.Resource(currentPoolApi().gvr).Namespace(MayastorNamespace).Get(context.TODO(), poolName, metaV1.GetOptions{})
+	if err != nil {
+		return PoolRecord{}, fmt.Errorf("failed to get mayastor pool %s, error %v", poolName, err)
+	}
+	capacity, found, err := unstructured.NestedInt64(pool.Object, "status", "capacity")
+	if err != nil || !found {
+		return PoolRecord{}, fmt.Errorf("pool %s reports no capacity, found %v, error %v", poolName, found, err)
+	}
+	used, found, err := unstructured.NestedInt64(pool.Object, "status", "used")
+	if err != nil || !found {
+		return PoolRecord{}, fmt.Errorf("pool %s reports no usage, found %v, error %v", poolName, found, err)
+	}
+	if capacity <= 0 {
+		return PoolRecord{}, fmt.Errorf("pool %s reports a non-positive capacity %d", poolName, capacity)
+	}
+	if used < 0 || used > capacity {
+		return PoolRecord{}, fmt.Errorf("pool %s reports usage %d outside its capacity %d", poolName, used, capacity)
+	}
+	return PoolRecord{Name: poolName, CapacityBytes: capacity, UsedBytes: used}, nil
+}
+
+// DeleteMsPool deletes a pool custom resource by name.
+func DeleteMsPool(poolName string) error {
+	err := gTestEnv.DynamicClient.Resource(currentPoolApi().gvr).Namespace(MayastorNamespace).Delete(context.TODO(), poolName, metaV1.DeleteOptions{})
+	if err == nil || k8serrors.IsNotFound(err) {
+		untrackResource("pool", poolName, "")
+	}
+	return err
+}
+
+// LabelMsPool sets a label on a pool custom resource; pool affinity
+// topology parameters select pools by these labels.
+func LabelMsPool(poolName string, key string, value string) error {
+	patch := fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, key, value)
+	_, err := gTestEnv.DynamicClient.Resource(currentPoolApi().gvr).Namespace(MayastorNamespace).Patch(
+		context.TODO(), poolName, types.MergePatchType, []byte(patch), metaV1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to label pool %s, error %v", poolName, err)
+	}
+	return nil
+}
+
+// UnlabelMsPool removes a label from a pool custom resource.
+func UnlabelMsPool(poolName string, key string) error {
+	patch := fmt.Sprintf(`{"metadata":{"labels":{%q:null}}}`, key)
+	_, err := gTestEnv.DynamicClient.Resource(currentPoolApi().gvr).Namespace(MayastorNamespace).Patch(
+		context.TODO(), poolName, types.MergePatchType, []byte(patch), metaV1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to unlabel pool %s, error %v", poolName, err)
+	}
+	return nil
+}
+
+// WaitMsPoolOnline waits for up to timeoutSecs for the pool to report
+// the online state.
+func WaitMsPoolOnline(poolName string, timeoutSecs int) error {
+	const sleepTime = 2
+	var state string
+	var err error
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		state, err = GetMsPoolState(poolName)
+		// the diskpool flavours capitalise the state
+		if err == nil && strings.EqualFold(state, "online") {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("pool %s not online after %d seconds, state %q, error %v", poolName, timeoutSecs, state, err)
+}
+
+// GetMsvReplicaPools returns the names of the pools hosting the
+// replicas of a mayastor volume, as reported in its status.
+func GetMsvReplicaPools(uuid string) ([]string, error) {
+	msv, err := GetMsv(uuid)
+	if err != nil {
+		return nil, err
+	}
+	var pools []string
+	for _, replica := range msv.Status.Replicas {
+		pools = append(pools, replica.Pool)
+	}
+	return pools, nil
+}
+
+// GetMsPoolState returns the state reported in the status of a mayastor
+// pool custom resource, e.g. "online" or "faulted".
+func GetMsPoolState(poolName string) (string, error) {
+	pool, err := gTestEnv.DynamicClient.Resource(currentPoolApi().gvr).Namespace(MayastorNamespace).Get(context.TODO(), poolName, metaV1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get mayastor pool %s, error %v", poolName, err)
+	}
+	state, found, err := unstructured.NestedString(pool.Object, "status", "state")
+	if err != nil || !found {
+		return "", fmt.Errorf("failed to read state of mayastor pool %s, found %v, error %v", poolName, found, err)
+	}
+	return state, nil
+}
+
+// MsPoolNodeAndDisk returns the node and the first disk declared in the
+// spec of a mayastor pool custom resource.
+func MsPoolNodeAndDisk(pool unstructured.Unstructured) (string, string, error) {
+	node, found, err := unstructured.NestedString(pool.Object, "spec", "node")
+	if err != nil || !found {
+		return "", "", fmt.Errorf("failed to read node of mayastor pool %s, found %v, error %v", pool.GetName(), found, err)
+	}
+	disks, found, err := unstructured.NestedStringSlice(pool.Object, "spec", "disks")
+	if err != nil || !found || len(disks) == 0 {
+		return "", "", fmt.Errorf("failed to read disks of mayastor pool %s, found %v, error %v", pool.GetName(), found, err)
+	}
+	return node, disks[0], nil
+}
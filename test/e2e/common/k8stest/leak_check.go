@@ -0,0 +1,87 @@
+package k8stest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// activePortForwards counts the port-forwards currently held open by
+// the harness; forwardToPod maintains it. Client-go watches and REST
+// keep-alives surface in the goroutine count instead, which is why the
+// leak check covers both.
+var activePortForwards int32
+
+// LeakSnapshot records the harness's goroutine and connection footprint
+// at a point in time, taken at suite start and compared at suite end.
+type LeakSnapshot struct {
+	Goroutines   int
+	PortForwards int32
+}
+
+// TakeLeakSnapshot captures the current footprint.
+func TakeLeakSnapshot() LeakSnapshot {
+	return LeakSnapshot{
+		Goroutines:   runtime.NumGoroutine(),
+		PortForwards: atomic.LoadInt32(&activePortForwards),
+	}
+}
+
+// CheckLeaks compares the current footprint against the baseline taken
+// at suite start. Goroutines are given settleSecs to wind down and a
+// configured slack for runtime-internal variance; port-forwards must
+// return to the baseline exactly. On a leak a goroutine dump artefact
+// is written and an error returned, or only a warning logged when the
+// leak check is configured warn-only.
+func CheckLeaks(baseline LeakSnapshot, settleSecs int) error {
+	cfg := e2e_config.GetConfig().LeakCheck
+	const sleepTime = 500 * time.Millisecond
+	var current LeakSnapshot
+	for ix := 0; ix < settleSecs*2; ix++ {
+		current = TakeLeakSnapshot()
+		if current.Goroutines <= baseline.Goroutines+cfg.GoroutineSlack &&
+			current.PortForwards == baseline.PortForwards {
+			return nil
+		}
+		time.Sleep(sleepTime)
+	}
+	err := fmt.Errorf(
+		"harness leak: goroutines %d -> %d (slack %d), port-forwards %d -> %d",
+		baseline.Goroutines, current.Goroutines, cfg.GoroutineSlack,
+		baseline.PortForwards, current.PortForwards)
+	dumpGoroutines()
+	if cfg.WarnOnly {
+		logf.Log.Info("leak check failed", "error", err)
+		return nil
+	}
+	return err
+}
+
+// dumpGoroutines writes a goroutine dump artefact so the leaking module
+// can be identified from CI output.
+func dumpGoroutines() {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		return
+	}
+	var dump strings.Builder
+	if err := pprof.Lookup("goroutine").WriteTo(&dump, 1); err != nil {
+		logf.Log.Info("failed to collect goroutine dump", "error", err)
+		return
+	}
+	filename := path.Join(reportsDir, fmt.Sprintf("goroutine-leak-%d.txt", time.Now().Unix()))
+	if err := os.WriteFile(filename, []byte(dump.String()), 0644); err != nil {
+		logf.Log.Info("failed to write goroutine dump artefact", "file", filename, "error", err)
+	} else {
+		logf.Log.Info("wrote goroutine dump artefact", "artefact", filename)
+	}
+}
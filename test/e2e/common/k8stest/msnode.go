@@ -0,0 +1,177 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Typed view of the MayastorNode custom resource, through which the
+// control plane publishes the registration state of each io-engine.
+
+var msnGVR = schema.GroupVersionResource{
+	Group:    "openebs.io",
+	Version:  "v1alpha1",
+	Resource: "mayastornodes",
+}
+
+// ioEngineDaemonSetName is the daemonset running the io-engine.
+const ioEngineDaemonSetName = "mayastor"
+
+// MsNode is the typed MayastorNode.
+type MsNode struct {
+	Name string
+	// GrpcEndpoint is the io-engine grpc endpoint the node registered
+	// with the control plane.
+	GrpcEndpoint string
+	// State is the registration state reported by the control plane,
+	// e.g. "online" or "offline".
+	State string
+	// Pools are the names of the pool custom resources declared on the
+	// node; the node resource does not carry them itself, they are
+	// collated from the pool resources.
+	Pools []string
+}
+
+// MsNodeFromUnstructured converts an unstructured MayastorNode into the
+// typed representation, without the pool list.
+func MsNodeFromUnstructured(raw *unstructured.Unstructured) MsNode {
+	node := MsNode{Name: raw.GetName()}
+	node.GrpcEndpoint, _, _ = unstructured.NestedString(raw.Object, "spec", "grpcEndpoint")
+	// MOAC reports the state as a bare status string; newer control
+	// planes nest it under status.state.
+	if state, found, _ := unstructured.NestedString(raw.Object, "status"); found {
+		node.State = state
+	} else {
+		node.State, _, _ = unstructured.NestedString(raw.Object, "status", "state")
+	}
+	return node
+}
+
+// msNodePools collates the pool custom resource names per node.
+func msNodePools() (map[string][]string, error) {
+	pools, err := ListMsPools()
+	if err != nil {
+		return nil, err
+	}
+	byNode := map[string][]string{}
+	for _, pool := range pools {
+		nodeName, _, err := MsPoolNodeAndDisk(pool)
+		if err != nil {
+			return nil, err
+		}
+		byNode[nodeName] = append(byNode[nodeName], pool.GetName())
+	}
+	return byNode, nil
+}
+
+// GetMsNode retrieves the mayastor node custom resource of the given
+// node, with the pool list filled in.
+func GetMsNode(nodeName string) (*MsNode, error) {
+	raw, err := gTestEnv.DynamicClient.Resource(msnGVR).Namespace(MayastorNamespace).Get(context.TODO(), nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mayastor node %s, error %v", nodeName, err)
+	}
+	node := MsNodeFromUnstructured(raw)
+	pools, err := msNodePools()
+	if err != nil {
+		return nil, err
+	}
+	node.Pools = pools[node.Name]
+	return &node, nil
+}
+
+// ListMsNodes retrieves all mayastor node custom resources, with the
+// pool lists filled in.
+func ListMsNodes() ([]MsNode, error) {
+	raws, err := listPaged(msnGVR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mayastor nodes, error %v", err)
+	}
+	pools, err := msNodePools()
+	if err != nil {
+		return nil, err
+	}
+	var nodes []MsNode
+	for ix := range raws {
+		node := MsNodeFromUnstructured(&raws[ix])
+		node.Pools = pools[node.Name]
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// WaitMsNodeOnline waits for up to timeoutSecs for the node to be
+// registered with the control plane and report the online state.
+func WaitMsNodeOnline(nodeName string, timeoutSecs int) error {
+	const sleepTime = 2
+	var state string
+	var err error
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		var node *MsNode
+		node, err = GetMsNode(nodeName)
+		if err == nil {
+			state = node.State
+			// the diskpool era control planes capitalise the state
+			if strings.EqualFold(state, "online") {
+				return nil
+			}
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("mayastor node %s not online after %d seconds, state %q, error %v",
+		nodeName, timeoutSecs, state, err)
+}
+
+// mayastorReadyOnce is a single readiness probe over the installation:
+// the io-engine daemonset must have a ready pod on every node it is
+// scheduled to, and the control plane must report every labelled node
+// as registered and online.
+func mayastorReadyOnce(nodeNames []string) error {
+	ds, err := gTestEnv.KubeInt.AppsV1().DaemonSets(MayastorNamespace).Get(context.TODO(), ioEngineDaemonSetName, metaV1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the io-engine daemonset, error %v", err)
+	}
+	if ds.Status.DesiredNumberScheduled == 0 ||
+		ds.Status.NumberReady != ds.Status.DesiredNumberScheduled {
+		return fmt.Errorf("io-engine daemonset has %d of %d pods ready",
+			ds.Status.NumberReady, ds.Status.DesiredNumberScheduled)
+	}
+	for _, nodeName := range nodeNames {
+		node, err := GetMsNode(nodeName)
+		if err != nil {
+			return fmt.Errorf("node %s is not registered with the control plane, error %v", nodeName, err)
+		}
+		if !strings.EqualFold(node.State, "online") {
+			return fmt.Errorf("node %s is registered but %q", nodeName, node.State)
+		}
+	}
+	return nil
+}
+
+// MayastorReady waits for up to timeoutSecs for the installation to be
+// ready to serve volumes. An io-engine pod can be Running well before
+// its engine has registered with the control plane, so readiness is
+// judged on the node custom resources as well as on daemonset
+// availability; the failure message names what is still wanting.
+func MayastorReady(timeoutSecs int) error {
+	const sleepTime = 5
+	nodeNames, err := GetMayastorNodeNames()
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		lastErr = mayastorReadyOnce(nodeNames)
+		if lastErr == nil {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("mayastor not ready after %d seconds: %v", timeoutSecs, lastErr)
+}
@@ -0,0 +1,246 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Typed view of the MayastorVolume custom resource, decoupled from the
+// unstructured representation so tests are not bound to one API
+// version's field layout.
+
+// MsvReplica is one replica of a volume as reported in the MSV status.
+type MsvReplica struct {
+	Node    string `json:"node"`
+	Pool    string `json:"pool"`
+	Uri     string `json:"uri"`
+	Offline bool   `json:"offline"`
+}
+
+// MsvChild is one nexus child as reported in the MSV status.
+type MsvChild struct {
+	Uri   string `json:"uri"`
+	State string `json:"state"`
+}
+
+// MsvNexus is the nexus of a volume as reported in the MSV status.
+type MsvNexus struct {
+	Node      string     `json:"node"`
+	DeviceUri string     `json:"deviceUri"`
+	State     string     `json:"state"`
+	Children  []MsvChild `json:"children"`
+}
+
+// MsvSpec is the desired state of a volume.
+type MsvSpec struct {
+	ReplicaCount   int      `json:"replicaCount"`
+	RequiredBytes  int64    `json:"requiredBytes"`
+	PreferredNodes []string `json:"preferredNodes"`
+	RequiredNodes  []string `json:"requiredNodes"`
+}
+
+// MsvStatus is the observed state of a volume.
+type MsvStatus struct {
+	State    string       `json:"state"`
+	Size     int64        `json:"size"`
+	Nexus    MsvNexus     `json:"nexus"`
+	Replicas []MsvReplica `json:"replicas"`
+}
+
+// Msv is the typed MayastorVolume.
+type Msv struct {
+	Name            string
+	ResourceVersion string
+	Spec            MsvSpec
+	Status          MsvStatus
+}
+
+// DeepCopy returns a copy sharing no memory with the original.
+func (msv *Msv) DeepCopy() *Msv {
+	if msv == nil {
+		return nil
+	}
+	copied := *msv
+	copied.Spec.PreferredNodes = append([]string(nil), msv.Spec.PreferredNodes...)
+	copied.Spec.RequiredNodes = append([]string(nil), msv.Spec.RequiredNodes...)
+	copied.Status.Replicas = append([]MsvReplica(nil), msv.Status.Replicas...)
+	copied.Status.Nexus.Children = append([]MsvChild(nil), msv.Status.Nexus.Children...)
+	return &copied
+}
+
+// msvVersions are the API versions the typed client understands, in
+// preference order; lookups fall through to older versions so suites
+// keep working across an API bump.
+var msvVersions = []schema.GroupVersionResource{
+	msvGVR,
+}
+
+// intFromUnstructured reads an integer spec or status field under any
+// of the given keys; API versions renamed some fields, e.g. the
+// replica count.
+func intFromUnstructured(obj map[string]interface{}, section string, keys ...string) int64 {
+	for _, key := range keys {
+		if value, found, err := unstructured.NestedInt64(obj, section, key); found && err == nil {
+			return value
+		}
+	}
+	return 0
+}
+
+// MsvFromUnstructured converts an unstructured MayastorVolume of any
+// supported API version into the typed representation.
+func MsvFromUnstructured(raw *unstructured.Unstructured) (*Msv, error) {
+	msv := Msv{
+		Name:            raw.GetName(),
+		ResourceVersion: raw.GetResourceVersion(),
+	}
+	// Newer API versions use numReplicas; v1alpha1 uses replicaCount.
+	msv.Spec.ReplicaCount = int(intFromUnstructured(raw.Object, "spec", "replicaCount", "numReplicas"))
+	msv.Spec.RequiredBytes = intFromUnstructured(raw.Object, "spec", "requiredBytes")
+	msv.Spec.PreferredNodes, _, _ = unstructured.NestedStringSlice(raw.Object, "spec", "preferredNodes")
+	msv.Spec.RequiredNodes, _, _ = unstructured.NestedStringSlice(raw.Object, "spec", "requiredNodes")
+
+	msv.Status.State, _, _ = unstructured.NestedString(raw.Object, "status", "state")
+	msv.Status.Size = intFromUnstructured(raw.Object, "status", "size")
+	msv.Status.Nexus.Node, _, _ = unstructured.NestedString(raw.Object, "status", "nexus", "node")
+	msv.Status.Nexus.DeviceUri, _, _ = unstructured.NestedString(raw.Object, "status", "nexus", "deviceUri")
+	msv.Status.Nexus.State, _, _ = unstructured.NestedString(raw.Object, "status", "nexus", "state")
+
+	children, _, err := unstructured.NestedSlice(raw.Object, "status", "nexus", "children")
+	if err != nil {
+		return nil, fmt.Errorf("malformed nexus children on MSV %s, error %v", raw.GetName(), err)
+	}
+	for _, rawChild := range children {
+		if child, ok := rawChild.(map[string]interface{}); ok {
+			uri, _ := child["uri"].(string)
+			state, _ := child["state"].(string)
+			msv.Status.Nexus.Children = append(msv.Status.Nexus.Children, MsvChild{Uri: uri, State: state})
+		}
+	}
+	replicas, _, err := unstructured.NestedSlice(raw.Object, "status", "replicas")
+	if err != nil {
+		return nil, fmt.Errorf("malformed replicas on MSV %s, error %v", raw.GetName(), err)
+	}
+	for _, rawReplica := range replicas {
+		if replica, ok := rawReplica.(map[string]interface{}); ok {
+			node, _ := replica["node"].(string)
+			pool, _ := replica["pool"].(string)
+			uri, _ := replica["uri"].(string)
+			offline, _ := replica["offline"].(bool)
+			msv.Status.Replicas = append(msv.Status.Replicas, MsvReplica{Node: node, Pool: pool, Uri: uri, Offline: offline})
+		}
+	}
+	return &msv, nil
+}
+
+// GetMsv retrieves a volume by uuid as the typed representation,
+// trying each supported API version in turn.
+func GetMsv(uuid string) (*Msv, error) {
+	var lastErr error
+	for _, gvr := range msvVersions {
+		raw, err := gTestEnv.DynamicClient.Resource(gvr).Namespace(MayastorNamespace).Get(context.TODO(), uuid, metaV1.GetOptions{})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return MsvFromUnstructured(raw)
+	}
+	return nil, fmt.Errorf("failed to get MSV %s on any supported API version, error %v", uuid, lastErr)
+}
+
+// ListMsv retrieves all volumes as the typed representation.
+func ListMsv() ([]Msv, error) {
+	raws, err := ListMsvs()
+	if err != nil {
+		return nil, err
+	}
+	var msvs []Msv
+	for ix := range raws {
+		msv, err := MsvFromUnstructured(&raws[ix])
+		if err != nil {
+			return nil, err
+		}
+		msvs = append(msvs, *msv)
+	}
+	return msvs, nil
+}
+
+// GetMsvNexusNode returns the node hosting the nexus of a volume.
+func GetMsvNexusNode(uuid string) (string, error) {
+	msv, err := GetMsv(uuid)
+	if err != nil {
+		return "", err
+	}
+	if msv.Status.Nexus.Node == "" {
+		return "", fmt.Errorf("MSV %s has no nexus node, volume not published", uuid)
+	}
+	return msv.Status.Nexus.Node, nil
+}
+
+// WaitMsvDeleted waits for up to timeoutSecs for the volume custom
+// resource to go away on every supported API version; used to verify
+// volumes are not leaked after their claim is released.
+func WaitMsvDeleted(uuid string, timeoutSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		gone := true
+		for _, gvr := range msvVersions {
+			_, err := gTestEnv.DynamicClient.Resource(gvr).Namespace(MayastorNamespace).Get(context.TODO(), uuid, metaV1.GetOptions{})
+			if err == nil {
+				gone = false
+				break
+			}
+			if !k8serrors.IsNotFound(err) {
+				return fmt.Errorf("failed to get MSV %s, error %v", uuid, err)
+			}
+		}
+		if gone {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("MSV %s still present after %d seconds", uuid, timeoutSecs)
+}
+
+// MsvReplicaNodes returns the nodes holding a replica of the volume.
+func MsvReplicaNodes(uuid string) ([]string, error) {
+	msv, err := GetMsv(uuid)
+	if err != nil {
+		return nil, err
+	}
+	var nodeNames []string
+	for _, replica := range msv.Status.Replicas {
+		nodeNames = append(nodeNames, replica.Node)
+	}
+	return nodeNames, nil
+}
+
+// WaitMsvNexusMoved waits for up to timeoutSecs for the nexus of a
+// volume to be republished on a node other than fromNode, and returns
+// the new node. Used by failover tests after taking down the io-engine
+// hosting the nexus.
+func WaitMsvNexusMoved(uuid string, fromNode string, timeoutSecs int) (string, error) {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		msv, err := GetMsv(uuid)
+		if err == nil && msv.Status.Nexus.Node != "" && msv.Status.Nexus.Node != fromNode {
+			return msv.Status.Nexus.Node, nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return "", fmt.Errorf("nexus of MSV %s not republished off node %s within %d seconds", uuid, fromNode, timeoutSecs)
+}
+
+// WatchMsv opens a watch over the volume custom resources; consumers
+// convert events with MsvFromUnstructured. The caller must stop the
+// returned watch.
+func WatchMsv() (watch.Interface, error) {
+	return gTestEnv.DynamicClient.Resource(msvGVR).Namespace(MayastorNamespace).Watch(context.TODO(), metaV1.ListOptions{})
+}
@@ -0,0 +1,50 @@
+package k8stest
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// FormatHostPort joins an address and port, bracketing IPv6 addresses
+// as required by URIs and dial strings. Helpers must use this rather
+// than concatenating with a colon, which is wrong for IPv6.
+func FormatHostPort(address string, port int) string {
+	return net.JoinHostPort(address, fmt.Sprintf("%d", port))
+}
+
+// NvmfTarget is the decomposition of an nvmf URI as published by the
+// io-engine, e.g. nvmf://10.0.0.1:8420/nqn.2019-05.io.openebs:volume or
+// nvmf://[fd00::1]:8420/nqn.2019-05.io.openebs:volume
+type NvmfTarget struct {
+	Address string
+	Port    string
+	Nqn     string
+}
+
+// IsIPv6 returns true if the target address is an IPv6 address.
+func (target NvmfTarget) IsIPv6() bool {
+	ip := net.ParseIP(target.Address)
+	return ip != nil && ip.To4() == nil
+}
+
+// ParseNvmfURI parses an nvmf URI, handling both IPv4 and bracketed
+// IPv6 addresses.
+func ParseNvmfURI(uri string) (*NvmfTarget, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nvmf URI %q, error %v", uri, err)
+	}
+	if parsed.Scheme != "nvmf" && parsed.Scheme != "nvmf+tcp" {
+		return nil, fmt.Errorf("URI %q is not an nvmf URI", uri)
+	}
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("nvmf URI %q has no host", uri)
+	}
+	return &NvmfTarget{
+		Address: parsed.Hostname(),
+		Port:    parsed.Port(),
+		Nqn:     strings.TrimPrefix(parsed.Path, "/"),
+	}, nil
+}
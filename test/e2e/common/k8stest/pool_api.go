@@ -0,0 +1,131 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// The pool custom resource comes in several flavours: MOAC-era clusters
+// serve mayastorpools v1alpha1, control-plane-era clusters serve
+// diskpools in v1beta1 and newer. The installed flavour is discovered
+// once and all pool helpers route through it, so suites work against
+// either generation of cluster.
+
+// poolApi describes one supported pool CRD flavour.
+type poolApi struct {
+	gvr  schema.GroupVersionResource
+	kind string
+}
+
+// poolApis lists the supported flavours, newest first; discovery picks
+// the first one the cluster serves.
+var poolApis = []poolApi{
+	{schema.GroupVersionResource{Group: "openebs.io", Version: "v1", Resource: "diskpools"}, "DiskPool"},
+	{schema.GroupVersionResource{Group: "openebs.io", Version: "v1beta2", Resource: "diskpools"}, "DiskPool"},
+	{schema.GroupVersionResource{Group: "openebs.io", Version: "v1beta1", Resource: "diskpools"}, "DiskPool"},
+	{mspGVR, "MayastorPool"},
+}
+
+var poolApiMu sync.Mutex
+var poolApiDetected *poolApi
+
+// currentPoolApi returns the pool CRD flavour served by the cluster,
+// falling back to the MOAC-era flavour when discovery finds none, e.g.
+// against the fake test environment. The result is cached.
+func currentPoolApi() poolApi {
+	poolApiMu.Lock()
+	defer poolApiMu.Unlock()
+	if poolApiDetected != nil {
+		return *poolApiDetected
+	}
+	poolApiDetected = &poolApis[len(poolApis)-1]
+	for ix := range poolApis {
+		api := &poolApis[ix]
+		if ApiServed(api.gvr) {
+			poolApiDetected = api
+			logf.Log.Info("discovered pool CRD flavour",
+				"groupVersion", api.gvr.GroupVersion().String(), "resource", api.gvr.Resource)
+			break
+		}
+	}
+	return *poolApiDetected
+}
+
+// ResetPoolApiCache discards the cached pool CRD flavour so the next
+// pool operation rediscovers it; of use after reinstalling mayastor or
+// when tests mutate the fake discovery data.
+func ResetPoolApiCache() {
+	poolApiMu.Lock()
+	defer poolApiMu.Unlock()
+	poolApiDetected = nil
+}
+
+// PoolApiVersion returns the apiVersion of the pool CRD flavour in use,
+// e.g. "openebs.io/v1alpha1".
+func PoolApiVersion() string {
+	return currentPoolApi().gvr.GroupVersion().String()
+}
+
+// CheckAllPoolsAreOnline returns an error naming every pool which does
+// not report an online state.
+func CheckAllPoolsAreOnline() error {
+	pools, err := ListMsPools()
+	if err != nil {
+		return err
+	}
+	var offline []string
+	for _, pool := range pools {
+		state, _, _ := unstructured.NestedString(pool.Object, "status", "state")
+		if !strings.EqualFold(state, "online") {
+			offline = append(offline, fmt.Sprintf("%s (%s)", pool.GetName(), state))
+		}
+	}
+	if len(offline) != 0 {
+		return fmt.Errorf("pools not online: %s", strings.Join(offline, ", "))
+	}
+	return nil
+}
+
+// DeleteAllPools deletes every pool custom resource.
+func DeleteAllPools() error {
+	pools, err := ListMsPools()
+	if err != nil {
+		return err
+	}
+	for _, pool := range pools {
+		if err := DeleteMsPool(pool.GetName()); err != nil {
+			return fmt.Errorf("failed to delete pool %s, error %v", pool.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// DeleteAllPoolFinalizers strips the finalizers from every pool custom
+// resource, for teardown of clusters whose control plane is gone and
+// can no longer release the pools itself.
+func DeleteAllPoolFinalizers() error {
+	api := currentPoolApi()
+	pools, err := ListMsPools()
+	if err != nil {
+		return err
+	}
+	for ix := range pools {
+		pool := &pools[ix]
+		if len(pool.GetFinalizers()) == 0 {
+			continue
+		}
+		pool.SetFinalizers(nil)
+		_, err := gTestEnv.DynamicClient.Resource(api.gvr).Namespace(MayastorNamespace).Update(context.TODO(), pool, metaV1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to remove finalizers of pool %s, error %v", pool.GetName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hugePagesResource is the hugepage size mayastor requires.
+const hugePagesResource = coreV1.ResourceName("hugepages-2Mi")
+
+// PreflightFailure is one failed pre-flight validation.
+type PreflightFailure struct {
+	// Check names the validation which failed.
+	Check string
+	// Node is the node concerned, empty for cluster level checks.
+	Node string
+	// Error describes what was found wanting.
+	Error string
+}
+
+// PreflightReport collects the pre-flight validation failures.
+type PreflightReport struct {
+	Failures []PreflightFailure
+}
+
+// Ok returns true if every validation passed.
+func (report PreflightReport) Ok() bool {
+	return len(report.Failures) == 0
+}
+
+// String renders the report one failure per line.
+func (report PreflightReport) String() string {
+	var lines []string
+	for _, failure := range report.Failures {
+		if failure.Node != "" {
+			lines = append(lines, fmt.Sprintf("%s [%s]: %s", failure.Check, failure.Node, failure.Error))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %s", failure.Check, failure.Error))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (report *PreflightReport) fail(check string, node string, format string, args ...interface{}) {
+	report.Failures = append(report.Failures, PreflightFailure{
+		Check: check,
+		Node:  node,
+		Error: fmt.Sprintf(format, args...),
+	})
+}
+
+// poolDevicePath strips a scheme prefix - "aio://", "uring://" - from
+// a configured pool device, leaving the path checked on the node. The
+// second return is false for devices which do not name a path at all,
+// such as "nvme://" URIs carrying a PCIe address.
+func poolDevicePath(device string) (string, bool) {
+	if strings.HasPrefix(device, "nvme://") {
+		return "", false
+	}
+	if ix := strings.Index(device, "://"); ix != -1 {
+		return device[ix+3:], true
+	}
+	return device, true
+}
+
+// PreflightCheck validates the prerequisites mayastor needs from the
+// cluster before installation - the recurring causes of "pods stuck in
+// Init" and "Node not found" support issues - and returns a structured
+// report of everything found wanting. The error return covers failures
+// of the checks themselves, not of the cluster.
+func PreflightCheck() (PreflightReport, error) {
+	var report PreflightReport
+
+	nodeNames, err := GetMayastorNodeNames()
+	if err != nil {
+		return report, err
+	}
+	if len(nodeNames) == 0 {
+		report.fail("engine-label", "",
+			"no nodes are labelled %s=%s", engineLabel, engineLabelValue)
+		// The remaining checks are all per labelled node.
+		return report, nil
+	}
+
+	for _, nodeName := range nodeNames {
+		node, err := GetNode(nodeName)
+		if err != nil {
+			return report, err
+		}
+		hugePages := node.Status.Allocatable[hugePagesResource]
+		if hugePages.IsZero() {
+			report.fail("hugepages", nodeName,
+				"no %s allocatable, configure vm.nr_hugepages and restart kubelet", hugePagesResource)
+		}
+		if _, err := RunCommandOnNode(nodeName, "test -d /sys/module/nvme_tcp"); err != nil {
+			report.fail("nvme-tcp", nodeName,
+				"nvme_tcp kernel module not loaded, run modprobe nvme_tcp")
+		}
+		for _, configured := range e2e_config.GetConfig().PoolDevicesForNode(nodeName) {
+			device, checkable := poolDevicePath(configured)
+			if !checkable {
+				continue
+			}
+			// File backed pools - "aio://" and "uring://" URIs - need
+			// the path to exist; bare devices must be block devices.
+			check := fmt.Sprintf("test -b %s", device)
+			if configured != device {
+				check = fmt.Sprintf("test -e %s", device)
+			}
+			if _, err := RunCommandOnNode(nodeName, check); err != nil {
+				report.fail("pool-device", nodeName,
+					"pool device %s is not present on the node", device)
+			}
+		}
+	}
+
+	// When the etcd statefulset is already installed its storage class
+	// must exist, or its pods sit pending forever; before installation
+	// there is nothing to check against.
+	if sts, err := GetStatefulSet(EtcdStatefulSetName, MayastorNamespace); err == nil {
+		for _, claim := range sts.Spec.VolumeClaimTemplates {
+			if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName == "" {
+				continue
+			}
+			scName := *claim.Spec.StorageClassName
+			_, err := gTestEnv.KubeInt.StorageV1().StorageClasses().Get(context.TODO(), scName, metaV1.GetOptions{})
+			if err != nil {
+				report.fail("etcd-storage-class", "",
+					"storage class %s used by the etcd statefulset does not exist", scName)
+			}
+		}
+	}
+
+	return report, nil
+}
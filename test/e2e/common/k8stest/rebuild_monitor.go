@@ -0,0 +1,144 @@
+package k8stest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// RebuildEvent records one rebuild of one nexus child, gathered by the
+// rebuild monitor while a suite runs. Events are appended to the run
+// manifest so rebuild time trends can be charted across CI runs.
+type RebuildEvent struct {
+	Volume       string    `json:"volume"`
+	Child        string    `json:"child"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	DurationSecs float64   `json:"durationSecs"`
+	// Bytes is the volume size, i.e. the upper bound of data copied
+	Bytes int64 `json:"bytes"`
+}
+
+const rebuildMonitorPollSecs = 5
+
+type rebuildMonitor struct {
+	stopChan chan struct{}
+	doneChan chan struct{}
+	// rebuilds in flight, keyed by volume/child
+	active map[string]RebuildEvent
+	events []RebuildEvent
+}
+
+var theRebuildMonitor *rebuildMonitor
+
+// child states indicating a rebuild is in progress
+func childRebuilding(state string) bool {
+	return state == "CHILD_DEGRADED" || state == "degraded"
+}
+
+func (monitor *rebuildMonitor) poll() {
+	msvs, err := ListMsvs()
+	if err != nil {
+		// transient API errors must not kill the monitor
+		return
+	}
+	now := time.Now()
+	seen := map[string]bool{}
+	for _, msv := range msvs {
+		volume := msv.GetName()
+		size, _, _ := unstructured.NestedInt64(msv.Object, "spec", "requiredBytes")
+		children, found, err := unstructured.NestedSlice(msv.Object, "status", "nexus", "children")
+		if err != nil || !found {
+			continue
+		}
+		for _, rawChild := range children {
+			child, ok := rawChild.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			uri, _ := child["uri"].(string)
+			state, _ := child["state"].(string)
+			key := volume + "/" + uri
+			if childRebuilding(state) {
+				seen[key] = true
+				if _, inFlight := monitor.active[key]; !inFlight {
+					monitor.active[key] = RebuildEvent{
+						Volume: volume,
+						Child:  uri,
+						Start:  now,
+						Bytes:  size,
+					}
+				}
+			}
+		}
+	}
+	// rebuilds no longer observed have completed (or their volume is
+	// gone)
+	for key, event := range monitor.active {
+		if !seen[key] {
+			event.End = now
+			event.DurationSecs = event.End.Sub(event.Start).Seconds()
+			monitor.events = append(monitor.events, event)
+			delete(monitor.active, key)
+		}
+	}
+}
+
+func (monitor *rebuildMonitor) run() {
+	defer close(monitor.doneChan)
+	ticker := time.NewTicker(rebuildMonitorPollSecs * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-monitor.stopChan:
+			monitor.poll()
+			return
+		case <-ticker.C:
+			monitor.poll()
+		}
+	}
+}
+
+// StartRebuildMonitor starts the background rebuild monitor; called from
+// SetupTestEnv so rebuild history is gathered during any suite.
+func StartRebuildMonitor() {
+	if theRebuildMonitor != nil {
+		return
+	}
+	theRebuildMonitor = &rebuildMonitor{
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+		active:   map[string]RebuildEvent{},
+	}
+	go theRebuildMonitor.run()
+}
+
+// StopRebuildMonitor stops the rebuild monitor and appends the gathered
+// rebuild events to the run manifest; called from TeardownTestEnv.
+func StopRebuildMonitor() error {
+	if theRebuildMonitor == nil {
+		return nil
+	}
+	monitor := theRebuildMonitor
+	theRebuildMonitor = nil
+	close(monitor.stopChan)
+	<-monitor.doneChan
+
+	// rebuilds still in flight at teardown are recorded without an end
+	// time
+	for _, event := range monitor.active {
+		monitor.events = append(monitor.events, event)
+	}
+	if len(monitor.events) == 0 {
+		return nil
+	}
+	logf.Log.Info("rebuild monitor", "events", len(monitor.events))
+	if err := manifest.AppendSection("rebuilds", monitor.events); err != nil {
+		return fmt.Errorf("failed to persist rebuild history, error %v", err)
+	}
+	return nil
+}
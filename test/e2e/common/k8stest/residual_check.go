@@ -0,0 +1,158 @@
+package k8stest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// mayastorNqnPrefix is the NQN prefix of every subsystem the io-engine
+// exports; an initiator connection with this prefix is a mayastor one.
+const mayastorNqnPrefix = "nqn.2019-05.io.openebs"
+
+// mayastorNvmfPort is the TCP port the io-engine nvmf target listens on.
+const mayastorNvmfPort = 8420
+
+// tcpListenState is the LISTEN socket state as encoded in /proc/net/tcp.
+const tcpListenState = "0A"
+
+// HasTcpListener reports whether the /proc/net/tcp style table contains
+// a listening socket on the given port. Lines which do not parse are
+// skipped.
+func HasTcpListener(procNetTcp string, port int) bool {
+	for _, line := range strings.Split(procNetTcp, "\n") {
+		fields := strings.Fields(line)
+		// sl local_address rem_address st ...
+		if len(fields) < 4 || fields[3] != tcpListenState {
+			continue
+		}
+		_, portHex, found := strings.Cut(fields[1], ":")
+		if !found {
+			continue
+		}
+		localPort, err := strconv.ParseInt(portHex, 16, 32)
+		if err == nil && int(localPort) == port {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHugePages extracts the total and free hugepage counts from
+// /proc/meminfo contents.
+func ParseHugePages(meminfo string) (total int, free int) {
+	for _, line := range strings.Split(meminfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "HugePages_Total:":
+			total = count
+		case "HugePages_Free:":
+			free = count
+		}
+	}
+	return total, free
+}
+
+// residualHostStateOnce inspects the hosts once for state an uninstall
+// should have released, reusing the preflight report structure: the
+// nvmf target port must no longer be listening and hugepages must be
+// free on the former io-engine nodes, no node may still hold an nvmf
+// connection to a mayastor subsystem, and - when cleanup is enabled -
+// the configured data directories must be empty. The error return
+// covers failures of the checks themselves, not residual state.
+func residualHostStateOnce() (PreflightReport, error) {
+	var report PreflightReport
+
+	engineNodes, err := GetMayastorNodeNames()
+	if err != nil {
+		return report, err
+	}
+	nodeLocs, err := GetNodeLocs()
+	if err != nil {
+		return report, err
+	}
+
+	for _, nodeName := range engineNodes {
+		sockets, err := RunCommandOnNode(nodeName, "cat /proc/net/tcp /proc/net/tcp6 2>/dev/null; true")
+		if err != nil {
+			return report, err
+		}
+		if HasTcpListener(sockets, mayastorNvmfPort) {
+			report.fail("nvmf-target", nodeName,
+				"something still listens on the nvmf port %d", mayastorNvmfPort)
+		}
+		meminfo, err := RunCommandOnNode(nodeName, "cat /proc/meminfo")
+		if err != nil {
+			return report, err
+		}
+		if total, free := ParseHugePages(meminfo); free < total {
+			report.fail("hugepages", nodeName,
+				"%d of %d hugepages still in use", total-free, total)
+		}
+		if e2e_config.GetConfig().Cleanup != 0 {
+			for _, dataDir := range e2e_config.GetConfig().DataDirs {
+				contents, err := RunCommandOnNode(nodeName, fmt.Sprintf("ls -A %s 2>/dev/null; true", dataDir))
+				if err != nil {
+					return report, err
+				}
+				if leftovers := strings.Fields(contents); len(leftovers) != 0 {
+					report.fail("data-dir", nodeName,
+						"%s not cleaned, contains %s", dataDir, strings.Join(leftovers, " "))
+				}
+			}
+		}
+	}
+
+	// Application nodes hold the initiator side; any node may have
+	// mounted a volume, so check them all.
+	for _, nodeLoc := range nodeLocs {
+		nqns, err := RunCommandOnNode(nodeLoc.NodeName, "cat /sys/class/nvme/*/subsysnqn 2>/dev/null; true")
+		if err != nil {
+			return report, err
+		}
+		for _, nqn := range strings.Fields(nqns) {
+			if strings.HasPrefix(nqn, mayastorNqnPrefix) {
+				report.fail("nvmf-initiator", nodeLoc.NodeName,
+					"still connected to mayastor subsystem %s", nqn)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// VerifyUninstalledHostState waits for up to timeoutSecs for the hosts
+// to be free of residual mayastor state - exported nvmf subsystems,
+// held hugepages, lingering initiator connections and, when cleanup is
+// enabled, data directory contents. The k8s resources going away does
+// not prove any of this: the checks poll because terminating io-engine
+// pods release host state some time after their API objects are gone.
+func VerifyUninstalledHostState(timeoutSecs int) error {
+	const sleepTime = 10
+	var report PreflightReport
+	var err error
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		report, err = residualHostStateOnce()
+		if err != nil {
+			return err
+		}
+		if report.Ok() {
+			return nil
+		}
+		logf.Log.Info("residual host state after uninstall, retrying", "report", report.String())
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("residual host state %d seconds after uninstall:\n%s", timeoutSecs, report.String())
+}
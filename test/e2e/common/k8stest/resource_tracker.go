@@ -0,0 +1,195 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// TrackedResource records one resource created through a common helper
+// and the spec which created it, so a leak can be attributed to the
+// spec responsible rather than merely detected.
+type TrackedResource struct {
+	// Kind is the tracker's resource kind: "pvc", "pod",
+	// "statefulset", "storageclass", "namespace" or "pool".
+	Kind string
+	// Name of the resource.
+	Name string
+	// Namespace of the resource, empty for cluster scoped kinds.
+	Namespace string
+	// Spec is the full text of the spec running when the resource was
+	// created, empty for suite level setup.
+	Spec string
+}
+
+var trackerMutex sync.Mutex
+var trackedResources []TrackedResource
+var currentSpecText string
+
+// SetCurrentSpecText records the spec now running, so resources created
+// through the common helpers are attributed to it. The reporter sets it
+// from the ginkgo lifecycle; an empty text marks suite level setup.
+func SetCurrentSpecText(text string) {
+	trackerMutex.Lock()
+	defer trackerMutex.Unlock()
+	currentSpecText = text
+}
+
+// trackResource records a resource created through a common helper
+// against the spec now running. Canary resources are long lived by
+// design and never tracked.
+func trackResource(kind string, name string, nameSpace string) {
+	if strings.HasPrefix(name, canaryPrefix) {
+		return
+	}
+	trackerMutex.Lock()
+	defer trackerMutex.Unlock()
+	removeTrackedLocked(kind, name, nameSpace)
+	trackedResources = append(trackedResources, TrackedResource{
+		Kind:      kind,
+		Name:      name,
+		Namespace: nameSpace,
+		Spec:      currentSpecText,
+	})
+}
+
+// untrackResource removes a resource from the tracker, called by the
+// delete helpers.
+func untrackResource(kind string, name string, nameSpace string) {
+	trackerMutex.Lock()
+	defer trackerMutex.Unlock()
+	removeTrackedLocked(kind, name, nameSpace)
+}
+
+func removeTrackedLocked(kind string, name string, nameSpace string) {
+	for ix, entry := range trackedResources {
+		if entry.Kind == kind && entry.Name == name && entry.Namespace == nameSpace {
+			trackedResources = append(trackedResources[:ix], trackedResources[ix+1:]...)
+			return
+		}
+	}
+}
+
+// trackedForSpec returns the tracked resources attributed to the given
+// spec.
+func trackedForSpec(specText string) []TrackedResource {
+	trackerMutex.Lock()
+	defer trackerMutex.Unlock()
+	var entries []TrackedResource
+	for _, entry := range trackedResources {
+		if entry.Spec == specText {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// trackedResourceExists checks whether the resource is still present on
+// the cluster; resources deleted outside the common helpers are
+// silently untracked by the caller.
+func trackedResourceExists(entry TrackedResource) (bool, error) {
+	var err error
+	switch entry.Kind {
+	case "pvc":
+		_, err = gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(entry.Namespace).Get(context.TODO(), entry.Name, metaV1.GetOptions{})
+	case "pod":
+		_, err = gTestEnv.KubeInt.CoreV1().Pods(entry.Namespace).Get(context.TODO(), entry.Name, metaV1.GetOptions{})
+	case "statefulset":
+		_, err = gTestEnv.KubeInt.AppsV1().StatefulSets(entry.Namespace).Get(context.TODO(), entry.Name, metaV1.GetOptions{})
+	case "storageclass":
+		_, err = gTestEnv.KubeInt.StorageV1().StorageClasses().Get(context.TODO(), entry.Name, metaV1.GetOptions{})
+	case "namespace":
+		_, err = gTestEnv.KubeInt.CoreV1().Namespaces().Get(context.TODO(), entry.Name, metaV1.GetOptions{})
+	case "pool":
+		_, err = gTestEnv.DynamicClient.Resource(currentPoolApi().gvr).Namespace(MayastorNamespace).Get(context.TODO(), entry.Name, metaV1.GetOptions{})
+	default:
+		return false, fmt.Errorf("unknown tracked resource kind %q", entry.Kind)
+	}
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteTrackedResource removes a leaked resource through the matching
+// common helper, so the deletion is also untracked.
+func deleteTrackedResource(entry TrackedResource) error {
+	switch entry.Kind {
+	case "pvc":
+		return DeletePVC(entry.Name, entry.Namespace)
+	case "pod":
+		return DeletePod(entry.Name, entry.Namespace)
+	case "statefulset":
+		return RmStatefulSet(entry.Name, entry.Namespace)
+	case "storageclass":
+		return RmStorageClass(entry.Name)
+	case "namespace":
+		return DeleteNamespace(entry.Name)
+	case "pool":
+		return DeleteMsPool(entry.Name)
+	default:
+		return fmt.Errorf("unknown tracked resource kind %q", entry.Kind)
+	}
+}
+
+func describeTracked(entry TrackedResource) string {
+	if entry.Namespace != "" {
+		return fmt.Sprintf("%s %s/%s", entry.Kind, entry.Namespace, entry.Name)
+	}
+	return fmt.Sprintf("%s %s", entry.Kind, entry.Name)
+}
+
+// AfterEachCheck verifies the finished spec cleaned up every resource
+// it created through the common helpers, naming exactly what it leaked.
+// With afterEachCheck.autoClean set, leaked resources are deleted and
+// only logged instead of failing the spec. It also flags mayastor
+// container restarts since the BeforeEachCheck baseline which no
+// budget - AllowRestarts or the configured restart tolerance - covers.
+func AfterEachCheck(specText string) error {
+	if err := checkRestarts(); err != nil {
+		return err
+	}
+	var leaked []TrackedResource
+	for _, entry := range trackedForSpec(specText) {
+		exists, err := trackedResourceExists(entry)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// Deleted outside the common helpers; not a leak.
+			untrackResource(entry.Kind, entry.Name, entry.Namespace)
+			continue
+		}
+		leaked = append(leaked, entry)
+	}
+	if len(leaked) == 0 {
+		return nil
+	}
+
+	var described []string
+	for _, entry := range leaked {
+		described = append(described, describeTracked(entry))
+	}
+	if !e2e_config.GetConfig().AfterEachCheck.AutoClean {
+		return fmt.Errorf("spec leaked %s", strings.Join(described, ", "))
+	}
+	logf.Log.Info("AfterEachCheck: cleaning resources leaked by spec",
+		"spec", specText, "leaked", strings.Join(described, ", "))
+	for _, entry := range leaked {
+		if err := deleteTrackedResource(entry); err != nil {
+			return fmt.Errorf("failed to clean leaked %s, error %v", describeTracked(entry), err)
+		}
+		untrackResource(entry.Kind, entry.Name, entry.Namespace)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package k8stest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+var restartMutex sync.Mutex
+
+// restartBaseline holds the container restart counts of the mayastor
+// pods as snapshotted before the running spec started, keyed
+// pod/container; the per-spec accounting flags only deltas against it,
+// so restarts from earlier specs are not reported twice.
+var restartBaseline map[string]int
+
+// restartAllowance is one restart budget: up to count container
+// restarts of the pods matching the expression.
+type restartAllowance struct {
+	pods  *regexp.Regexp
+	count int
+}
+
+// restartAllowances are the budgets registered through AllowRestarts;
+// they last for one spec.
+var restartAllowances []restartAllowance
+
+// AllowRestarts permits up to count container restarts of the mayastor
+// pods matching podRegexp during the current spec; called by specs
+// which restart components deliberately, e.g. upgrade rollouts and HA
+// failovers. The budget is cleared when the spec's accounting runs.
+// Standing tolerances for a whole suite go in the
+// afterEachCheck.restartTolerance configuration instead.
+func AllowRestarts(podRegexp string, count int) error {
+	re, err := regexp.Compile(podRegexp)
+	if err != nil {
+		return fmt.Errorf("invalid pod regexp %q, error %v", podRegexp, err)
+	}
+	if count < 1 {
+		return fmt.Errorf("invalid restart budget %d for pods %q", count, podRegexp)
+	}
+	restartMutex.Lock()
+	defer restartMutex.Unlock()
+	restartAllowances = append(restartAllowances, restartAllowance{pods: re, count: count})
+	return nil
+}
+
+// restartCounts reads the container restart counts of the mayastor
+// pods, keyed pod/container.
+func restartCounts() (map[string]int, error) {
+	podList, err := ListPodsBySelector(MayastorNamespace, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mayastor pods, error %v", err)
+	}
+	counts := map[string]int{}
+	for _, pod := range podList.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			counts[pod.Name+"/"+status.Name] = int(status.RestartCount)
+		}
+	}
+	return counts, nil
+}
+
+// snapshotRestartBaseline records the current restart counts as the
+// baseline the next accounting compares against.
+func snapshotRestartBaseline() error {
+	counts, err := restartCounts()
+	if err != nil {
+		return err
+	}
+	restartMutex.Lock()
+	defer restartMutex.Unlock()
+	restartBaseline = counts
+	return nil
+}
+
+// specAllowances returns the budgets in force for the finishing spec:
+// those registered through AllowRestarts - which are consumed - plus
+// the standing tolerances from the configuration.
+func specAllowances() ([]restartAllowance, error) {
+	restartMutex.Lock()
+	allowances := restartAllowances
+	restartAllowances = nil
+	restartMutex.Unlock()
+	for _, tolerance := range e2e_config.GetConfig().AfterEachCheck.RestartTolerance {
+		re, err := regexp.Compile(tolerance.Pods)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pod regexp %q in restart tolerance, error %v", tolerance.Pods, err)
+		}
+		allowances = append(allowances, restartAllowance{pods: re, count: tolerance.Count})
+	}
+	return allowances, nil
+}
+
+// checkRestarts compares the current restart counts against the
+// baseline and returns an error naming every container which restarted
+// beyond the budgets in force. A pod absent from the baseline - e.g.
+// recreated by its daemonset during the spec - counts from zero.
+func checkRestarts() error {
+	counts, err := restartCounts()
+	if err != nil {
+		return err
+	}
+	allowances, err := specAllowances()
+	if err != nil {
+		return err
+	}
+	restartMutex.Lock()
+	baseline := restartBaseline
+	restartMutex.Unlock()
+
+	containers := make([]string, 0, len(counts))
+	for container := range counts {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	var unexpected []string
+	for _, container := range containers {
+		delta := counts[container] - baseline[container]
+		if delta <= 0 {
+			continue
+		}
+		budgeted := false
+		for ix := range allowances {
+			if !allowances[ix].pods.MatchString(strings.Split(container, "/")[0]) {
+				continue
+			}
+			budgeted = true
+			allowances[ix].count -= delta
+			if allowances[ix].count < 0 {
+				budgeted = false
+			}
+			break
+		}
+		if !budgeted {
+			unexpected = append(unexpected, fmt.Sprintf("%s (%d)", container, delta))
+		}
+	}
+	if len(unexpected) != 0 {
+		return fmt.Errorf("unexpected container restarts: %s", strings.Join(unexpected, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package k8stest
+
+import (
+	"sort"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// StorageClassBuilder assembles a mayastor storage class from optional
+// parts; the Mk* helpers cover the common shapes, the builder covers
+// everything else - arbitrary parameters such as thin, fsType or local,
+// combined with reclaim policy, expansion and topology restrictions.
+type StorageClassBuilder struct {
+	name           string
+	replicas       int
+	protocol       string
+	params         map[string]string
+	reclaimPolicy  coreV1.PersistentVolumeReclaimPolicy
+	allowExpansion bool
+	lateBinding    bool
+	topologies     []coreV1.TopologySelectorTerm
+	mountOptions   []string
+}
+
+// NewStorageClassBuilder returns a builder for a storage class with the
+// given name, defaulting to the configured replica count, the nvmf
+// protocol and a delete reclaim policy.
+func NewStorageClassBuilder(scName string) *StorageClassBuilder {
+	return &StorageClassBuilder{
+		name:          scName,
+		replicas:      e2e_config.GetConfig().DefaultReplicaCount,
+		protocol:      "nvmf",
+		params:        map[string]string{},
+		reclaimPolicy: coreV1.PersistentVolumeReclaimDelete,
+	}
+}
+
+// WithReplicas sets the replica count.
+func (b *StorageClassBuilder) WithReplicas(replicas int) *StorageClassBuilder {
+	b.replicas = replicas
+	return b
+}
+
+// WithProtocol sets the share protocol.
+func (b *StorageClassBuilder) WithProtocol(protocol string) *StorageClassBuilder {
+	b.protocol = protocol
+	return b
+}
+
+// WithParam sets an arbitrary storage class parameter.
+func (b *StorageClassBuilder) WithParam(key string, value string) *StorageClassBuilder {
+	b.params[key] = value
+	return b
+}
+
+// WithThin marks volumes of this class as thin provisioned.
+func (b *StorageClassBuilder) WithThin(thin bool) *StorageClassBuilder {
+	if thin {
+		return b.WithParam("thin", "true")
+	}
+	return b.WithParam("thin", "false")
+}
+
+// WithFsType sets the filesystem the volume is formatted with.
+func (b *StorageClassBuilder) WithFsType(fsType string) *StorageClassBuilder {
+	return b.WithParam("fsType", fsType)
+}
+
+// WithLocal requests locality, placing a replica on the node running the
+// application.
+func (b *StorageClassBuilder) WithLocal(local bool) *StorageClassBuilder {
+	if local {
+		return b.WithParam("local", "true")
+	}
+	return b.WithParam("local", "false")
+}
+
+// topologyLabelParam joins labels into the comma separated key=value
+// form the topology parameters carry, sorted for determinism.
+func topologyLabelParam(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// WithPoolAffinity restricts replica placement to pools carrying the
+// given labels.
+func (b *StorageClassBuilder) WithPoolAffinity(labels map[string]string) *StorageClassBuilder {
+	return b.WithParam("poolAffinityTopologyLabel", topologyLabelParam(labels))
+}
+
+// WithNodeAffinity restricts replica placement to nodes carrying the
+// given labels.
+func (b *StorageClassBuilder) WithNodeAffinity(labels map[string]string) *StorageClassBuilder {
+	return b.WithParam("nodeAffinityTopologyLabel", topologyLabelParam(labels))
+}
+
+// WithReclaimPolicy sets the reclaim policy.
+func (b *StorageClassBuilder) WithReclaimPolicy(reclaimPolicy coreV1.PersistentVolumeReclaimPolicy) *StorageClassBuilder {
+	b.reclaimPolicy = reclaimPolicy
+	return b
+}
+
+// WithExpansion allows volume expansion.
+func (b *StorageClassBuilder) WithExpansion() *StorageClassBuilder {
+	b.allowExpansion = true
+	return b
+}
+
+// WithLateBinding defers binding until a consumer pod is scheduled,
+// i.e. WaitForFirstConsumer instead of the immediate default.
+func (b *StorageClassBuilder) WithLateBinding() *StorageClassBuilder {
+	b.lateBinding = true
+	return b
+}
+
+// WithTopology restricts provisioning to the given topologies and
+// implies WaitForFirstConsumer binding.
+func (b *StorageClassBuilder) WithTopology(topologies []coreV1.TopologySelectorTerm) *StorageClassBuilder {
+	b.topologies = topologies
+	return b
+}
+
+// WithMountOptions sets the mount options applied when volumes of this
+// class are mounted, e.g. "noatime".
+func (b *StorageClassBuilder) WithMountOptions(mountOptions []string) *StorageClassBuilder {
+	b.mountOptions = mountOptions
+	return b
+}
+
+// Build creates the storage class.
+func (b *StorageClassBuilder) Build() error {
+	return mkStorageClass(b.name, b.replicas, b.protocol, b.params, b.reclaimPolicy, b.allowExpansion, b.lateBinding, b.topologies, b.mountOptions)
+}
@@ -0,0 +1,122 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	"gopkg.in/yaml.v3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// dmesgLines bounds the kernel log excerpt collected per node.
+const dmesgLines = 500
+
+// CollectSupportBundle gathers the cluster state needed to analyse a
+// spec failure offline - mayastor pod logs, the volume, pool and node
+// objects, recent events and the kernel log of every io-engine node -
+// into a timestamped directory under the reports directory. Collection
+// is best effort: anything which cannot be gathered is recorded in the
+// bundle's errors file instead of failing the collection. Returns the
+// bundle directory, empty when no reports directory is configured.
+func CollectSupportBundle(tag string) (string, error) {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		return "", nil
+	}
+	bundleDir := path.Join(reportsDir,
+		fmt.Sprintf("bundle-%s-%s", tag, time.Now().Format("20060102-150405")))
+	if err := os.MkdirAll(bundleDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create bundle directory %s, error %v", bundleDir, err)
+	}
+
+	var problems []string
+	note := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	writeFile := func(filename string, contents string) {
+		if err := os.WriteFile(path.Join(bundleDir, filename), []byte(contents), 0644); err != nil {
+			note("failed to write %s, error %v", filename, err)
+		}
+	}
+	writeYaml := func(filename string, obj interface{}) {
+		contents, err := yaml.Marshal(obj)
+		if err != nil {
+			note("failed to marshal %s, error %v", filename, err)
+			return
+		}
+		writeFile(filename, string(contents))
+	}
+
+	// Logs of every pod in the mayastor namespace.
+	if pods, err := ListPodsBySelector(MayastorNamespace, ""); err != nil {
+		note("failed to list mayastor pods, error %v", err)
+	} else {
+		for _, pod := range pods.Items {
+			log, err := GetPodLog(pod.Name, MayastorNamespace)
+			if err != nil {
+				note("failed to get log of pod %s, error %v", pod.Name, err)
+				continue
+			}
+			writeFile(fmt.Sprintf("pod-%s.log", pod.Name), log)
+		}
+	}
+
+	// The mayastor custom resources and the node objects.
+	if msvs, err := ListMsvs(); err != nil {
+		note("failed to list volumes, error %v", err)
+	} else {
+		writeYaml("msvs.yaml", msvs)
+	}
+	if pools, err := ListMsPools(); err != nil {
+		note("failed to list pools, error %v", err)
+	} else {
+		writeYaml("pools.yaml", pools)
+	}
+	if nodes, err := gTestEnv.KubeInt.CoreV1().Nodes().List(context.TODO(), metaV1.ListOptions{}); err != nil {
+		note("failed to list nodes, error %v", err)
+	} else {
+		writeYaml("nodes.yaml", nodes.Items)
+	}
+
+	// Recent events across all namespaces, time ordered.
+	if events, err := gTestEnv.KubeInt.CoreV1().Events(metaV1.NamespaceAll).List(context.TODO(), metaV1.ListOptions{}); err != nil {
+		note("failed to list events, error %v", err)
+	} else {
+		sort.Slice(events.Items, func(ix, jx int) bool {
+			return events.Items[ix].LastTimestamp.Before(&events.Items[jx].LastTimestamp)
+		})
+		var lines []string
+		for _, event := range events.Items {
+			lines = append(lines, fmt.Sprintf("%s %s %s %s/%s: %s",
+				event.LastTimestamp.Format(time.RFC3339), event.Type, event.Reason,
+				event.InvolvedObject.Namespace, event.InvolvedObject.Name, event.Message))
+		}
+		writeFile("events.txt", strings.Join(lines, "\n"))
+	}
+
+	// The kernel log of every io-engine node, for nvme and oom traces.
+	if nodeNames, err := GetMayastorNodeNames(); err != nil {
+		note("failed to list mayastor nodes, error %v", err)
+	} else {
+		for _, nodeName := range nodeNames {
+			out, err := RunCommandOnNode(nodeName, fmt.Sprintf("dmesg | tail -n %d", dmesgLines))
+			if err != nil {
+				note("failed to collect dmesg from node %s, error %v", nodeName, err)
+				continue
+			}
+			writeFile(fmt.Sprintf("dmesg-%s.txt", nodeName), out)
+		}
+	}
+
+	if len(problems) != 0 {
+		writeFile("errors.txt", strings.Join(problems, "\n"))
+	}
+	return bundleDir, nil
+}
@@ -0,0 +1,155 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	discoveryFake "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// TestEnvironment holds the set of clients used by the test framework to
+// communicate with the cluster under test, and the per-suite namespace
+// test resources are created in by default.
+type TestEnvironment struct {
+	Cfg           *rest.Config
+	K8sClient     client.Client
+	KubeInt       kubernetes.Interface
+	DynamicClient dynamic.Interface
+	Namespace     string
+}
+
+var gTestEnv TestEnvironment
+
+// SetTestEnv installs the given environment as the one used by every
+// helper in this package. SetupTestEnv and SetupFakeTestEnv call this;
+// it is exported so specialised suites can inject their own clients.
+func SetTestEnv(env TestEnvironment) {
+	gTestEnv = env
+}
+
+// SetupTestEnv initialises the clients used by the test framework,
+// against the cluster selected by the configured kubeconfig and
+// context, or the standard kubeconfig lookup when none is configured.
+// It must be called once, before any helper in this package is used,
+// typically from the suite BeforeSuite function.
+func SetupTestEnv() error {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	leakBaseline = TakeLeakSnapshot()
+
+	cfg := e2e_config.GetConfig()
+	env, err := NewTestEnvironment(cfg.KubeConfig, cfg.KubeContext, SuiteNamespace(path.Base(os.Args[0])))
+	if err != nil {
+		return err
+	}
+	SetTestEnv(env)
+	if err := CreateNamespace(gTestEnv.Namespace); err != nil {
+		return fmt.Errorf("failed to create test namespace %s, error %v", gTestEnv.Namespace, err)
+	}
+	StartRebuildMonitor()
+	StartAvailabilityMonitor()
+	StartChurnMonitor()
+	return nil
+}
+
+// SetupFakeTestEnv initialises the test framework against fake in-memory
+// clients instead of a real cluster. This is used by the harness
+// validation suite to exercise the framework itself without a mayastor
+// installation; it must not be called from product test suites.
+func SetupFakeTestEnv() {
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	SetTestEnv(TestEnvironment{
+		KubeInt:       fake.NewSimpleClientset(),
+		DynamicClient: dynamicFake.NewSimpleDynamicClient(runtime.NewScheme()),
+		K8sClient:     crFake.NewClientBuilder().Build(),
+		Namespace:     "default",
+	})
+}
+
+// SetFakeServedResources installs the given API resource lists into the
+// fake discovery data, so the harness validation suite can exercise
+// version discovery. Only valid after SetupFakeTestEnv.
+func SetFakeServedResources(lists []*metaV1.APIResourceList) {
+	gTestEnv.KubeInt.(*fake.Clientset).Discovery().(*discoveryFake.FakeDiscovery).Resources = lists
+}
+
+// AddFakeNode creates a minimal node object in the fake clientset, so
+// the harness validation suite can exercise the node helpers. Only
+// valid after SetupFakeTestEnv.
+func AddFakeNode(nodeName string) error {
+	_, err := gTestEnv.KubeInt.CoreV1().Nodes().Create(context.TODO(),
+		&coreV1.Node{ObjectMeta: metaV1.ObjectMeta{Name: nodeName}}, metaV1.CreateOptions{})
+	return err
+}
+
+// AddFakeEvent creates a minimal event object in the fake clientset, so
+// the harness validation suite can exercise the event watcher. Only
+// valid after SetupFakeTestEnv.
+func AddFakeEvent(nameSpace string, objectName string, reason string, eventType string) error {
+	nameSpace = resolveNamespace(nameSpace)
+	_, err := gTestEnv.KubeInt.CoreV1().Events(nameSpace).Create(context.TODO(),
+		&coreV1.Event{
+			// The fake clientset does not expand GenerateName, so the
+			// object and reason have to make the name unique.
+			ObjectMeta:     metaV1.ObjectMeta{Name: objectName + "." + reason, Namespace: nameSpace},
+			InvolvedObject: coreV1.ObjectReference{Name: objectName, Namespace: nameSpace},
+			Reason:         reason,
+			Type:           eventType,
+		}, metaV1.CreateOptions{})
+	return err
+}
+
+// leakBaseline is the harness footprint at suite start, compared at
+// teardown by the leak self-check.
+var leakBaseline LeakSnapshot
+
+// TeardownTestEnv releases resources held by the test framework.
+func TeardownTestEnv() error {
+	if _, err := StopAvailabilityMonitor(); err != nil {
+		return err
+	}
+	if _, err := StopChurnMonitor(); err != nil {
+		return err
+	}
+	if err := StopRebuildMonitor(); err != nil {
+		return err
+	}
+	if gTestEnv.Namespace != "" && gTestEnv.Namespace != "default" {
+		if err := DeleteNamespace(gTestEnv.Namespace); err != nil {
+			return fmt.Errorf("failed to delete test namespace %s, error %v", gTestEnv.Namespace, err)
+		}
+	}
+	// With the monitors stopped the footprint must return to the suite
+	// start baseline; anything left over is a harness leak.
+	return CheckLeaks(leakBaseline, 10)
+}
+
+// GetServerMinorVersion returns the minor version of the kubernetes
+// cluster under test, e.g. 25 for a v1.25.x cluster.
+func GetServerMinorVersion() (uint, error) {
+	version, err := gTestEnv.KubeInt.Discovery().ServerVersion()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get server version, error %v", err)
+	}
+	var minor uint
+	// The minor version may have a suffix, e.g. "21+" on some distributions.
+	if _, err = fmt.Sscanf(version.Minor, "%d", &minor); err != nil {
+		return 0, fmt.Errorf("failed to parse server minor version %q, error %v", version.Minor, err)
+	}
+	return minor, nil
+}
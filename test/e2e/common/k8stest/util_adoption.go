@@ -0,0 +1,67 @@
+package k8stest
+
+import (
+	"fmt"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// This file encodes the documented disaster recovery procedure for
+// re-importing volumes after a control plane wipe: replica data remains
+// on the pools, the control plane volume records are recreated by
+// restarting the control plane (which re-imports pools and replicas),
+// and kubernetes access is re-established through static PVs pointing
+// at the original volume handles.
+
+// name of the control plane deployment
+const controlPlaneDeployment = "moac"
+
+// WipeControlPlaneVolumes deletes all control plane volume records,
+// leaving replica data on the pools in place. Destructive, only for use
+// by disaster recovery tests.
+func WipeControlPlaneVolumes() error {
+	msvs, err := ListMsvs()
+	if err != nil {
+		return err
+	}
+	for _, msv := range msvs {
+		if err := DeleteMsv(msv.GetName()); err != nil {
+			return fmt.Errorf("failed to delete MSV %s, error %v", msv.GetName(), err)
+		}
+	}
+	logf.Log.Info("wiped control plane volume records", "count", len(msvs))
+	return nil
+}
+
+// RestartControlPlane deletes the control plane pods and waits for the
+// deployment to roll out again, forcing a re-import of pools and
+// replicas.
+func RestartControlPlane(timeoutSecs int) error {
+	pods, err := ListPodsBySelector(MayastorNamespace, "app="+controlPlaneDeployment)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if err := DeletePod(pod.Name, MayastorNamespace); err != nil {
+			return fmt.Errorf("failed to delete control plane pod %s, error %v", pod.Name, err)
+		}
+	}
+	// allow the old pods to go away before waiting on readiness
+	time.Sleep(5 * time.Second)
+	return WaitDeploymentReady(controlPlaneDeployment, MayastorNamespace, timeoutSecs)
+}
+
+// AdoptVolume re-establishes kubernetes access to a surviving mayastor
+// volume after a control plane wipe, by creating a static PV for the
+// volume handle and a claim bound to it, then waiting for the bind.
+func AdoptVolume(volumeHandle string, volName string, volSizeMb int, scName string, protocol string, nameSpace string, timeoutSecs int) error {
+	pvName := "adopted-" + volumeHandle
+	if _, err := CreateStaticPv(pvName, volumeHandle, volSizeMb, scName, protocol); err != nil {
+		return fmt.Errorf("failed to create static PV for volume %s, error %v", volumeHandle, err)
+	}
+	if _, err := CreateStaticPvc(volName, pvName, volSizeMb, scName, nameSpace); err != nil {
+		return fmt.Errorf("failed to create claim for adopted volume %s, error %v", volumeHandle, err)
+	}
+	return WaitPvcBound(volName, nameSpace, timeoutSecs)
+}
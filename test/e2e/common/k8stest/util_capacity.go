@@ -0,0 +1,114 @@
+package k8stest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// GetPoolUsage returns the validated capacity accounting of a single
+// pool; available space is derived with PoolRecord.AvailableBytes.
+func GetPoolUsage(poolName string) (PoolRecord, error) {
+	return GetMsPoolUsage(poolName)
+}
+
+// TotalPoolUsage aggregates capacity and usage over all pools in the
+// cluster, so suites can reason about cluster-wide allocation without
+// caring how replicas were spread.
+func TotalPoolUsage() (PoolRecord, error) {
+	pools, err := ListMsPools()
+	if err != nil {
+		return PoolRecord{}, err
+	}
+	total := PoolRecord{Name: "total"}
+	for _, pool := range pools {
+		usage, err := GetMsPoolUsage(pool.GetName())
+		if err != nil {
+			return PoolRecord{}, err
+		}
+		total.CapacityBytes += usage.CapacityBytes
+		total.UsedBytes += usage.UsedBytes
+	}
+	return total, nil
+}
+
+// ReplicaBytesPerPool returns, for each pool, the sum of the sizes of
+// the replicas the control plane has placed on it.
+func ReplicaBytesPerPool() (map[string]int64, error) {
+	client, stop, err := ControlPlaneRestClient()
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	replicas, err := client.GetReplicas()
+	if err != nil {
+		return nil, err
+	}
+	perPool := map[string]int64{}
+	for _, replica := range replicas {
+		perPool[replica.Pool] += replica.Size
+	}
+	return perPool, nil
+}
+
+// CheckReplicaAccounting compares each pool's used bytes against the sum
+// of the sizes of the replicas placed on it, and returns a description of
+// every pool disagreeing. For thick provisioning the figures must agree
+// within the pool consistency tolerance; for thin provisioning replicas
+// are allocated lazily, so the pool usage must merely not exceed the
+// replica sum.
+func CheckReplicaAccounting(thin bool) ([]string, error) {
+	tolerance := e2e_config.GetConfig().PoolConsistency.TolerancePercent
+	perPool, err := ReplicaBytesPerPool()
+	if err != nil {
+		return nil, err
+	}
+	pools, err := ListMsPools()
+	if err != nil {
+		return nil, err
+	}
+	var mismatches []string
+	for _, pool := range pools {
+		usage, err := GetMsPoolUsage(pool.GetName())
+		if err != nil {
+			return nil, err
+		}
+		replicaBytes := perPool[pool.GetName()]
+		if thin {
+			if usage.UsedBytes > replicaBytes {
+				mismatches = append(mismatches, fmt.Sprintf("pool %s uses %d bytes for %d bytes of thin replicas",
+					pool.GetName(), usage.UsedBytes, replicaBytes))
+			}
+		} else if !withinTolerance(usage.UsedBytes, replicaBytes, tolerance) {
+			mismatches = append(mismatches, fmt.Sprintf("pool %s uses %d bytes for %d bytes of replicas",
+				pool.GetName(), usage.UsedBytes, replicaBytes))
+		}
+	}
+	return mismatches, nil
+}
+
+// CheckPoolsUnused returns an error naming every pool reporting non-zero
+// usage; used between tests to verify deleted volumes returned their
+// space to the pools.
+func CheckPoolsUnused() error {
+	pools, err := ListMsPools()
+	if err != nil {
+		return err
+	}
+	var used []string
+	for _, pool := range pools {
+		usage, err := GetMsPoolUsage(pool.GetName())
+		if err != nil {
+			return err
+		}
+		if usage.UsedBytes != 0 {
+			used = append(used, fmt.Sprintf("%s (%d bytes)", pool.GetName(), usage.UsedBytes))
+		}
+	}
+	if len(used) != 0 {
+		return fmt.Errorf("pools still in use: %s", strings.Join(used, ", "))
+	}
+	return nil
+}
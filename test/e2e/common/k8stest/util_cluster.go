@@ -0,0 +1,132 @@
+package k8stest
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// frameworkScheme is the single scheme shared by every typed client
+// the framework builds, holding the standard client-go types. The
+// mayastor custom resources are accessed through the dynamic client in
+// whichever API flavour the cluster serves, so they need no typed
+// registration here.
+var frameworkScheme = func() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	return scheme
+}()
+
+// Support for targeting clusters other than the default one: the rest
+// config can be built from a named kubeconfig and context, and more
+// than one TestEnvironment can be held at a time, so a suite can drive
+// several clusters from one test process.
+
+// buildRestConfig returns the rest config for the given kubeconfig
+// path and context; empty values fall back to the standard kubeconfig
+// lookup and its current context.
+func buildRestConfig(kubeConfig string, kubeContext string) (*rest.Config, error) {
+	if kubeConfig == "" && kubeContext == "" {
+		return config.GetConfig()
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeConfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	).ClientConfig()
+}
+
+// NewTestEnvironment builds the set of framework clients for the
+// cluster selected by the given kubeconfig and context, with resources
+// created in the given namespace by default. It does not install the
+// environment; use SetTestEnv or PushClusterScope for that.
+func NewTestEnvironment(kubeConfig string, kubeContext string, nameSpace string) (TestEnvironment, error) {
+	restConfig, err := buildRestConfig(kubeConfig, kubeContext)
+	if err != nil {
+		return TestEnvironment{}, fmt.Errorf("failed to get rest config, error %v", err)
+	}
+	k8sClient, err := client.New(restConfig, client.Options{Scheme: frameworkScheme})
+	if err != nil {
+		return TestEnvironment{}, fmt.Errorf("failed to create k8s client, error %v", err)
+	}
+	kubeInt, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return TestEnvironment{}, fmt.Errorf("failed to create clientset, error %v", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return TestEnvironment{}, fmt.Errorf("failed to create dynamic client, error %v", err)
+	}
+	return TestEnvironment{
+		Cfg:           restConfig,
+		K8sClient:     k8sClient,
+		KubeInt:       kubeInt,
+		DynamicClient: dynamicClient,
+		Namespace:     nameSpace,
+	}, nil
+}
+
+// clusterEnvs holds the environments of the additional clusters
+// connected so far, keyed by their configured name.
+var clusterEnvs = map[string]TestEnvironment{}
+
+// ConnectCluster builds and registers the environment of the named
+// additional cluster from the e2e configuration. Resources on that
+// cluster are created in the default namespace unless a suite sets up
+// its own. Connecting an already connected cluster is a no-op.
+func ConnectCluster(name string) error {
+	if _, connected := clusterEnvs[name]; connected {
+		return nil
+	}
+	for _, cluster := range e2e_config.GetConfig().Clusters {
+		if cluster.Name != name {
+			continue
+		}
+		env, err := NewTestEnvironment(cluster.KubeConfig, cluster.Context, "default")
+		if err != nil {
+			return fmt.Errorf("failed to connect cluster %s, error %v", name, err)
+		}
+		clusterEnvs[name] = env
+		return nil
+	}
+	return fmt.Errorf("cluster %s is not configured", name)
+}
+
+// ClusterEnv returns the environment of a previously connected cluster.
+func ClusterEnv(name string) (TestEnvironment, error) {
+	env, connected := clusterEnvs[name]
+	if !connected {
+		return TestEnvironment{}, fmt.Errorf("cluster %s is not connected", name)
+	}
+	return env, nil
+}
+
+// PushClusterScope switches the helpers in this package to the named
+// cluster and returns a function restoring the previous environment.
+// The switch is process wide and not safe for concurrent use; callers
+// scope it tightly:
+//
+//	restore, err := k8stest.PushClusterScope("dr")
+//	defer restore()
+func PushClusterScope(name string) (func(), error) {
+	env, err := ClusterEnv(name)
+	if err != nil {
+		return nil, err
+	}
+	saved := gTestEnv
+	SetTestEnv(env)
+	return func() {
+		SetTestEnv(saved)
+	}, nil
+}
@@ -0,0 +1,114 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	coreV1 "k8s.io/api/core/v1"
+	storageV1 "k8s.io/api/storage/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// csiSocketDir is the kubelet plugin directory holding the node plugin
+// socket on every mayastor node.
+const csiSocketDir = "/var/lib/kubelet/plugins/" + CSIProvisioner
+
+// GetCsiDriver retrieves the CSIDriver object registered by the
+// deployment.
+func GetCsiDriver(name string) (*storageV1.CSIDriver, error) {
+	driver, err := gTestEnv.KubeInt.StorageV1().CSIDrivers().Get(context.TODO(), name, metaV1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CSIDriver %s, error %v", name, err)
+	}
+	return driver, nil
+}
+
+// capabilityRegexp matches capability names in csc output, e.g.
+// "STAGE_UNSTAGE_VOLUME" or "&csi.NodeServiceCapability_Rpc{Type:GET_VOLUME_STATS}".
+var capabilityRegexp = regexp.MustCompile(`[A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+`)
+
+// ParseCscCapabilities extracts the capability names from the output of
+// a csc get-capabilities invocation, in output order.
+func ParseCscCapabilities(out string) []string {
+	var capabilities []string
+	seen := map[string]bool{}
+	for _, name := range capabilityRegexp.FindAllString(out, -1) {
+		if !seen[name] {
+			seen[name] = true
+			capabilities = append(capabilities, name)
+		}
+	}
+	return capabilities
+}
+
+// NodePluginCapabilities queries the capabilities advertised by the CSI
+// node plugin on the given node, csi-sanity style: a transient
+// privileged pod mounts the kubelet plugin directory and issues the
+// GetCapabilities gRPC over the node socket using the csc client.
+func NodePluginCapabilities(nodeName string) ([]string, error) {
+	podName := fmt.Sprintf("e2e-csc-%d", time.Now().UnixNano())
+	isPrivileged := true
+	hostPathType := coreV1.HostPathDirectory
+	podDef := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: podName,
+		},
+		Spec: coreV1.PodSpec{
+			NodeName:      nodeName,
+			RestartPolicy: coreV1.RestartPolicyNever,
+			Containers: []coreV1.Container{
+				{
+					Name:  "csc",
+					Image: e2e_config.GetConfig().E2eCscImage,
+					Args:  []string{"node", "get-capabilities", "--endpoint", "unix:///csi/csi.sock"},
+					SecurityContext: &coreV1.SecurityContext{
+						Privileged: &isPrivileged,
+					},
+					VolumeMounts: []coreV1.VolumeMount{
+						{
+							Name:      "csi-socket-dir",
+							MountPath: "/csi",
+						},
+					},
+				},
+			},
+			Volumes: []coreV1.Volume{
+				{
+					Name: "csi-socket-dir",
+					VolumeSource: coreV1.VolumeSource{
+						HostPath: &coreV1.HostPathVolumeSource{
+							Path: csiSocketDir,
+							Type: &hostPathType,
+						},
+					},
+				},
+			},
+		},
+	}
+	const nameSpace = "default"
+	if _, err := CreatePod(podDef, nameSpace); err != nil {
+		return nil, fmt.Errorf("failed to create csc pod on %s, error %v", nodeName, err)
+	}
+	defer func() {
+		_ = DeletePod(podName, nameSpace)
+	}()
+	if err := WaitPodCompleted(podName, nameSpace, defTimeoutSecs); err != nil {
+		return nil, err
+	}
+	out, err := GetPodLog(podName, nameSpace)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := GetPod(podName, nameSpace)
+	if err != nil {
+		return nil, err
+	}
+	if pod.Status.Phase != coreV1.PodSucceeded {
+		return nil, fmt.Errorf("csc get-capabilities failed on node %s, output %s", nodeName, out)
+	}
+	return ParseCscCapabilities(out), nil
+}
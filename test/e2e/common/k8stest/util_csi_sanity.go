@@ -0,0 +1,154 @@
+package k8stest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CsiSanityResult is the outcome of a csi-sanity run.
+type CsiSanityResult struct {
+	Passed  int
+	Failed  int
+	Skipped int
+	// FailedSpecs names the csi-sanity specs which failed.
+	FailedSpecs []string
+	// Output is the full csi-sanity output, for the support bundle.
+	Output string
+}
+
+// csiSanitySummaryRegexp matches the csi-sanity run summary, e.g.
+// "FAIL! -- 60 Passed | 5 Failed | 0 Pending | 8 Skipped".
+var csiSanitySummaryRegexp = regexp.MustCompile(`(\d+) Passed \| (\d+) Failed \| \d+ Pending \| (\d+) Skipped`)
+
+// ParseCsiSanityOutput extracts the result from csi-sanity output:
+// the pass/fail/skip counts from the summary line and the names of the
+// failed specs from the failure summary.
+func ParseCsiSanityOutput(out string) (CsiSanityResult, error) {
+	result := CsiSanityResult{Output: out}
+	match := csiSanitySummaryRegexp.FindStringSubmatch(out)
+	if match == nil {
+		return result, fmt.Errorf("no csi-sanity summary found in output")
+	}
+	result.Passed, _ = strconv.Atoi(match[1])
+	result.Failed, _ = strconv.Atoi(match[2])
+	result.Skipped, _ = strconv.Atoi(match[3])
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[Fail]") {
+			result.FailedSpecs = append(result.FailedSpecs, strings.TrimSpace(strings.TrimPrefix(line, "[Fail]")))
+		}
+	}
+	return result, nil
+}
+
+// RunCsiSanity runs csi-sanity against the mayastor CSI controller and
+// node sockets, from a pod on the node hosting moac. The controller
+// socket must be reachable on the host at the configured
+// ControllerSocketDir; the node plugin socket comes from the kubelet
+// plugin directory, so the moac node must also run the node plugin.
+func RunCsiSanity() (CsiSanityResult, error) {
+	cfg := e2e_config.GetConfig().CsiSanity
+
+	pods, err := ListPodsBySelector(MayastorNamespace, "app=moac")
+	if err != nil || len(pods.Items) == 0 {
+		return CsiSanityResult{}, fmt.Errorf("failed to find the moac pod, error %v", err)
+	}
+	moacNode := pods.Items[0].Spec.NodeName
+
+	nodePluginFound := false
+	nodeNames, err := GetMayastorNodeNames()
+	if err != nil {
+		return CsiSanityResult{}, err
+	}
+	for _, nodeName := range nodeNames {
+		if nodeName == moacNode {
+			nodePluginFound = true
+			break
+		}
+	}
+	if !nodePluginFound {
+		return CsiSanityResult{}, fmt.Errorf("moac node %s does not run the CSI node plugin", moacNode)
+	}
+
+	podName := fmt.Sprintf("e2e-csi-sanity-%d", time.Now().UnixNano())
+	isPrivileged := true
+	hostPathType := coreV1.HostPathDirectory
+	podDef := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: podName,
+		},
+		Spec: coreV1.PodSpec{
+			NodeName:      moacNode,
+			RestartPolicy: coreV1.RestartPolicyNever,
+			Containers: []coreV1.Container{
+				{
+					Name:  "csi-sanity",
+					Image: cfg.Image,
+					Args: []string{
+						"--csi.endpoint", "unix:///node/csi.sock",
+						"--csi.controllerendpoint", "unix:///controller/csi.sock",
+						"--ginkgo.noColor",
+					},
+					SecurityContext: &coreV1.SecurityContext{
+						Privileged: &isPrivileged,
+					},
+					VolumeMounts: []coreV1.VolumeMount{
+						{
+							Name:      "node-socket-dir",
+							MountPath: "/node",
+						},
+						{
+							Name:      "controller-socket-dir",
+							MountPath: "/controller",
+						},
+					},
+				},
+			},
+			Volumes: []coreV1.Volume{
+				{
+					Name: "node-socket-dir",
+					VolumeSource: coreV1.VolumeSource{
+						HostPath: &coreV1.HostPathVolumeSource{
+							Path: csiSocketDir,
+							Type: &hostPathType,
+						},
+					},
+				},
+				{
+					Name: "controller-socket-dir",
+					VolumeSource: coreV1.VolumeSource{
+						HostPath: &coreV1.HostPathVolumeSource{
+							Path: cfg.ControllerSocketDir,
+							Type: &hostPathType,
+						},
+					},
+				},
+			},
+		},
+	}
+	const nameSpace = "default"
+	if _, err := CreatePod(podDef, nameSpace); err != nil {
+		return CsiSanityResult{}, fmt.Errorf("failed to create csi-sanity pod on %s, error %v", moacNode, err)
+	}
+	defer func() {
+		_ = DeletePod(podName, nameSpace)
+	}()
+	if err := WaitPodCompleted(podName, nameSpace, cfg.TimeoutSecs); err != nil {
+		return CsiSanityResult{}, err
+	}
+	out, err := GetPodLog(podName, nameSpace)
+	if err != nil {
+		return CsiSanityResult{}, err
+	}
+	// csi-sanity exits non zero when specs fail; the parsed result
+	// carries the failures, so the pod phase is not checked here.
+	return ParseCsiSanityOutput(out)
+}
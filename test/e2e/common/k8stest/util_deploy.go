@@ -0,0 +1,203 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetDeployment retrieves a deployment by name.
+func GetDeployment(deployName string, nameSpace string) (*appsV1.Deployment, error) {
+	return gTestEnv.KubeInt.AppsV1().Deployments(nameSpace).Get(context.TODO(), deployName, metaV1.GetOptions{})
+}
+
+// SetDeploymentEnv sets an environment variable on every container of
+// the given deployment, triggering a rollout.
+func SetDeploymentEnv(deployName string, nameSpace string, key string, value string) error {
+	deployment, err := GetDeployment(deployName, nameSpace)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s, error %v", deployName, err)
+	}
+	for ix := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[ix]
+		replaced := false
+		for jx := range container.Env {
+			if container.Env[jx].Name == key {
+				container.Env[jx].Value = value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			container.Env = append(container.Env, coreV1.EnvVar{Name: key, Value: value})
+		}
+	}
+	_, err = gTestEnv.KubeInt.AppsV1().Deployments(nameSpace).Update(context.TODO(), deployment, metaV1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update deployment %s, error %v", deployName, err)
+	}
+	return nil
+}
+
+// retagImage replaces the tag of an image reference, leaving the
+// registry and repository untouched.
+func retagImage(image string, tag string) string {
+	slash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > slash {
+		image = image[:colon]
+	}
+	return image + ":" + tag
+}
+
+// SetDeploymentImageTag retags every container image of the deployment,
+// triggering a rollout; used by the upgrade framework.
+func SetDeploymentImageTag(deployName string, nameSpace string, tag string) error {
+	deployment, err := GetDeployment(deployName, nameSpace)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s, error %v", deployName, err)
+	}
+	for ix := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[ix]
+		container.Image = retagImage(container.Image, tag)
+	}
+	_, err = gTestEnv.KubeInt.AppsV1().Deployments(nameSpace).Update(context.TODO(), deployment, metaV1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update deployment %s, error %v", deployName, err)
+	}
+	return nil
+}
+
+// GetDaemonSet retrieves a daemonset by name.
+func GetDaemonSet(dsName string, nameSpace string) (*appsV1.DaemonSet, error) {
+	return gTestEnv.KubeInt.AppsV1().DaemonSets(nameSpace).Get(context.TODO(), dsName, metaV1.GetOptions{})
+}
+
+// SetDaemonSetImageTag retags every container image of the daemonset,
+// triggering a rollout; used by the upgrade framework.
+func SetDaemonSetImageTag(dsName string, nameSpace string, tag string) error {
+	ds, err := GetDaemonSet(dsName, nameSpace)
+	if err != nil {
+		return fmt.Errorf("failed to get daemonset %s, error %v", dsName, err)
+	}
+	for ix := range ds.Spec.Template.Spec.Containers {
+		container := &ds.Spec.Template.Spec.Containers[ix]
+		container.Image = retagImage(container.Image, tag)
+	}
+	_, err = gTestEnv.KubeInt.AppsV1().DaemonSets(nameSpace).Update(context.TODO(), ds, metaV1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update daemonset %s, error %v", dsName, err)
+	}
+	return nil
+}
+
+// ScaleDeployment sets the replica count of the given deployment.
+func ScaleDeployment(deployName string, nameSpace string, replicas int32) error {
+	deployment, err := GetDeployment(deployName, nameSpace)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s, error %v", deployName, err)
+	}
+	deployment.Spec.Replicas = &replicas
+	_, err = gTestEnv.KubeInt.AppsV1().Deployments(nameSpace).Update(context.TODO(), deployment, metaV1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to scale deployment %s to %d, error %v", deployName, replicas, err)
+	}
+	return nil
+}
+
+// DeploymentReady returns true if all replicas of the deployment are
+// updated and available.
+func DeploymentReady(deployment *appsV1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas &&
+		deployment.Status.ObservedGeneration >= deployment.Generation
+}
+
+// WaitDeploymentReady waits for up to timeoutSecs for the deployment
+// rollout to complete.
+func WaitDeploymentReady(deployName string, nameSpace string, timeoutSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		deployment, err := GetDeployment(deployName, nameSpace)
+		if err == nil && DeploymentReady(deployment) {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("deployment %s not ready after %d seconds", deployName, timeoutSecs)
+}
+
+// CreateVolumeDeployment creates a single replica deployment running
+// the fio image with the given volume mounted at /volume, labelled
+// app=<deployName>. Tests which need the workload rescheduled after
+// node disruption use this instead of a bare pod, which kubernetes
+// would not recreate.
+func CreateVolumeDeployment(deployName string, volName string, nameSpace string, image string) error {
+	replicas := int32(1)
+	labels := map[string]string{"app": deployName}
+	podSpec := NewVolumePodSpec(deployName, volName, image, []string{"sleep", "1000000"})
+	deployment := &appsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: deployName,
+		},
+		Spec: appsV1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metaV1.LabelSelector{MatchLabels: labels},
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: labels},
+				Spec:       podSpec.Spec,
+			},
+		},
+	}
+	_, err := gTestEnv.KubeInt.AppsV1().Deployments(nameSpace).Create(context.TODO(), deployment, metaV1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create deployment %s, error %v", deployName, err)
+	}
+	return nil
+}
+
+// DeleteDeployment deletes a deployment by name.
+func DeleteDeployment(deployName string, nameSpace string) error {
+	return gTestEnv.KubeInt.AppsV1().Deployments(nameSpace).Delete(context.TODO(), deployName, metaV1.DeleteOptions{})
+}
+
+// CreateDaemonSet creates a daemonset from the given definition.
+func CreateDaemonSet(dsDef *appsV1.DaemonSet, nameSpace string) (*appsV1.DaemonSet, error) {
+	return gTestEnv.KubeInt.AppsV1().DaemonSets(nameSpace).Create(context.TODO(), dsDef, metaV1.CreateOptions{})
+}
+
+// DeleteDaemonSet deletes a daemonset by name.
+func DeleteDaemonSet(dsName string, nameSpace string) error {
+	return gTestEnv.KubeInt.AppsV1().DaemonSets(nameSpace).Delete(context.TODO(), dsName, metaV1.DeleteOptions{})
+}
+
+// WaitDaemonSetReady waits for up to timeoutSecs for the daemonset to
+// have a ready pod on every node it is scheduled to.
+func WaitDaemonSetReady(dsName string, nameSpace string, timeoutSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		ds, err := gTestEnv.KubeInt.AppsV1().DaemonSets(nameSpace).Get(context.TODO(), dsName, metaV1.GetOptions{})
+		if err == nil && ds.Status.DesiredNumberScheduled > 0 &&
+			ds.Status.NumberReady == ds.Status.DesiredNumberScheduled {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("daemonset %s not ready after %d seconds", dsName, timeoutSecs)
+}
+
+// ListPodsBySelector returns the pods matching the given label selector
+// in the given namespace.
+func ListPodsBySelector(nameSpace string, labelSelector string) (*coreV1.PodList, error) {
+	return gTestEnv.KubeInt.CoreV1().Pods(nameSpace).List(context.TODO(), metaV1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+}
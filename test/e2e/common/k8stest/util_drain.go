@@ -0,0 +1,75 @@
+package k8stest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/kubectl_mayastor"
+)
+
+// drainLabel names the drains issued by the harness, so uncordoning
+// removes only our drain and not one applied by an operator.
+const drainLabel = "e2e-drain"
+
+// DrainMayastorNode starts a control plane drain of the node - volume
+// targets on it are republished elsewhere, unlike a plain kubernetes
+// cordon which only affects pod scheduling - and waits for up to
+// timeoutSecs for the node to hold no volume targets.
+func DrainMayastorNode(nodeName string, timeoutSecs int) error {
+	if err := kubectl_mayastor.DrainNode(nodeName, drainLabel); err != nil {
+		return fmt.Errorf("failed to drain node %s, error %v", nodeName, err)
+	}
+	return WaitNoVolumeTargetsOnNode(nodeName, timeoutSecs)
+}
+
+// UncordonMayastorNode removes the harness drain from the node, making
+// it eligible for volume targets again.
+func UncordonMayastorNode(nodeName string) error {
+	if err := kubectl_mayastor.UncordonNode(nodeName, drainLabel); err != nil {
+		return fmt.Errorf("failed to uncordon node %s, error %v", nodeName, err)
+	}
+	return nil
+}
+
+// WaitNoVolumeTargetsOnNode waits for up to timeoutSecs for no volume to
+// have its nexus on the given node.
+func WaitNoVolumeTargetsOnNode(nodeName string, timeoutSecs int) error {
+	const sleepTime = 2
+	var remaining []string
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		msvs, err := ListMsv()
+		if err != nil {
+			return err
+		}
+		remaining = remaining[:0]
+		for _, msv := range msvs {
+			if msv.Status.Nexus.Node == nodeName {
+				remaining = append(remaining, msv.Name)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("node %s still hosts the targets of volumes %s after %d seconds",
+		nodeName, strings.Join(remaining, ", "), timeoutSecs)
+}
+
+// WaitMsvHealthy waits for up to timeoutSecs for the volume to report
+// the healthy state, e.g. for a rebuild started by a drain or replica
+// fault to complete.
+func WaitMsvHealthy(uuid string, timeoutSecs int) error {
+	const sleepTime = 2
+	var state string
+	var err error
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		state, err = GetMsvState(uuid)
+		if err == nil && state == "healthy" {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("MSV %s not healthy after %d seconds, state %q, error %v", uuid, timeoutSecs, state, err)
+}
@@ -0,0 +1,98 @@
+package k8stest
+
+import (
+	"fmt"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// Helpers for disrupting the persistent store of the control plane: the
+// etcd statefulset and the NATS message bus deployment.
+
+// EtcdStatefulSetName is the name of the etcd statefulset in the
+// mayastor namespace.
+const EtcdStatefulSetName = "etcd"
+
+// NatsDeploymentName is the name of the NATS deployment in the mayastor
+// namespace.
+const NatsDeploymentName = "nats"
+
+// etcdSelector matches the pods of the etcd statefulset.
+const etcdSelector = "app=etcd"
+
+// DeleteAllEtcdPods deletes every etcd pod at once, forcing a full
+// quorum loss and re-election when the statefulset recreates them.
+func DeleteAllEtcdPods() error {
+	pods, err := ListPodsBySelector(MayastorNamespace, etcdSelector)
+	if err != nil {
+		return fmt.Errorf("failed to list etcd pods, error %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no etcd pods found in namespace %s", MayastorNamespace)
+	}
+	for _, pod := range pods.Items {
+		if err := DeletePod(pod.Name, MayastorNamespace); err != nil {
+			return fmt.Errorf("failed to delete etcd pod %s, error %v", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// WaitEtcdHealthy waits for up to timeoutSecs for the etcd statefulset
+// to be fully ready and for every member to report a healthy endpoint.
+func WaitEtcdHealthy(timeoutSecs int) error {
+	if err := WaitStatefulSetReady(EtcdStatefulSetName, MayastorNamespace, timeoutSecs); err != nil {
+		return err
+	}
+	pods, err := ListPodsBySelector(MayastorNamespace, etcdSelector)
+	if err != nil {
+		return fmt.Errorf("failed to list etcd pods, error %v", err)
+	}
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		healthy := 0
+		for _, pod := range pods.Items {
+			if _, err := ExecPod(pod.Name, MayastorNamespace, "sh", "-c", "etcdctl endpoint health"); err == nil {
+				healthy++
+			}
+		}
+		if healthy == len(pods.Items) {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("etcd not healthy after %d seconds", timeoutSecs)
+}
+
+// EtcdReplicaCount returns the configured replica count of the etcd
+// statefulset, for restoring after a scale down.
+func EtcdReplicaCount() (int32, error) {
+	sts, err := GetStatefulSet(EtcdStatefulSetName, MayastorNamespace)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get etcd statefulset, error %v", err)
+	}
+	if sts.Spec.Replicas == nil {
+		return 1, nil
+	}
+	return *sts.Spec.Replicas, nil
+}
+
+// WaitPodsGoneBySelector waits for up to timeoutSecs for no pods
+// matching the selector to remain.
+func WaitPodsGoneBySelector(nameSpace string, labelSelector string, timeoutSecs int) error {
+	const sleepTime = 2
+	var remaining []coreV1.Pod
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		pods, err := ListPodsBySelector(nameSpace, labelSelector)
+		if err != nil {
+			return fmt.Errorf("failed to list pods matching %q, error %v", labelSelector, err)
+		}
+		remaining = pods.Items
+		if len(remaining) == 0 {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("%d pods matching %q still present after %d seconds", len(remaining), labelSelector, timeoutSecs)
+}
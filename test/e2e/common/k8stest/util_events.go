@@ -0,0 +1,142 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ListEvents returns all events in the given namespace; an empty
+// namespace lists events across all namespaces.
+func ListEvents(nameSpace string) (*coreV1.EventList, error) {
+	events, err := gTestEnv.KubeInt.CoreV1().Events(nameSpace).List(context.TODO(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events in namespace %q, error %v", nameSpace, err)
+	}
+	return events, nil
+}
+
+// EventsForObject returns the events in the given namespace involving
+// the named object.
+func EventsForObject(nameSpace string, objectName string) ([]coreV1.Event, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	events, err := ListEvents(nameSpace)
+	if err != nil {
+		return nil, err
+	}
+	var matched []coreV1.Event
+	for _, event := range events.Items {
+		if event.InvolvedObject.Name == objectName {
+			matched = append(matched, event)
+		}
+	}
+	return matched, nil
+}
+
+// EventWatcher records the kubernetes events of a namespace from the
+// moment it is started, so suites can assert the events a scenario must
+// emit - and that no error events leaked - without races against event
+// garbage collection.
+type EventWatcher struct {
+	watcher watch.Interface
+	done    chan struct{}
+
+	mu     sync.Mutex
+	events []coreV1.Event
+}
+
+// StartEventWatcher watches events in the given namespace, the mayastor
+// namespace being the usual choice; the caller must call Stop.
+func StartEventWatcher(nameSpace string) (*EventWatcher, error) {
+	watcher, err := gTestEnv.KubeInt.CoreV1().Events(nameSpace).Watch(context.TODO(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch events in namespace %q, error %v", nameSpace, err)
+	}
+	eventWatcher := &EventWatcher{watcher: watcher, done: make(chan struct{})}
+	go func() {
+		defer close(eventWatcher.done)
+		for watchEvent := range watcher.ResultChan() {
+			event, ok := watchEvent.Object.(*coreV1.Event)
+			if !ok {
+				continue
+			}
+			eventWatcher.mu.Lock()
+			eventWatcher.events = append(eventWatcher.events, *event)
+			eventWatcher.mu.Unlock()
+		}
+	}()
+	return eventWatcher, nil
+}
+
+// Stop ends the watch.
+func (w *EventWatcher) Stop() {
+	w.watcher.Stop()
+	<-w.done
+}
+
+// Events returns the events recorded so far.
+func (w *EventWatcher) Events() []coreV1.Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]coreV1.Event(nil), w.events...)
+}
+
+// ExpectEvent waits for up to withinSecs for an event with the given
+// reason involving the named object to be recorded; an empty
+// involvedObject matches any object.
+func (w *EventWatcher) ExpectEvent(reason string, involvedObject string, withinSecs int) error {
+	const sleepTime = 1
+	for ix := 0; ix < withinSecs/sleepTime+1; ix++ {
+		for _, event := range w.Events() {
+			if event.Reason == reason &&
+				(involvedObject == "" || event.InvolvedObject.Name == involvedObject) {
+				return nil
+			}
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("no %q event for object %q within %d seconds", reason, involvedObject, withinSecs)
+}
+
+// ErrorEvents returns the warning events recorded so far; happy path
+// scenarios assert this is empty.
+func (w *EventWatcher) ErrorEvents() []coreV1.Event {
+	var errors []coreV1.Event
+	for _, event := range w.Events() {
+		if event.Type == coreV1.EventTypeWarning {
+			errors = append(errors, event)
+		}
+	}
+	return errors
+}
+
+// EventsMatching returns the events since the given time whose reason
+// or message contains the given substring, case insensitively.
+func EventsMatching(nameSpace string, since time.Time, substring string) ([]coreV1.Event, error) {
+	events, err := ListEvents(nameSpace)
+	if err != nil {
+		return nil, err
+	}
+	needle := strings.ToLower(substring)
+	var matched []coreV1.Event
+	for _, event := range events.Items {
+		when := event.LastTimestamp.Time
+		if when.IsZero() {
+			when = event.CreationTimestamp.Time
+		}
+		if when.Before(since) {
+			continue
+		}
+		if strings.Contains(strings.ToLower(event.Reason), needle) ||
+			strings.Contains(strings.ToLower(event.Message), needle) {
+			matched = append(matched, event)
+		}
+	}
+	return matched, nil
+}
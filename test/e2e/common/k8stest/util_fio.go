@@ -0,0 +1,192 @@
+package k8stest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// Arguments sufficient for fio to run against a file on the mounted
+// volume, suites append workload specific arguments.
+var fioBaseArgs = []string{
+	"--name=benchtest",
+	"--direct=1",
+	"--rw=randrw",
+	"--ioengine=libaio",
+	"--bs=4k",
+	"--iodepth=16",
+	"--numjobs=1",
+	"--time_based",
+}
+
+// CreateFioPod creates a pod running the configured fio image with the
+// given volume mounted at /volume. The pod sleeps until fio is invoked
+// with ExecPod, so one pod can run multiple workloads.
+func CreateFioPod(podName string, volName string, nameSpace string) (*coreV1.Pod, error) {
+	podDef := NewVolumePodSpec(podName, volName, e2e_config.GetConfig().E2eFioImage,
+		[]string{"sleep", "1000000"})
+	return CreatePod(podDef, nameSpace)
+}
+
+// CreateFioPodOnNode creates a fio pod like CreateFioPod, pinned to the
+// given node; used by placement sensitive tests, e.g. remote volume
+// access from a compute-only node.
+func CreateFioPodOnNode(podName string, volName string, nameSpace string, nodeName string) (*coreV1.Pod, error) {
+	podDef := NewVolumePodSpec(podName, volName, e2e_config.GetConfig().E2eFioImage,
+		[]string{"sleep", "1000000"})
+	podDef.Spec.NodeSelector = map[string]string{"kubernetes.io/hostname": nodeName}
+	return CreatePod(podDef, nameSpace)
+}
+
+// msvNexusNodeOfPvc resolves the node hosting the nexus of the volume
+// bound to the given PVC.
+func msvNexusNodeOfPvc(volName string, nameSpace string) (string, error) {
+	uuid, err := GetVolumeUuid(volName, nameSpace)
+	if err != nil {
+		return "", err
+	}
+	return GetMsvNexusNode(uuid)
+}
+
+// CreateFioPodOnMsvNexusNode creates a fio pod like CreateFioPod,
+// pinned to the node hosting the nexus of the volume, resolved when the
+// pod is created, so the application reaches its data locally. The
+// volume must be provisioned already.
+func CreateFioPodOnMsvNexusNode(podName string, volName string, nameSpace string) (*coreV1.Pod, error) {
+	nexusNode, err := msvNexusNodeOfPvc(volName, nameSpace)
+	if err != nil {
+		return nil, err
+	}
+	return CreateFioPodOnNode(podName, volName, nameSpace, nexusNode)
+}
+
+// CreateFioPodAwayFromMsvNexusNode creates a fio pod like CreateFioPod,
+// schedulable on any node except the one hosting the nexus of the
+// volume, resolved when the pod is created, so the application reaches
+// the volume over remote NVMe-oF.
+func CreateFioPodAwayFromMsvNexusNode(podName string, volName string, nameSpace string) (*coreV1.Pod, error) {
+	nexusNode, err := msvNexusNodeOfPvc(volName, nameSpace)
+	if err != nil {
+		return nil, err
+	}
+	podDef := NewVolumePodSpec(podName, volName, e2e_config.GetConfig().E2eFioImage,
+		[]string{"sleep", "1000000"})
+	podDef.Spec.Affinity = &coreV1.Affinity{
+		NodeAffinity: &coreV1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &coreV1.NodeSelector{
+				NodeSelectorTerms: []coreV1.NodeSelectorTerm{{
+					MatchExpressions: []coreV1.NodeSelectorRequirement{{
+						Key:      "kubernetes.io/hostname",
+						Operator: coreV1.NodeSelectorOpNotIn,
+						Values:   []string{nexusNode},
+					}},
+				}},
+			},
+		},
+	}
+	return CreatePod(podDef, nameSpace)
+}
+
+// CreateRawBlockFioPod creates a fio pod like CreateFioPod, with the
+// volume mapped as a raw block device instead of a mounted filesystem.
+func CreateRawBlockFioPod(podName string, volName string, nameSpace string) (*coreV1.Pod, error) {
+	podDef := NewRawBlockPodSpec(podName, volName, e2e_config.GetConfig().E2eFioImage,
+		[]string{"sleep", "1000000"})
+	return CreatePod(podDef, nameSpace)
+}
+
+// RunFio runs fio in the given pod against a file on the mounted volume
+// for the given duration, and returns the fio output.
+func RunFio(podName string, nameSpace string, runTimeSecs int, additionalArgs ...string) (string, error) {
+	args := []string{"fio"}
+	args = append(args, fioBaseArgs...)
+	args = append(args,
+		"--filename=/volume/fio-test-file",
+		"--size=64m",
+		fmt.Sprintf("--runtime=%d", runTimeSecs),
+	)
+	args = append(args, additionalArgs...)
+	out, err := ExecPod(podName, nameSpace, args...)
+	recordFioRun(podName, out, err)
+	return out, err
+}
+
+// RunFioRawBlock runs fio in the given pod against the raw block volume
+// device for the given duration, and returns the fio output.
+func RunFioRawBlock(podName string, nameSpace string, runTimeSecs int, additionalArgs ...string) (string, error) {
+	args := []string{"fio"}
+	args = append(args, fioBaseArgs...)
+	args = append(args,
+		"--filename="+RawBlockDevicePath,
+		"--size=64m",
+		fmt.Sprintf("--runtime=%d", runTimeSecs),
+	)
+	args = append(args, additionalArgs...)
+	out, err := ExecPod(podName, nameSpace, args...)
+	recordFioRun(podName, out, err)
+	return out, err
+}
+
+// recordFioRun notes the achieved bandwidth of a successful fio run in
+// the run report; output without bandwidth figures is not recorded.
+func recordFioRun(podName string, out string, err error) {
+	if err != nil {
+		return
+	}
+	if bandwidth, bwErr := FioBandwidthBytes(out); bwErr == nil {
+		runreport.RecordFioResult(podName, bandwidth)
+	}
+}
+
+var fioBwRegexp = regexp.MustCompile(`(?:READ|WRITE): bw=[0-9.]+[A-Za-z/]+ \(([0-9.]+)[kMG]B/s\)`)
+
+// FioBandwidthBytes extracts the aggregate read+write bandwidth, in
+// decimal bytes per second order of magnitude as reported by fio, from
+// fio output.
+func FioBandwidthBytes(fioOutput string) (float64, error) {
+	matches := fioBwRegexp.FindAllStringSubmatch(fioOutput, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("no bandwidth figures found in fio output")
+	}
+	var total float64
+	for _, match := range matches {
+		bw, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse fio bandwidth %q, error %v", match[1], err)
+		}
+		total += bw
+	}
+	return total, nil
+}
+
+var fioClatRegexp = regexp.MustCompile(`clat \((usec|msec|nsec)\):[^\n]*avg=\s*([0-9.]+)`)
+
+// FioMeanLatencyUsecs extracts the mean completion latency in
+// microseconds from fio output, averaged over the directions reported;
+// data locality tests use it to compare local against remote access.
+func FioMeanLatencyUsecs(fioOutput string) (float64, error) {
+	matches := fioClatRegexp.FindAllStringSubmatch(fioOutput, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("no completion latency figures found in fio output")
+	}
+	var total float64
+	for _, match := range matches {
+		avg, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse fio latency %q, error %v", match[2], err)
+		}
+		switch match[1] {
+		case "nsec":
+			avg /= 1000
+		case "msec":
+			avg *= 1000
+		}
+		total += avg
+	}
+	return total / float64(len(matches)), nil
+}
@@ -0,0 +1,109 @@
+package k8stest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// fioLatLogPrefix is where fio writes its latency logs on the mounted
+// volume; fio appends _lat.<jobindex>.log to the prefix.
+const fioLatLogPrefix = "/volume/fio-e2e"
+
+// RunFioWithLatencyLog runs fio like RunFio, additionally recording a
+// per-second averaged completion latency log on the volume, for later
+// collection with CollectFioLatencyCsv. Soak suites use the log to
+// correlate latency spikes with disruption events recorded in the run
+// manifest.
+func RunFioWithLatencyLog(podName string, nameSpace string, runTimeSecs int, additionalArgs ...string) (string, error) {
+	args := []string{
+		fmt.Sprintf("--write_lat_log=%s", fioLatLogPrefix),
+		"--log_avg_msec=1000",
+		"--percentile_list=50:95:99:99.99",
+	}
+	args = append(args, additionalArgs...)
+	return RunFio(podName, nameSpace, runTimeSecs, args...)
+}
+
+// FioLatencySample is one entry of a fio latency log: the time offset
+// into the run and the averaged completion latency of one direction.
+type FioLatencySample struct {
+	TimeMsecs    int
+	LatencyUsecs float64
+	// Direction is "read" or "write".
+	Direction string
+}
+
+// ParseFioLatencyLog parses fio latency log lines, which have the form
+//
+//	msec, latency(nsec), direction(0=read/1=write), blocksize, offset
+func ParseFioLatencyLog(contents string) ([]FioLatencySample, error) {
+	var samples []FioLatencySample
+	for _, line := range strings.Split(contents, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("malformed fio latency log line %q", line)
+		}
+		timeMsecs, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("malformed time in fio latency log line %q", line)
+		}
+		latencyNsecs, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed latency in fio latency log line %q", line)
+		}
+		direction := "read"
+		if strings.TrimSpace(fields[2]) == "1" {
+			direction = "write"
+		}
+		samples = append(samples, FioLatencySample{
+			TimeMsecs:    timeMsecs,
+			LatencyUsecs: latencyNsecs / 1000,
+			Direction:    direction,
+		})
+	}
+	return samples, nil
+}
+
+// CollectFioLatencyCsv retrieves the fio latency logs written by
+// RunFioWithLatencyLog from the pod and writes them as a single
+// latency-over-time CSV artefact, fio-latency-<tag>.csv, in the reports
+// directory; columns are time_msec, latency_usec, direction. The rows
+// are ready for heatmap plotting against the disruption timestamps in
+// the run manifest. A no-op if no reports directory is configured.
+func CollectFioLatencyCsv(podName string, nameSpace string, tag string) error {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		logf.Log.Info("CollectFioLatencyCsv: no reports directory configured, skipping")
+		return nil
+	}
+	contents, err := ExecPod(podName, nameSpace, "sh", "-c",
+		fmt.Sprintf("cat %s_lat.*.log", fioLatLogPrefix))
+	if err != nil {
+		return fmt.Errorf("failed to retrieve fio latency logs from pod %s, error %v", podName, err)
+	}
+	samples, err := ParseFioLatencyLog(contents)
+	if err != nil {
+		return err
+	}
+	var csv strings.Builder
+	csv.WriteString("time_msec,latency_usec,direction\n")
+	for _, sample := range samples {
+		csv.WriteString(fmt.Sprintf("%d,%.1f,%s\n", sample.TimeMsecs, sample.LatencyUsecs, sample.Direction))
+	}
+	filename := path.Join(reportsDir, fmt.Sprintf("fio-latency-%s.csv", tag))
+	if err := os.WriteFile(filename, []byte(csv.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write latency artefact %s, error %v", filename, err)
+	}
+	logf.Log.Info("CollectFioLatencyCsv: captured latency log", "pod", podName, "samples", len(samples), "artefact", filename)
+	return nil
+}
@@ -0,0 +1,65 @@
+package k8stest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// componentSelectors maps the component tag used in merged log output
+// to the label selector of the pods carrying that component's logs.
+var componentSelectors = map[string]string{
+	"moac":      "app=moac",
+	"io-engine": "app=mayastor",
+	"csi":       "app=mayastor-csi",
+}
+
+// CollectComponentLogs gathers the logs of all mayastor components
+// since the given time, keyed by "<component>/<pod>" so clustered
+// components stay distinguishable in the merged stream.
+func CollectComponentLogs(since time.Time) map[string]string {
+	streams := map[string]string{}
+	for component, selector := range componentSelectors {
+		pods, err := ListPodsBySelector(MayastorNamespace, selector)
+		if err != nil {
+			logf.Log.Info("failed to list pods for log collection",
+				"selector", selector, "error", err)
+			continue
+		}
+		for _, pod := range pods.Items {
+			log, err := GetPodLogSince(pod.Name, MayastorNamespace, since)
+			if err != nil {
+				logf.Log.Info("failed to collect pod log",
+					"pod", pod.Name, "error", err)
+				continue
+			}
+			streams[component+"/"+pod.Name] = log
+		}
+	}
+	return streams
+}
+
+// WriteMergedComponentLogs collects the component logs since the given
+// time, merges them with the test's own action stream into a single
+// time-ordered artefact, and writes it to the reports directory as
+// merged-logs-<tag>.txt. Returns the artefact path.
+func WriteMergedComponentLogs(tag string, since time.Time) (string, error) {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		return "", nil
+	}
+	streams := CollectComponentLogs(since)
+	streams["test"] = logmerge.ActionStream()
+	merged := logmerge.Format(logmerge.Merge(streams))
+	filename := path.Join(reportsDir, fmt.Sprintf("merged-logs-%s.txt", tag))
+	if err := os.WriteFile(filename, []byte(merged), 0644); err != nil {
+		return "", fmt.Errorf("failed to write merged log artefact %s, error %v", filename, err)
+	}
+	return filename, nil
+}
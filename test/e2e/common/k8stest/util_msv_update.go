@@ -0,0 +1,93 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+)
+
+// updateMsvSpec applies a mutation to the spec of a mayastor volume
+// with conflict retry: the resource is re-read and the mutation
+// re-applied if the control plane updated the volume concurrently.
+func updateMsvSpec(uuid string, mutate func(*unstructured.Unstructured) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		msv, err := GetMSV(uuid)
+		if err != nil {
+			return err
+		}
+		if err := mutate(msv); err != nil {
+			return err
+		}
+		_, err = gTestEnv.DynamicClient.Resource(msvGVR).Namespace(MayastorNamespace).Update(context.TODO(), msv, metaV1.UpdateOptions{})
+		return err
+	})
+}
+
+// UpdateNumReplicas sets the replica count of a mayastor volume; the
+// control plane then grows or shrinks the volume to match.
+func UpdateNumReplicas(uuid string, replicaCount int) error {
+	if replicaCount < 1 {
+		return fmt.Errorf("invalid replica count %d for volume %s", replicaCount, uuid)
+	}
+	return updateMsvSpec(uuid, func(msv *unstructured.Unstructured) error {
+		return unstructured.SetNestedField(msv.Object, int64(replicaCount), "spec", "replicaCount")
+	})
+}
+
+// SetMsvPreferredNodes sets the scheduler's preferred nodes for the
+// replicas of a mayastor volume. An empty list clears the preference.
+func SetMsvPreferredNodes(uuid string, nodeNames []string) error {
+	return updateMsvSpec(uuid, func(msv *unstructured.Unstructured) error {
+		if len(nodeNames) == 0 {
+			unstructured.RemoveNestedField(msv.Object, "spec", "preferredNodes")
+			return nil
+		}
+		return unstructured.SetNestedStringSlice(msv.Object, nodeNames, "spec", "preferredNodes")
+	})
+}
+
+// SetMsvRequiredNodes restricts the replicas of a mayastor volume to
+// the given nodes; the nodes must exist and carry the engine label,
+// otherwise the mutation is refused here rather than leaving the
+// volume unschedulable.
+func SetMsvRequiredNodes(uuid string, nodeNames []string) error {
+	if len(nodeNames) != 0 {
+		mayastorNodes, err := GetMayastorNodeNames()
+		if err != nil {
+			return err
+		}
+		known := map[string]bool{}
+		for _, nodeName := range mayastorNodes {
+			known[nodeName] = true
+		}
+		for _, nodeName := range nodeNames {
+			if !known[nodeName] {
+				return fmt.Errorf("node %s is not a mayastor node, refusing to strand volume %s", nodeName, uuid)
+			}
+		}
+	}
+	return updateMsvSpec(uuid, func(msv *unstructured.Unstructured) error {
+		if len(nodeNames) == 0 {
+			unstructured.RemoveNestedField(msv.Object, "spec", "requiredNodes")
+			return nil
+		}
+		return unstructured.SetNestedStringSlice(msv.Object, nodeNames, "spec", "requiredNodes")
+	})
+}
+
+// GetMsvSpecInt returns an integer field of a mayastor volume spec,
+// for assertions on applied mutations.
+func GetMsvSpecInt(uuid string, field string) (int64, error) {
+	msv, err := GetMSV(uuid)
+	if err != nil {
+		return 0, err
+	}
+	value, found, err := unstructured.NestedInt64(msv.Object, "spec", field)
+	if err != nil || !found {
+		return 0, fmt.Errorf("failed to read spec field %s of MSV %s, found %v, error %v", field, uuid, found, err)
+	}
+	return value, nil
+}
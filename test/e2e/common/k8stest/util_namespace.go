@@ -0,0 +1,80 @@
+package k8stest
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Per-suite test namespaces. Each suite runs its PVCs and pods in its
+// own generated namespace instead of sharing "default", so suites can
+// execute in parallel without their resources colliding. Helpers taking
+// a namespace treat the empty string as the suite namespace.
+
+// nsInvalidChars matches everything not allowed in a namespace name.
+var nsInvalidChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// SuiteNamespace returns the per-suite test namespace name derived from
+// the suite binary name, e.g. "e2e-maya-snapshot" for "snapshot.test".
+func SuiteNamespace(binary string) string {
+	name := strings.TrimSuffix(binary, ".test")
+	name = nsInvalidChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = "suite"
+	}
+	return "e2e-maya-" + name
+}
+
+// TestNamespace returns the namespace test resources are created in by
+// default: the per-suite namespace generated at setup, or "default" when
+// none was.
+func TestNamespace() string {
+	if gTestEnv.Namespace == "" {
+		return "default"
+	}
+	return gTestEnv.Namespace
+}
+
+// resolveNamespace maps the empty namespace to the suite test namespace;
+// helpers operating on named resources apply it to their namespace
+// argument.
+func resolveNamespace(nameSpace string) string {
+	if nameSpace == "" {
+		return TestNamespace()
+	}
+	return nameSpace
+}
+
+// CreateNamespace creates the given namespace; an already existing
+// namespace is not an error.
+func CreateNamespace(nameSpace string) error {
+	ns := &coreV1.Namespace{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: nameSpace,
+		},
+	}
+	_, err := gTestEnv.KubeInt.CoreV1().Namespaces().Create(context.TODO(), ns, metaV1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err == nil {
+		trackResource("namespace", nameSpace, "")
+	}
+	return err
+}
+
+// DeleteNamespace deletes the given namespace and does not wait for it
+// to terminate.
+func DeleteNamespace(nameSpace string) error {
+	err := gTestEnv.KubeInt.CoreV1().Namespaces().Delete(context.TODO(), nameSpace, metaV1.DeleteOptions{})
+	if err == nil || k8serrors.IsNotFound(err) {
+		untrackResource("namespace", nameSpace, "")
+		return nil
+	}
+	return err
+}
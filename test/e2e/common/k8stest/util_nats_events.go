@@ -0,0 +1,118 @@
+package k8stest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsPort is the client port of the mayastor NATS server.
+const natsPort = 4222
+
+// NatsWatcher subscribes to a subject on the mayastor NATS server over
+// a port-forward and records the messages published while it runs, so
+// suites can assert on the mayastor eventing stream. The subscription
+// speaks the plain text NATS protocol directly, which keeps the harness
+// free of a client library dependency.
+type NatsWatcher struct {
+	conn net.Conn
+	stop func()
+	done chan struct{}
+
+	mu       sync.Mutex
+	messages []string
+}
+
+// StartNatsWatcher subscribes to the given subject, "*" or ">" wildcards
+// included; the caller must call Stop.
+func StartNatsWatcher(subject string) (*NatsWatcher, error) {
+	pods, err := ListPodsBySelector(MayastorNamespace, "app=nats")
+	if err != nil || len(pods.Items) == 0 {
+		return nil, fmt.Errorf("failed to find the nats pod, error %v", err)
+	}
+	localPort, stopForward, err := forwardToPod(pods.Items[0].Name, MayastorNamespace, natsPort)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		stopForward()
+		return nil, fmt.Errorf("failed to connect to nats, error %v", err)
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT {\"verbose\":false}\r\nSUB %s 1\r\n", subject); err != nil {
+		_ = conn.Close()
+		stopForward()
+		return nil, fmt.Errorf("failed to subscribe to %q, error %v", subject, err)
+	}
+	watcher := &NatsWatcher{conn: conn, stop: stopForward, done: make(chan struct{})}
+	go watcher.read()
+	return watcher, nil
+}
+
+// read is the receive loop: payloads of MSG frames are recorded, PING
+// is answered to keep the connection alive, everything else is skipped.
+func (w *NatsWatcher) read() {
+	defer close(w.done)
+	reader := bufio.NewReader(w.conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "PING" {
+			_, _ = fmt.Fprint(w.conn, "PONG\r\n")
+			continue
+		}
+		if !strings.HasPrefix(line, "MSG ") {
+			continue
+		}
+		// MSG <subject> <sid> [reply-to] <#bytes>, then the payload.
+		fields := strings.Fields(line)
+		size, err := strconv.Atoi(fields[len(fields)-1])
+		if err != nil {
+			continue
+		}
+		payload := make([]byte, size+2) // payload plus trailing CRLF
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return
+		}
+		w.mu.Lock()
+		w.messages = append(w.messages, string(payload[:size]))
+		w.mu.Unlock()
+	}
+}
+
+// Stop ends the subscription.
+func (w *NatsWatcher) Stop() {
+	_ = w.conn.Close()
+	<-w.done
+	w.stop()
+}
+
+// Messages returns the payloads recorded so far.
+func (w *NatsWatcher) Messages() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.messages...)
+}
+
+// ExpectMessage waits for up to withinSecs for a message containing the
+// given substring.
+func (w *NatsWatcher) ExpectMessage(substring string, withinSecs int) error {
+	const sleepTime = 1
+	for ix := 0; ix < withinSecs/sleepTime+1; ix++ {
+		for _, message := range w.Messages() {
+			if strings.Contains(message, substring) {
+				return nil
+			}
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("no nats message containing %q within %d seconds", substring, withinSecs)
+}
@@ -0,0 +1,45 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+
+	networkingV1 "k8s.io/api/networking/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplyDenyEgressPolicy creates a network policy denying all egress from
+// pods matching the given labels in the given namespace. Used to fence
+// components off from the outside world, e.g. to detect call-home
+// attempts or to disrupt connectivity in fault injection tests.
+func ApplyDenyEgressPolicy(policyName string, nameSpace string, podLabels map[string]string) error {
+	policy := &networkingV1.NetworkPolicy{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      policyName,
+			Namespace: nameSpace,
+		},
+		Spec: networkingV1.NetworkPolicySpec{
+			PodSelector: metaV1.LabelSelector{
+				MatchLabels: podLabels,
+			},
+			PolicyTypes: []networkingV1.PolicyType{
+				networkingV1.PolicyTypeEgress,
+			},
+			// no egress rules => all egress denied
+		},
+	}
+	_, err := gTestEnv.KubeInt.NetworkingV1().NetworkPolicies(nameSpace).Create(context.TODO(), policy, metaV1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create network policy %s, error %v", policyName, err)
+	}
+	return nil
+}
+
+// RemoveNetworkPolicy deletes the given network policy.
+func RemoveNetworkPolicy(policyName string, nameSpace string) error {
+	err := gTestEnv.KubeInt.NetworkingV1().NetworkPolicies(nameSpace).Delete(context.TODO(), policyName, metaV1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete network policy %s, error %v", policyName, err)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package k8stest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// label set on nodes which run the mayastor daemonset
+const engineLabel = "openebs.io/engine"
+const engineLabelValue = "mayastor"
+
+// GetMayastorNodeNames returns the names of all nodes labelled to run
+// mayastor.
+func GetMayastorNodeNames() ([]string, error) {
+	nodeList, err := gTestEnv.KubeInt.CoreV1().Nodes().List(context.TODO(), metaV1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", engineLabel, engineLabelValue),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mayastor nodes, error %v", err)
+	}
+	var nodeNames []string
+	for _, node := range nodeList.Items {
+		nodeNames = append(nodeNames, node.Name)
+	}
+	return nodeNames, nil
+}
+
+// GetNode retrieves a node by name.
+func GetNode(nodeName string) (*coreV1.Node, error) {
+	node, err := gTestEnv.KubeInt.CoreV1().Nodes().Get(context.TODO(), nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s, error %v", nodeName, err)
+	}
+	return node, nil
+}
+
+// patchNode applies a strategic merge patch to the node. Patching
+// rather than get-modify-update avoids conflicts with controllers which
+// write to node objects concurrently.
+func patchNode(nodeName string, patch string) error {
+	_, err := gTestEnv.KubeInt.CoreV1().Nodes().Patch(context.TODO(), nodeName,
+		types.StrategicMergePatchType, []byte(patch), metaV1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch node %s, error %v", nodeName, err)
+	}
+	return nil
+}
+
+// LabelNode sets the given label on the node, replacing any previous
+// value.
+func LabelNode(nodeName string, key string, value string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{key: value},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal label patch, error %v", err)
+	}
+	return patchNode(nodeName, string(patch))
+}
+
+// UnlabelNode removes the given label from the node; a no-op if the
+// label is not set.
+func UnlabelNode(nodeName string, key string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{key: nil},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal label patch, error %v", err)
+	}
+	return patchNode(nodeName, string(patch))
+}
+
+// SetMayastorNodeLabel adds or removes the engine label on the given
+// node, controlling whether the io-engine daemonset schedules there.
+func SetMayastorNodeLabel(nodeName string, enabled bool) error {
+	if enabled {
+		return LabelNode(nodeName, engineLabel, engineLabelValue)
+	}
+	return UnlabelNode(nodeName, engineLabel)
+}
+
+// patchNodeTaints replaces the node taint list. The taints field has no
+// merge key, so the list is patched atomically with a value computed
+// client-side, as kubectl taint does.
+func patchNodeTaints(nodeName string, taints []coreV1.Taint) error {
+	if taints == nil {
+		// nil marshals to null, which strategic merge treats as
+		// "leave unchanged" rather than "clear".
+		taints = []coreV1.Taint{}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"taints": taints,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal taint patch, error %v", err)
+	}
+	return patchNode(nodeName, string(patch))
+}
+
+// TaintNode applies the given taint to the node, leaving taints with
+// other keys in place; an existing taint with the same key is replaced.
+func TaintNode(nodeName string, key string, value string, effect coreV1.TaintEffect) error {
+	node, err := GetNode(nodeName)
+	if err != nil {
+		return err
+	}
+	taints := []coreV1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != key {
+			taints = append(taints, taint)
+		}
+	}
+	taints = append(taints, coreV1.Taint{Key: key, Value: value, Effect: effect})
+	return patchNodeTaints(nodeName, taints)
+}
+
+// UntaintNode removes all taints with the given key from the node; a
+// no-op if no such taint exists.
+func UntaintNode(nodeName string, key string) error {
+	node, err := GetNode(nodeName)
+	if err != nil {
+		return err
+	}
+	taints := []coreV1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != key {
+			taints = append(taints, taint)
+		}
+	}
+	if len(taints) == len(node.Spec.Taints) {
+		return nil
+	}
+	return patchNodeTaints(nodeName, taints)
+}
+
+// IsNodeReady returns true if the node has a Ready condition with
+// status true.
+func IsNodeReady(nodeName string) (bool, error) {
+	node, err := gTestEnv.KubeInt.CoreV1().Nodes().Get(context.TODO(), nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get node %s, error %v", nodeName, err)
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == coreV1.NodeReady {
+			return cond.Status == coreV1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+// WaitNodeReady waits for up to timeoutSecs for the node to report
+// ready.
+func WaitNodeReady(nodeName string, timeoutSecs int) error {
+	const sleepTime = 5
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		ready, err := IsNodeReady(nodeName)
+		if err == nil && ready {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("node %s not ready after %d seconds", nodeName, timeoutSecs)
+}
+
+// WaitNodeNotReady waits for up to timeoutSecs for the node to stop
+// reporting ready; used after taking a node down to be sure kubernetes
+// has noticed before asserting on its reaction.
+func WaitNodeNotReady(nodeName string, timeoutSecs int) error {
+	const sleepTime = 5
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		ready, err := IsNodeReady(nodeName)
+		if err == nil && !ready {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("node %s still ready after %d seconds", nodeName, timeoutSecs)
+}
+
+// GetCsiNodeDriverNames returns the names of the CSI drivers registered
+// with kubelet on the given node, from the CSINode object.
+func GetCsiNodeDriverNames(nodeName string) ([]string, error) {
+	csiNode, err := gTestEnv.KubeInt.StorageV1().CSINodes().Get(context.TODO(), nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CSINode %s, error %v", nodeName, err)
+	}
+	var driverNames []string
+	for _, driver := range csiNode.Spec.Drivers {
+		driverNames = append(driverNames, driver.Name)
+	}
+	return driverNames, nil
+}
+
+// NodeLocation describes a cluster node and its addresses; on dual
+// stack clusters both address fields are populated.
+type NodeLocation struct {
+	NodeName     string
+	IPv4Address  string
+	IPv6Address  string
+	MayastorNode bool
+}
+
+// GetNodeLocs returns the name, internal IPv4 and IPv6 addresses and
+// mayastor membership of every node in the cluster.
+func GetNodeLocs() ([]NodeLocation, error) {
+	nodeList, err := gTestEnv.KubeInt.CoreV1().Nodes().List(context.TODO(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes, error %v", err)
+	}
+	var nodeLocs []NodeLocation
+	for _, node := range nodeList.Items {
+		loc := NodeLocation{
+			NodeName:     node.Name,
+			MayastorNode: node.Labels[engineLabel] == engineLabelValue,
+		}
+		for _, addr := range node.Status.Addresses {
+			if addr.Type != coreV1.NodeInternalIP {
+				continue
+			}
+			ip := net.ParseIP(addr.Address)
+			if ip == nil {
+				continue
+			}
+			if ip.To4() != nil {
+				if loc.IPv4Address == "" {
+					loc.IPv4Address = addr.Address
+				}
+			} else if loc.IPv6Address == "" {
+				loc.IPv6Address = addr.Address
+			}
+		}
+		nodeLocs = append(nodeLocs, loc)
+	}
+	return nodeLocs, nil
+}
+
+// GetNodeIPAddress returns the internal IP address of the given node.
+func GetNodeIPAddress(nodeName string) (string, error) {
+	node, err := gTestEnv.KubeInt.CoreV1().Nodes().Get(context.TODO(), nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get node %s, error %v", nodeName, err)
+	}
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == "InternalIP" {
+			return addr.Address, nil
+		}
+	}
+	return "", fmt.Errorf("no internal IP address found for node %s", nodeName)
+}
@@ -0,0 +1,95 @@
+package k8stest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RunCommandOnNode runs a shell command on the host of the given node,
+// using a transient privileged pod which chroots into the host
+// filesystem. Returns the command output. Used by fault injection tests
+// which need to manipulate host state, e.g. sysfs or systemd.
+func RunCommandOnNode(nodeName string, command string) (string, error) {
+	podName := fmt.Sprintf("e2e-node-exec-%d", time.Now().UnixNano())
+	isPrivileged := true
+	hostPathType := coreV1.HostPathDirectory
+	podDef := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: podName,
+		},
+		Spec: coreV1.PodSpec{
+			NodeName:      nodeName,
+			HostNetwork:   true,
+			HostPID:       true,
+			RestartPolicy: coreV1.RestartPolicyNever,
+			Containers: []coreV1.Container{
+				{
+					Name:    "node-exec",
+					Image:   e2e_config.GetConfig().E2eFioImage,
+					Command: []string{"chroot", "/host", "sh", "-c", command},
+					SecurityContext: &coreV1.SecurityContext{
+						Privileged: &isPrivileged,
+					},
+					VolumeMounts: []coreV1.VolumeMount{
+						{
+							Name:      "host-root",
+							MountPath: "/host",
+						},
+					},
+				},
+			},
+			Volumes: []coreV1.Volume{
+				{
+					Name: "host-root",
+					VolumeSource: coreV1.VolumeSource{
+						HostPath: &coreV1.HostPathVolumeSource{
+							Path: "/",
+							Type: &hostPathType,
+						},
+					},
+				},
+			},
+		},
+	}
+	const nameSpace = "default"
+	if _, err := CreatePod(podDef, nameSpace); err != nil {
+		return "", fmt.Errorf("failed to create node exec pod on %s, error %v", nodeName, err)
+	}
+	defer func() {
+		_ = DeletePod(podName, nameSpace)
+	}()
+	if err := WaitPodCompleted(podName, nameSpace, defTimeoutSecs); err != nil {
+		return "", err
+	}
+	out, err := GetPodLog(podName, nameSpace)
+	if err != nil {
+		return "", err
+	}
+	pod, err := GetPod(podName, nameSpace)
+	if err != nil {
+		return out, err
+	}
+	if pod.Status.Phase != coreV1.PodSucceeded {
+		return out, fmt.Errorf("command %q failed on node %s, output %s", command, nodeName, out)
+	}
+	return out, nil
+}
+
+// WaitPodCompleted waits for up to timeoutSecs for the pod to reach the
+// succeeded or failed phase.
+func WaitPodCompleted(podName string, nameSpace string, timeoutSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		pod, err := GetPod(podName, nameSpace)
+		if err == nil && (pod.Status.Phase == coreV1.PodSucceeded || pod.Status.Phase == coreV1.PodFailed) {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("pod %s not completed after %d seconds", podName, timeoutSecs)
+}
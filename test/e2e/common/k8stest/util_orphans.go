@@ -0,0 +1,53 @@
+package k8stest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckForOrphanedResources compares the nexus and replica objects the
+// control plane holds against the set of volumes and returns an error
+// naming every object with no owning volume. The control plane names
+// both after the uuid of the volume they belong to, so an unmatched
+// uuid is a leak, typically left behind by a control plane crash midway
+// through provisioning or teardown.
+func CheckForOrphanedResources() error {
+	msvs, err := ListMsv()
+	if err != nil {
+		return err
+	}
+	volumes := map[string]bool{}
+	for _, msv := range msvs {
+		volumes[msv.Name] = true
+	}
+
+	client, stop, err := ControlPlaneRestClient()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	var orphans []string
+	replicas, err := client.GetReplicas()
+	if err != nil {
+		return err
+	}
+	for _, replica := range replicas {
+		if !volumes[replica.Uuid] {
+			orphans = append(orphans, fmt.Sprintf("replica %s on %s", replica.Uuid, replica.Node))
+		}
+	}
+	nexuses, err := client.GetNexuses()
+	if err != nil {
+		return err
+	}
+	for _, nexus := range nexuses {
+		if !volumes[nexus.Uuid] {
+			orphans = append(orphans, fmt.Sprintf("nexus %s on %s", nexus.Uuid, nexus.Node))
+		}
+	}
+	if len(orphans) != 0 {
+		return fmt.Errorf("%d orphaned resources found: %s", len(orphans), strings.Join(orphans, ", "))
+	}
+	return nil
+}
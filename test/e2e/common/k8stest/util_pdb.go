@@ -0,0 +1,70 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+
+	policyV1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ListPodDisruptionBudgets returns the PDBs in the given namespace.
+func ListPodDisruptionBudgets(nameSpace string) (*policyV1.PodDisruptionBudgetList, error) {
+	return gTestEnv.KubeInt.PolicyV1().PodDisruptionBudgets(nameSpace).List(context.TODO(), metaV1.ListOptions{})
+}
+
+// MkPodDisruptionBudget creates a PDB limiting voluntary disruptions of
+// the pods matching the given labels to maxUnavailable at a time. Used
+// by tests to protect components which do not ship a PDB of their own.
+func MkPodDisruptionBudget(pdbName string, nameSpace string, podLabels map[string]string, maxUnavailable int) error {
+	maxUnavailableVal := intstr.FromInt(maxUnavailable)
+	pdb := &policyV1.PodDisruptionBudget{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      pdbName,
+			Namespace: nameSpace,
+		},
+		Spec: policyV1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailableVal,
+			Selector: &metaV1.LabelSelector{
+				MatchLabels: podLabels,
+			},
+		},
+	}
+	_, err := gTestEnv.KubeInt.PolicyV1().PodDisruptionBudgets(nameSpace).Create(context.TODO(), pdb, metaV1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create pod disruption budget %s, error %v", pdbName, err)
+	}
+	return nil
+}
+
+// RmPodDisruptionBudget deletes the given PDB.
+func RmPodDisruptionBudget(pdbName string, nameSpace string) error {
+	err := gTestEnv.KubeInt.PolicyV1().PodDisruptionBudgets(nameSpace).Delete(context.TODO(), pdbName, metaV1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete pod disruption budget %s, error %v", pdbName, err)
+	}
+	return nil
+}
+
+// EvictPod requests a voluntary eviction of the given pod. Returns true
+// if the eviction was admitted, false if it was rejected by a pod
+// disruption budget, and an error for any other failure.
+func EvictPod(podName string, nameSpace string) (bool, error) {
+	eviction := &policyV1.Eviction{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      podName,
+			Namespace: nameSpace,
+		},
+	}
+	err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).EvictV1(context.TODO(), eviction)
+	if err != nil {
+		if k8serrors.IsTooManyRequests(err) {
+			// the eviction was rejected by a PDB
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to evict pod %s, error %v", podName, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,245 @@
+package k8stest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/timeouts"
+
+	coreV1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// CreatePod creates a pod from the given definition in the given
+// namespace, the suite test namespace when empty.
+func CreatePod(podDef *coreV1.Pod, nameSpace string) (*coreV1.Pod, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	pod, err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).Create(context.TODO(), podDef, metaV1.CreateOptions{})
+	if err == nil {
+		trackResource("pod", podDef.Name, nameSpace)
+	}
+	return pod, err
+}
+
+// DeletePod deletes a pod by name and does not wait for it to go away.
+func DeletePod(podName string, nameSpace string) error {
+	nameSpace = resolveNamespace(nameSpace)
+	err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).Delete(context.TODO(), podName, metaV1.DeleteOptions{})
+	if err == nil || k8serrors.IsNotFound(err) {
+		untrackResource("pod", podName, nameSpace)
+	}
+	return err
+}
+
+// DeletePodForce deletes a pod with a zero grace period. Needed to
+// release pods stuck terminating on a dead node, where kubelet can no
+// longer confirm the deletion.
+func DeletePodForce(podName string, nameSpace string) error {
+	nameSpace = resolveNamespace(nameSpace)
+	gracePeriod := int64(0)
+	err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).Delete(context.TODO(), podName,
+		metaV1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	if err == nil || k8serrors.IsNotFound(err) {
+		untrackResource("pod", podName, nameSpace)
+	}
+	return err
+}
+
+// GetPod retrieves a pod by name.
+func GetPod(podName string, nameSpace string) (*coreV1.Pod, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	return gTestEnv.KubeInt.CoreV1().Pods(nameSpace).Get(context.TODO(), podName, metaV1.GetOptions{})
+}
+
+// IsPodRunning returns true if the pod is in the running phase.
+func IsPodRunning(podName string, nameSpace string) bool {
+	pod, err := GetPod(podName, nameSpace)
+	if err != nil {
+		return false
+	}
+	return pod.Status.Phase == coreV1.PodRunning
+}
+
+// WaitPodRunning waits for up to timeoutSecs for the pod to reach the
+// running phase, reacting to watch events rather than polling. A
+// timeoutSecs of 0 selects the configured pod readiness budget.
+func WaitPodRunning(podName string, nameSpace string, timeoutSecs int) error {
+	if timeoutSecs == 0 {
+		timeoutSecs = timeouts.PodReadySecs()
+	}
+	_, err := WaitFor(fmt.Sprintf("pod %s running", podName),
+		podListWatch(nameSpace, podName), &coreV1.Pod{},
+		func(obj runtime.Object) (bool, error) {
+			pod, ok := obj.(*coreV1.Pod)
+			return ok && pod.Status.Phase == coreV1.PodRunning, nil
+		}, timeoutSecs)
+	return err
+}
+
+// GetPodLog retrieves the log of the given pod.
+func GetPodLog(podName string, nameSpace string) (string, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	contents, err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).GetLogs(podName, &coreV1.PodLogOptions{}).DoRaw(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve log of pod %s, error %v", podName, err)
+	}
+	return string(contents), nil
+}
+
+// GetPodLogSince retrieves the log of the given pod, limited to entries
+// after the given time.
+func GetPodLogSince(podName string, nameSpace string, since time.Time) (string, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	sinceTime := metaV1.NewTime(since)
+	contents, err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).GetLogs(podName, &coreV1.PodLogOptions{
+		SinceTime: &sinceTime,
+	}).DoRaw(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve log of pod %s, error %v", podName, err)
+	}
+	return string(contents), nil
+}
+
+// ExecInPod executes a command in the given container of the pod using
+// the kubernetes exec subresource, returning captured stdout and stderr
+// separately. An empty container selects the pod's default container. A
+// positive timeoutSecs bounds the execution; zero means no limit.
+func ExecInPod(nameSpace string, podName string, container string, command []string, timeoutSecs int) (string, string, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	req := gTestEnv.KubeInt.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(nameSpace).Name(podName).
+		SubResource("exec").
+		VersionedParams(&coreV1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(gTestEnv.Cfg, "POST", req.URL())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create executor for pod %s, error %v", podName, err)
+	}
+	ctx := context.Background()
+	if timeoutSecs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSecs)*time.Second)
+		defer cancel()
+	}
+	var stdout, stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return stdout.String(), stderr.String(),
+			fmt.Errorf("exec in pod %s failed, error %v, stderr %s", podName, err, stderr.String())
+	}
+	return stdout.String(), stderr.String(), nil
+}
+
+// ExecPod executes a command in the default container of the given pod
+// and returns its standard output; the convenience wrapper most tests
+// use. ExecInPod exposes the container and timeout controls.
+func ExecPod(podName string, nameSpace string, command ...string) (string, error) {
+	out, _, err := ExecInPod(nameSpace, podName, "", command, 0)
+	return out, err
+}
+
+// RawBlockDevicePath is where raw block pod specs map the volume
+// device inside the container.
+const RawBlockDevicePath = "/dev/ms-volume"
+
+// CsiNodePod returns the name of the csi node plugin pod running on the
+// given node, and the container to exec into. The plugin container
+// shares the host namespaces the initiator connections live in;
+// sidecars such as the registrar do not.
+func CsiNodePod(nodeName string) (string, string, error) {
+	pods, err := ListPodsBySelector(MayastorNamespace, "app=mayastor-csi")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list csi node pods, error %v", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			if strings.Contains(container.Name, "csi") {
+				return pod.Name, container.Name, nil
+			}
+		}
+		return pod.Name, pod.Spec.Containers[0].Name, nil
+	}
+	return "", "", fmt.Errorf("no csi node pod found on node %s", nodeName)
+}
+
+// NewVolumePodSpec returns a minimal pod definition with the given PVC
+// mounted at /volume, suitable as a starting point for test application
+// pods.
+func NewVolumePodSpec(podName string, volName string, image string, args []string) *coreV1.Pod {
+	return &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: podName,
+		},
+		Spec: coreV1.PodSpec{
+			RestartPolicy: coreV1.RestartPolicyNever,
+			Containers: []coreV1.Container{
+				{
+					Name:  podName,
+					Image: image,
+					Args:  args,
+					VolumeMounts: []coreV1.VolumeMount{
+						{
+							Name:      "ms-volume",
+							MountPath: "/volume",
+						},
+					},
+				},
+			},
+			Volumes: []coreV1.Volume{
+				{
+					Name: "ms-volume",
+					VolumeSource: coreV1.VolumeSource{
+						PersistentVolumeClaim: &coreV1.PersistentVolumeClaimVolumeSource{
+							ClaimName: volName,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// SetPodNonRootUser constrains the pod to run as the given non root
+// user, with fsGroup applied so kubelet chowns the mounted volume to
+// the group and the user can write to it.
+func SetPodNonRootUser(podDef *coreV1.Pod, runAsUser int64, fsGroup int64) {
+	runAsNonRoot := true
+	podDef.Spec.SecurityContext = &coreV1.PodSecurityContext{
+		RunAsNonRoot: &runAsNonRoot,
+		RunAsUser:    &runAsUser,
+		RunAsGroup:   &fsGroup,
+		FSGroup:      &fsGroup,
+	}
+}
+
+// NewRawBlockPodSpec returns a minimal pod definition with the given
+// raw block PVC mapped as the device RawBlockDevicePath, for tests
+// consuming the volume without a filesystem.
+func NewRawBlockPodSpec(podName string, volName string, image string, args []string) *coreV1.Pod {
+	podDef := NewVolumePodSpec(podName, volName, image, args)
+	podDef.Spec.Containers[0].VolumeMounts = nil
+	podDef.Spec.Containers[0].VolumeDevices = []coreV1.VolumeDevice{
+		{
+			Name:       "ms-volume",
+			DevicePath: RawBlockDevicePath,
+		},
+	}
+	return podDef
+}
@@ -0,0 +1,193 @@
+package k8stest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PoolRecord is one pool's accounting as reported by one source: the
+// CRD status, the control plane REST API, or the Prometheus metrics of
+// the io-engine.
+type PoolRecord struct {
+	Name          string
+	CapacityBytes int64
+	UsedBytes     int64
+}
+
+// AvailableBytes returns the capacity not yet allocated to replicas.
+func (r PoolRecord) AvailableBytes() int64 {
+	return r.CapacityBytes - r.UsedBytes
+}
+
+// PoolsFromCrds reads pool capacity and usage from the mayastor pool
+// custom resource statuses.
+func PoolsFromCrds() (map[string]PoolRecord, error) {
+	pools, err := ListMsPools()
+	if err != nil {
+		return nil, err
+	}
+	records := map[string]PoolRecord{}
+	for _, pool := range pools {
+		capacity, _, err := unstructured.NestedInt64(pool.Object, "status", "capacity")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read capacity of pool %s, error %v", pool.GetName(), err)
+		}
+		used, _, err := unstructured.NestedInt64(pool.Object, "status", "used")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read usage of pool %s, error %v", pool.GetName(), err)
+		}
+		records[pool.GetName()] = PoolRecord{Name: pool.GetName(), CapacityBytes: capacity, UsedBytes: used}
+	}
+	return records, nil
+}
+
+// PoolsFromRestApi reads pool accounting from the control plane REST
+// API via a port-forward to the moac pod.
+func PoolsFromRestApi() (map[string]PoolRecord, error) {
+	client, stop, err := ControlPlaneRestClient()
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	pools, err := client.GetPools()
+	if err != nil {
+		return nil, err
+	}
+	records := map[string]PoolRecord{}
+	for _, pool := range pools {
+		records[pool.Name] = PoolRecord{Name: pool.Name, CapacityBytes: pool.Capacity, UsedBytes: pool.Used}
+	}
+	return records, nil
+}
+
+var poolMetricRegexp = regexp.MustCompile(`^pool_(total|used)_size_bytes\{[^}]*name="([^"]+)"[^}]*\}\s+([0-9.e+]+)$`)
+
+// ParsePoolMetrics extracts pool capacity and usage from Prometheus
+// text exposition output, matching the pool_total_size_bytes and
+// pool_used_size_bytes families.
+func ParsePoolMetrics(metricsText string) map[string]PoolRecord {
+	records := map[string]PoolRecord{}
+	for _, line := range strings.Split(metricsText, "\n") {
+		match := poolMetricRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			continue
+		}
+		record := records[match[2]]
+		record.Name = match[2]
+		if match[1] == "total" {
+			record.CapacityBytes = int64(value)
+		} else {
+			record.UsedBytes = int64(value)
+		}
+		records[match[2]] = record
+	}
+	return records
+}
+
+// PoolsFromMetrics reads pool accounting from the Prometheus metrics
+// endpoints of all io-engine pods.
+func PoolsFromMetrics() (map[string]PoolRecord, error) {
+	pods, err := ListPodsBySelector(MayastorNamespace, "app=mayastor")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list io-engine pods, error %v", err)
+	}
+	port := e2e_config.GetConfig().PoolConsistency.MetricsPort
+	records := map[string]PoolRecord{}
+	for _, pod := range pods.Items {
+		localPort, stop, err := forwardToPod(pod.Name, MayastorNamespace, port)
+		if err != nil {
+			return nil, err
+		}
+		client := http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", localPort))
+		if err != nil {
+			stop()
+			return nil, fmt.Errorf("failed to query metrics of pod %s, error %v", pod.Name, err)
+		}
+		contents, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		stop()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metrics of pod %s, error %v", pod.Name, err)
+		}
+		for name, record := range ParsePoolMetrics(string(contents)) {
+			records[name] = record
+		}
+	}
+	return records, nil
+}
+
+// withinTolerance reports whether two byte figures agree within the
+// given percentage of the larger one; zero against zero agrees.
+func withinTolerance(a int64, b int64, tolerancePercent int) bool {
+	larger := a
+	if b > larger {
+		larger = b
+	}
+	if larger == 0 {
+		return true
+	}
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	return float64(diff)/float64(larger)*100 <= float64(tolerancePercent)
+}
+
+// CheckPoolConsistency compares pool capacity and usage across the CRD
+// statuses, the REST API and the Prometheus metrics, and returns a
+// description of every figure disagreeing beyond the configured
+// tolerance. Catches accounting drift where a pool shows space used
+// with no volumes to account for it.
+func CheckPoolConsistency() ([]string, error) {
+	tolerance := e2e_config.GetConfig().PoolConsistency.TolerancePercent
+	fromCrds, err := PoolsFromCrds()
+	if err != nil {
+		return nil, err
+	}
+	sources := map[string]map[string]PoolRecord{}
+	if fromRest, err := PoolsFromRestApi(); err == nil {
+		sources["REST API"] = fromRest
+	} else {
+		return nil, err
+	}
+	if fromMetrics, err := PoolsFromMetrics(); err == nil {
+		sources["metrics"] = fromMetrics
+	} else {
+		return nil, err
+	}
+
+	var mismatches []string
+	for sourceName, source := range sources {
+		for name, crdRecord := range fromCrds {
+			record, ok := source[name]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("pool %s missing from the %s", name, sourceName))
+				continue
+			}
+			if !withinTolerance(crdRecord.CapacityBytes, record.CapacityBytes, tolerance) {
+				mismatches = append(mismatches, fmt.Sprintf("pool %s capacity: CRD %d, %s %d",
+					name, crdRecord.CapacityBytes, sourceName, record.CapacityBytes))
+			}
+			if !withinTolerance(crdRecord.UsedBytes, record.UsedBytes, tolerance) {
+				mismatches = append(mismatches, fmt.Sprintf("pool %s usage: CRD %d, %s %d",
+					name, crdRecord.UsedBytes, sourceName, record.UsedBytes))
+			}
+		}
+	}
+	return mismatches, nil
+}
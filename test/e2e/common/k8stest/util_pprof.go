@@ -0,0 +1,122 @@
+package k8stest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// pprof endpoints captured by CapturePprofProfiles, relative to the
+// debug http server root of the target component.
+var pprofEndpoints = map[string]string{
+	"cpu":       "/debug/pprof/profile?seconds=%d",
+	"heap":      "/debug/pprof/heap",
+	"goroutine": "/debug/pprof/goroutine",
+}
+
+// forwardToPod sets up a port-forward to the given pod and returns the
+// local port and a function to tear the forward down.
+func forwardToPod(podName string, nameSpace string, podPort int) (uint16, func(), error) {
+	url := gTestEnv.KubeInt.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(nameSpace).
+		Name(podName).
+		SubResource("portforward").URL()
+
+	transport, upgrader, err := spdy.RoundTripperFor(gTestEnv.Cfg)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create round tripper, error %v", err)
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	// Port 0 => an ephemeral local port, retrieved below once the
+	// forwarder is ready.
+	fw, err := portforward.New(dialer, []string{fmt.Sprintf("0:%d", podPort)}, stopChan, readyChan, io.Discard, io.Discard)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create port forwarder, error %v", err)
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- fw.ForwardPorts()
+	}()
+	select {
+	case err = <-errChan:
+		return 0, nil, fmt.Errorf("port forward to pod %s failed, error %v", podName, err)
+	case <-readyChan:
+	}
+	ports, err := fw.GetPorts()
+	if err != nil || len(ports) == 0 {
+		close(stopChan)
+		return 0, nil, fmt.Errorf("failed to get forwarded ports, error %v", err)
+	}
+	atomic.AddInt32(&activePortForwards, 1)
+	var stopOnce sync.Once
+	return ports[0].Local, func() {
+		stopOnce.Do(func() {
+			close(stopChan)
+			atomic.AddInt32(&activePortForwards, -1)
+		})
+	}, nil
+}
+
+// CapturePprofProfiles captures cpu, heap and goroutine profiles from the
+// debug endpoint of the given pod via port-forward, and writes them as
+// artefacts to the reports directory. The artefact file names include the
+// pod name, the profile type and the given tag, so captures at different
+// points of a test run can be told apart. A no-op if no reports directory
+// is configured.
+func CapturePprofProfiles(podName string, nameSpace string, podPort int, cpuSampleSecs int, tag string) error {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		logf.Log.Info("CapturePprofProfiles: no reports directory configured, skipping")
+		return nil
+	}
+
+	localPort, stop, err := forwardToPod(podName, nameSpace, podPort)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	for profile, endpoint := range pprofEndpoints {
+		url := fmt.Sprintf("http://127.0.0.1:%d", localPort)
+		if profile == "cpu" {
+			url += fmt.Sprintf(endpoint, cpuSampleSecs)
+		} else {
+			url += endpoint
+		}
+		client := http.Client{Timeout: time.Duration(cpuSampleSecs+30) * time.Second}
+		resp, err := client.Get(url)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve %s profile from pod %s, error %v", profile, podName, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return fmt.Errorf("failed to retrieve %s profile from pod %s, status %s", profile, podName, resp.Status)
+		}
+		contents, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s profile from pod %s, error %v", profile, podName, err)
+		}
+		filename := path.Join(reportsDir, fmt.Sprintf("pprof-%s-%s-%s.pb.gz", podName, profile, tag))
+		if err := os.WriteFile(filename, contents, 0644); err != nil {
+			return fmt.Errorf("failed to write profile artefact %s, error %v", filename, err)
+		}
+		logf.Log.Info("CapturePprofProfiles: captured profile", "pod", podName, "profile", profile, "artefact", filename)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+	"github.com/openebs/mayastor/test/e2e/common/timeouts"
+
+	coreV1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/flowcontrol"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const defTimeoutSecs = 90
+
+// number of parallel workers used when deleting PVCs individually
+const pvcDeleteWorkers = 8
+
+// client-side QPS cap applied to per-item PVC delete calls so that bulk
+// deletion does not overwhelm the API server
+const pvcDeleteQps = 20
+
+// mkPVC creates a PVC with the given volume mode in the given
+// namespace, the suite test namespace when empty, and returns the
+// create response from the API server. A non-nil dataSource makes the
+// claim a clone of a PVC or a restore of a snapshot.
+func mkPVC(volName string, volSizeMb int, scName string, nameSpace string, volumeMode coreV1.PersistentVolumeMode, dataSource *coreV1.TypedLocalObjectReference) (*coreV1.PersistentVolumeClaim, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	volSize := fmt.Sprintf("%dM", volSizeMb)
+	// An empty scName omits storageClassName, so the claim binds to the
+	// cluster default storage class.
+	var storageClassName *string
+	if scName != "" {
+		storageClassName = &scName
+	}
+	createOpts := &coreV1.PersistentVolumeClaim{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      volName,
+			Namespace: nameSpace,
+		},
+		Spec: coreV1.PersistentVolumeClaimSpec{
+			StorageClassName: storageClassName,
+			AccessModes:      []coreV1.PersistentVolumeAccessMode{coreV1.ReadWriteOnce},
+			VolumeMode:       &volumeMode,
+			DataSource:       dataSource,
+			Resources: coreV1.ResourceRequirements{
+				Requests: coreV1.ResourceList{
+					coreV1.ResourceStorage: resource.MustParse(volSize),
+				},
+			},
+		},
+	}
+	pvc, err := gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).Create(context.TODO(), createOpts, metaV1.CreateOptions{})
+	if err == nil {
+		trackResource("pvc", volName, nameSpace)
+		runreport.RecordVolume(volName, scName)
+	}
+	return pvc, err
+}
+
+// CreatePVC creates a filesystem mode PVC.
+func CreatePVC(volName string, volSizeMb int, scName string, nameSpace string) (*coreV1.PersistentVolumeClaim, error) {
+	return mkPVC(volName, volSizeMb, scName, nameSpace, coreV1.PersistentVolumeFilesystem, nil)
+}
+
+// CreateRawBlockPVC creates a raw block mode PVC; consumers see the
+// volume as a device rather than a mounted filesystem.
+func CreateRawBlockPVC(volName string, volSizeMb int, scName string, nameSpace string) (*coreV1.PersistentVolumeClaim, error) {
+	return mkPVC(volName, volSizeMb, scName, nameSpace, coreV1.PersistentVolumeBlock, nil)
+}
+
+// ClonePVC creates a filesystem mode PVC cloned from the given source
+// PVC. The requested size must be at least the size of the source
+// volume, and the clone provisions in the same storage class.
+func ClonePVC(volName string, srcVolName string, volSizeMb int, scName string, nameSpace string) (*coreV1.PersistentVolumeClaim, error) {
+	return mkPVC(volName, volSizeMb, scName, nameSpace, coreV1.PersistentVolumeFilesystem,
+		&coreV1.TypedLocalObjectReference{
+			Kind: "PersistentVolumeClaim",
+			Name: srcVolName,
+		})
+}
+
+// DeletePVC deletes a PVC by name and does not wait for the claim or the
+// bound volume to go away.
+func DeletePVC(volName string, nameSpace string) error {
+	nameSpace = resolveNamespace(nameSpace)
+	err := gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).Delete(context.TODO(), volName, metaV1.DeleteOptions{})
+	if err == nil || k8serrors.IsNotFound(err) {
+		untrackResource("pvc", volName, nameSpace)
+	}
+	return err
+}
+
+// GetPVC retrieves a PVC by name.
+func GetPVC(volName string, nameSpace string) (*coreV1.PersistentVolumeClaim, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	return gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).Get(context.TODO(), volName, metaV1.GetOptions{})
+}
+
+// GetPvcStatusPhase retrieves the status phase of a PVC.
+func GetPvcStatusPhase(volName string, nameSpace string) (coreV1.PersistentVolumeClaimPhase, error) {
+	pvc, err := GetPVC(volName, nameSpace)
+	if err != nil {
+		return "", err
+	}
+	return pvc.Status.Phase, nil
+}
+
+// WaitPvcBound waits for up to timeoutSecs for the PVC to reach the
+// bound phase, reacting to watch events rather than polling. A
+// timeoutSecs of 0 selects the configured claim binding budget.
+func WaitPvcBound(volName string, nameSpace string, timeoutSecs int) error {
+	if timeoutSecs == 0 {
+		timeoutSecs = timeouts.PvcBindSecs()
+	}
+	_, err := WaitFor(fmt.Sprintf("PVC %s bound", volName),
+		pvcListWatch(nameSpace, volName), &coreV1.PersistentVolumeClaim{},
+		func(obj runtime.Object) (bool, error) {
+			pvc, ok := obj.(*coreV1.PersistentVolumeClaim)
+			return ok && pvc.Status.Phase == coreV1.ClaimBound, nil
+		}, timeoutSecs)
+	return err
+}
+
+// WaitPvcStillPending verifies the claim does not bind within
+// windowSecs, for negative tests where provisioning is expected to fail
+// - e.g. more replicas requested than nodes available, or a volume
+// larger than the pool capacity. Returns an error if the claim binds or
+// leaves the pending phase within the window.
+func WaitPvcStillPending(volName string, nameSpace string, windowSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (windowSecs+sleepTime-1)/sleepTime; ix++ {
+		phase, err := GetPvcStatusPhase(volName, nameSpace)
+		if err != nil {
+			return err
+		}
+		if phase != coreV1.ClaimPending {
+			return fmt.Errorf("PVC %s reached phase %s, expected it to stay pending", volName, phase)
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return nil
+}
+
+// GetVolumeUuid returns the uuid of the mayastor volume bound to a PVC,
+// i.e. the CSI volume handle of the bound PV, which also names the MSV.
+func GetVolumeUuid(volName string, nameSpace string) (string, error) {
+	pvc, err := GetPVC(volName, nameSpace)
+	if err != nil {
+		return "", err
+	}
+	if pvc.Spec.VolumeName == "" {
+		return "", fmt.Errorf("PVC %s is not bound", volName)
+	}
+	pv, err := GetPV(pvc.Spec.VolumeName)
+	if err != nil {
+		return "", err
+	}
+	if pv.Spec.CSI == nil {
+		return "", fmt.Errorf("PV %s is not a CSI volume", pvc.Spec.VolumeName)
+	}
+	return pv.Spec.CSI.VolumeHandle, nil
+}
+
+// ExpandPVC patches the PVC to request newSizeMb and waits for up to
+// timeoutSecs for the new capacity to be reflected in the bound PV and
+// the MSV. The storage class must allow volume expansion.
+func ExpandPVC(volName string, newSizeMb int, nameSpace string, timeoutSecs int) error {
+	nameSpace = resolveNamespace(nameSpace)
+	newSize := resource.MustParse(fmt.Sprintf("%dM", newSizeMb))
+	patch := []byte(fmt.Sprintf(`{"spec":{"resources":{"requests":{"storage":"%dM"}}}}`, newSizeMb))
+	pvc, err := gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).Patch(context.TODO(), volName, types.MergePatchType, patch, metaV1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch PVC %s, error %v", volName, err)
+	}
+	if pvc.Spec.VolumeName == "" {
+		return fmt.Errorf("PVC %s is not bound, cannot track expansion", volName)
+	}
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		pv, err := GetPV(pvc.Spec.VolumeName)
+		if err == nil {
+			capacity := pv.Spec.Capacity[coreV1.ResourceStorage]
+			if capacity.Value() >= newSize.Value() {
+				msv, err := GetMsv(pv.Spec.CSI.VolumeHandle)
+				if err == nil && msv.Status.Size >= newSize.Value() {
+					return nil
+				}
+			}
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("expansion of PVC %s to %dMB not reflected after %d seconds", volName, newSizeMb, timeoutSecs)
+}
+
+// ListPvcs returns the set of PVCs in the given namespace.
+func ListPvcs(nameSpace string) (*coreV1.PersistentVolumeClaimList, error) {
+	return gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).List(context.TODO(), metaV1.ListOptions{})
+}
+
+// DeleteAllPvcs deletes all PVCs in the given namespace and waits for the
+// claims to go away. Deletion is issued in bulk with DeleteCollection and
+// falls back to rate limited parallel per-item deletes for claims the
+// collection delete did not cover, followed by a single aggregated wait.
+// Returns the number of claims still present when the wait expired.
+func DeleteAllPvcs(nameSpace string) (int, error) {
+	// Issue one collection delete for the namespace, this covers the
+	// common case with a single API call.
+	err := gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).DeleteCollection(
+		context.TODO(), metaV1.DeleteOptions{}, metaV1.ListOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		logf.Log.Info("DeleteAllPvcs: DeleteCollection failed, falling back to per-item deletes", "error", err)
+	}
+
+	// Mop up anything DeleteCollection did not cover with parallel,
+	// rate limited, per-item deletes.
+	pvcs, err := ListPvcs(nameSpace)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list PVCs in namespace %s, error %v", nameSpace, err)
+	}
+	if len(pvcs.Items) != 0 {
+		rateLimiter := flowcontrol.NewTokenBucketRateLimiter(pvcDeleteQps, pvcDeleteQps)
+		names := make(chan string, len(pvcs.Items))
+		for _, pvc := range pvcs.Items {
+			if pvc.ObjectMeta.DeletionTimestamp == nil {
+				names <- pvc.Name
+			}
+		}
+		close(names)
+		var wg sync.WaitGroup
+		for ix := 0; ix < pvcDeleteWorkers; ix++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for name := range names {
+					rateLimiter.Accept()
+					delErr := DeletePVC(name, nameSpace)
+					if delErr != nil && !k8serrors.IsNotFound(delErr) {
+						logf.Log.Info("DeleteAllPvcs: failed to delete PVC", "pvc", name, "error", delErr)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	// A single aggregated wait for all claims to go away, instead of a
+	// per-claim wait.
+	remaining := 0
+	const sleepTime = 2
+	for ix := 0; ix < defTimeoutSecs/sleepTime; ix++ {
+		pvcs, err = ListPvcs(nameSpace)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list PVCs in namespace %s, error %v", nameSpace, err)
+		}
+		remaining = len(pvcs.Items)
+		if remaining == 0 {
+			break
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	if remaining != 0 {
+		return remaining, fmt.Errorf("%d PVCs still present in namespace %s after %d seconds", remaining, nameSpace, defTimeoutSecs)
+	}
+	return 0, nil
+}
+
+// DefaultReplicaCount returns the replica count tests should use when not
+// otherwise specified.
+func DefaultReplicaCount() int {
+	return e2e_config.GetConfig().DefaultReplicaCount
+}
@@ -0,0 +1,188 @@
+package k8stest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Cross node data integrity verification. Each replica of a volume is
+// connected to directly over its nvmf target from a privileged checker
+// pod and its contents checksummed, so disruption tests can assert the
+// replicas converged to identical data instead of trusting fio verify
+// alone.
+
+// ReplicaChecksum is the checksum of one replica's contents.
+type ReplicaChecksum struct {
+	Node     string
+	Uri      string
+	Checksum string
+}
+
+// checksumMarker prefixes the checksum line in the checker pod output,
+// separating it from nvme-cli noise.
+const checksumMarker = "replica-checksum:"
+
+// checksumScript connects to one nvmf target, checksums the namespace
+// device and disconnects again. The device is identified as the one
+// which appears after the connect.
+const checksumScript = `
+set -e
+before=$(ls /dev/nvme*n* 2>/dev/null || true)
+nvme connect -t tcp -a "$1" -s "$2" -n "$3"
+sleep 2
+after=$(ls /dev/nvme*n* 2>/dev/null || true)
+dev=""
+for d in $after; do
+	case " $before " in
+	*" $d "*) ;;
+	*) dev=$d ;;
+	esac
+done
+if [ -z "$dev" ]; then
+	echo "no nvme device appeared" >&2
+	exit 1
+fi
+sum=$(md5sum "$dev" | cut -d' ' -f1)
+nvme disconnect -n "$3" > /dev/null
+echo "` + checksumMarker + ` $sum"
+`
+
+// checksumReplicaTarget runs the checker pod on the given node against
+// one nvmf target and returns the checksum of the namespace contents.
+func checksumReplicaTarget(nodeName string, addr string, port string, nqn string) (string, error) {
+	podName := fmt.Sprintf("e2e-replica-checksum-%d", time.Now().UnixNano())
+	isPrivileged := true
+	hostPathType := coreV1.HostPathDirectory
+	podDef := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: podName,
+		},
+		Spec: coreV1.PodSpec{
+			NodeSelector:  map[string]string{"kubernetes.io/hostname": nodeName},
+			HostNetwork:   true,
+			RestartPolicy: coreV1.RestartPolicyNever,
+			Containers: []coreV1.Container{
+				{
+					Name:    "replica-checksum",
+					Image:   e2e_config.GetConfig().E2eFioImage,
+					Command: []string{"sh", "-c", checksumScript, "checksum", addr, port, nqn},
+					SecurityContext: &coreV1.SecurityContext{
+						Privileged: &isPrivileged,
+					},
+					VolumeMounts: []coreV1.VolumeMount{
+						{
+							Name:      "device-dir",
+							MountPath: "/dev",
+						},
+					},
+				},
+			},
+			Volumes: []coreV1.Volume{
+				{
+					Name: "device-dir",
+					VolumeSource: coreV1.VolumeSource{
+						HostPath: &coreV1.HostPathVolumeSource{
+							Path: "/dev",
+							Type: &hostPathType,
+						},
+					},
+				},
+			},
+		},
+	}
+	const nameSpace = "default"
+	if _, err := CreatePod(podDef, nameSpace); err != nil {
+		return "", fmt.Errorf("failed to create checker pod on %s, error %v", nodeName, err)
+	}
+	defer func() {
+		_ = DeletePod(podName, nameSpace)
+	}()
+	if err := WaitPodCompleted(podName, nameSpace, defTimeoutSecs); err != nil {
+		return "", err
+	}
+	out, err := GetPodLog(podName, nameSpace)
+	if err != nil {
+		return "", err
+	}
+	pod, err := GetPod(podName, nameSpace)
+	if err != nil {
+		return "", err
+	}
+	if pod.Status.Phase != coreV1.PodSucceeded {
+		return "", fmt.Errorf("checksum of %s on node %s failed, output %s", nqn, nodeName, out)
+	}
+	return ParseReplicaChecksum(out)
+}
+
+// ParseReplicaChecksum extracts the checksum from checker pod output.
+func ParseReplicaChecksum(out string) (string, error) {
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, checksumMarker) {
+			sum := strings.TrimSpace(strings.TrimPrefix(line, checksumMarker))
+			if sum == "" {
+				break
+			}
+			return sum, nil
+		}
+	}
+	return "", fmt.Errorf("no checksum found in checker output %q", out)
+}
+
+// ChecksumReplicas checksums the contents of every replica of the given
+// volume by connecting to each replica's nvmf target directly. Only
+// nvmf shared replicas are supported; the caller must quiesce I/O to the
+// volume first or the checksums are meaningless.
+func ChecksumReplicas(uuid string) ([]ReplicaChecksum, error) {
+	msv, err := GetMsv(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if len(msv.Status.Replicas) == 0 {
+		return nil, fmt.Errorf("MSV %s has no replicas to checksum", uuid)
+	}
+	var checksums []ReplicaChecksum
+	for _, replica := range msv.Status.Replicas {
+		target, err := url.Parse(replica.Uri)
+		if err != nil {
+			return nil, fmt.Errorf("unparsable URI %q of replica on %s, error %v", replica.Uri, replica.Node, err)
+		}
+		if target.Scheme != "nvmf" {
+			return nil, fmt.Errorf("replica on %s is not nvmf shared, URI %q", replica.Node, replica.Uri)
+		}
+		nqn := strings.TrimPrefix(target.Path, "/")
+		logmerge.RecordAction("checksumming replica of %s on %s", uuid, replica.Node)
+		sum, err := checksumReplicaTarget(replica.Node, target.Hostname(), target.Port(), nqn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to checksum replica of %s on %s, error %v", uuid, replica.Node, err)
+		}
+		checksums = append(checksums, ReplicaChecksum{Node: replica.Node, Uri: replica.Uri, Checksum: sum})
+	}
+	return checksums, nil
+}
+
+// CheckReplicasMatch checksums all replicas of the volume and returns an
+// error describing the divergence if they are not identical.
+func CheckReplicasMatch(uuid string) error {
+	checksums, err := ChecksumReplicas(uuid)
+	if err != nil {
+		return err
+	}
+	for _, replica := range checksums[1:] {
+		if replica.Checksum != checksums[0].Checksum {
+			var report []string
+			for _, r := range checksums {
+				report = append(report, fmt.Sprintf("%s=%s", r.Node, r.Checksum))
+			}
+			return fmt.Errorf("replicas of %s diverge: %s", uuid, strings.Join(report, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// NodeRolloutStatus records how long one node took to run a ready
+// daemonset pod during an install rollout.
+type NodeRolloutStatus struct {
+	Node string `json:"node"`
+	// Ready is false if the node never became ready within the budget.
+	Ready     bool `json:"ready"`
+	ReadySecs int  `json:"readySecs"`
+}
+
+// podReadyOnNode returns the nodes with a ready pod matching the
+// selector.
+func readyNodes(nameSpace string, labelSelector string) (map[string]bool, error) {
+	pods, err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).List(context.TODO(), metaV1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods for selector %s, error %v", labelSelector, err)
+	}
+	nodes := map[string]bool{}
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == coreV1.PodReady && condition.Status == coreV1.ConditionTrue {
+				nodes[pod.Spec.NodeName] = true
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// WaitDaemonSetRollout tracks the rollout of the daemonset pods
+// matching labelSelector across the given nodes, with per-node timing
+// rather than a single readiness count, so installs on large clusters
+// produce useful progress output and failures name the offending
+// nodes. The configured percentile of nodes must be ready within the
+// percentile timeout and every node within the maximum timeout. The
+// per-node results, slowest first, are recorded in the run manifest.
+func WaitDaemonSetRollout(nameSpace string, labelSelector string, nodeNames []string) ([]NodeRolloutStatus, error) {
+	cfg := e2e_config.GetConfig().InstallRollout
+	start := time.Now()
+	const sleepTime = 5
+	const progressEvery = 30 * time.Second
+	lastProgress := start
+
+	readyAt := map[string]time.Duration{}
+	percentileDeadline := start.Add(time.Duration(cfg.PercentileTimeoutSecs) * time.Second)
+	maxDeadline := start.Add(time.Duration(cfg.MaxTimeoutSecs) * time.Second)
+	percentileCount := (len(nodeNames)*cfg.PercentileNodes + 99) / 100
+
+	for {
+		ready, err := readyNodes(nameSpace, labelSelector)
+		if err != nil {
+			return nil, err
+		}
+		var waitingOn []string
+		for _, nodeName := range nodeNames {
+			if ready[nodeName] {
+				if _, ok := readyAt[nodeName]; !ok {
+					readyAt[nodeName] = time.Since(start)
+				}
+			} else {
+				waitingOn = append(waitingOn, nodeName)
+			}
+		}
+		if len(waitingOn) == 0 {
+			break
+		}
+		now := time.Now()
+		if now.Sub(lastProgress) >= progressEvery {
+			lastProgress = now
+			logf.Log.Info("daemonset rollout progress",
+				"selector", labelSelector,
+				"ready", fmt.Sprintf("%d/%d", len(readyAt), len(nodeNames)),
+				"waitingOn", strings.Join(waitingOn, ","))
+		}
+		if len(readyAt) < percentileCount && now.After(percentileDeadline) {
+			return rolloutResults(nodeNames, readyAt), fmt.Errorf(
+				"only %d of %d nodes ready within the %d%% rollout budget of %ds, waiting on: %s",
+				len(readyAt), len(nodeNames), cfg.PercentileNodes, cfg.PercentileTimeoutSecs,
+				strings.Join(waitingOn, ", "))
+		}
+		if now.After(maxDeadline) {
+			return rolloutResults(nodeNames, readyAt), fmt.Errorf(
+				"rollout incomplete after the maximum budget of %ds, waiting on: %s",
+				cfg.MaxTimeoutSecs, strings.Join(waitingOn, ", "))
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+
+	results := rolloutResults(nodeNames, readyAt)
+	slowest := results[0]
+	logf.Log.Info("daemonset rollout complete",
+		"selector", labelSelector, "nodes", len(nodeNames),
+		"slowestNode", slowest.Node, "slowestSecs", slowest.ReadySecs)
+	if err := manifest.AppendSection("installRollout", results); err != nil {
+		logf.Log.Info("failed to record rollout results in the run manifest", "error", err)
+	}
+	return results, nil
+}
+
+// rolloutResults assembles per-node statuses sorted slowest first;
+// nodes which never became ready sort before all others.
+func rolloutResults(nodeNames []string, readyAt map[string]time.Duration) []NodeRolloutStatus {
+	var results []NodeRolloutStatus
+	for _, nodeName := range nodeNames {
+		status := NodeRolloutStatus{Node: nodeName}
+		if elapsed, ok := readyAt[nodeName]; ok {
+			status.Ready = true
+			status.ReadySecs = int(elapsed.Seconds())
+		}
+		results = append(results, status)
+	}
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].Ready != results[b].Ready {
+			return !results[a].Ready
+		}
+		return results[a].ReadySecs > results[b].ReadySecs
+	})
+	return results
+}
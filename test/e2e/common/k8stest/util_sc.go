@@ -0,0 +1,122 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	coreV1 "k8s.io/api/core/v1"
+	storageV1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CSIProvisioner is the provisioner name advertised by the mayastor CSI
+// driver.
+const CSIProvisioner = "io.openebs.csi-mayastor"
+
+// MkStorageClass creates a mayastor storage class with the given replica
+// count and share protocol.
+func MkStorageClass(scName string, scReplicas int, protocol string) error {
+	return MkStorageClassWithParams(scName, scReplicas, protocol, map[string]string{})
+}
+
+// MkStorageClassWithParams creates a mayastor storage class with the given
+// replica count and share protocol, and any additional storage class
+// parameters supplied by the caller.
+func MkStorageClassWithParams(scName string, scReplicas int, protocol string, params map[string]string) error {
+	return mkStorageClass(scName, scReplicas, protocol, params, coreV1.PersistentVolumeReclaimDelete, false, false, nil, nil)
+}
+
+// MkStorageClassWithReclaimPolicy creates a mayastor storage class with
+// the given replica count, share protocol and reclaim policy.
+func MkStorageClassWithReclaimPolicy(scName string, scReplicas int, protocol string, reclaimPolicy coreV1.PersistentVolumeReclaimPolicy) error {
+	return mkStorageClass(scName, scReplicas, protocol, map[string]string{}, reclaimPolicy, false, false, nil, nil)
+}
+
+// MkExpandableStorageClass creates a mayastor storage class which allows
+// volume expansion.
+func MkExpandableStorageClass(scName string, scReplicas int, protocol string) error {
+	return mkStorageClass(scName, scReplicas, protocol, map[string]string{}, coreV1.PersistentVolumeReclaimDelete, true, false, nil, nil)
+}
+
+// MkStorageClassWithTopology creates a mayastor storage class restricted
+// to the given allowed topologies, with WaitForFirstConsumer binding so
+// the restriction is honoured at provisioning time.
+func MkStorageClassWithTopology(scName string, scReplicas int, protocol string, topologies []coreV1.TopologySelectorTerm) error {
+	return mkStorageClass(scName, scReplicas, protocol, map[string]string{}, coreV1.PersistentVolumeReclaimDelete, false, false, topologies, nil)
+}
+
+// defaultScAnnotation marks a storage class as the cluster default.
+const defaultScAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// MkDefaultStorageClass creates a mayastor storage class annotated as
+// the cluster default, so PVCs which omit storageClassName bind to it.
+func MkDefaultStorageClass(scName string, scReplicas int, protocol string) error {
+	if err := MkStorageClass(scName, scReplicas, protocol); err != nil {
+		return err
+	}
+	return SetStorageClassDefault(scName, true)
+}
+
+// SetStorageClassDefault sets or clears the default class annotation on
+// the given storage class.
+func SetStorageClassDefault(scName string, isDefault bool) error {
+	sc, err := gTestEnv.KubeInt.StorageV1().StorageClasses().Get(context.TODO(), scName, metaV1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get storage class %s, error %v", scName, err)
+	}
+	if sc.Annotations == nil {
+		sc.Annotations = map[string]string{}
+	}
+	sc.Annotations[defaultScAnnotation] = strconv.FormatBool(isDefault)
+	if _, err := gTestEnv.KubeInt.StorageV1().StorageClasses().Update(context.TODO(), sc, metaV1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update storage class %s, error %v", scName, err)
+	}
+	return nil
+}
+
+func mkStorageClass(scName string, scReplicas int, protocol string, params map[string]string, reclaimPolicy coreV1.PersistentVolumeReclaimPolicy, allowExpansion bool, lateBinding bool, topologies []coreV1.TopologySelectorTerm, mountOptions []string) error {
+	scParams := map[string]string{
+		"repl":     strconv.Itoa(scReplicas),
+		"protocol": protocol,
+	}
+	for key, val := range params {
+		scParams[key] = val
+	}
+	createOpts := &storageV1.StorageClass{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: scName,
+		},
+		Provisioner:          CSIProvisioner,
+		Parameters:           scParams,
+		ReclaimPolicy:        &reclaimPolicy,
+		AllowVolumeExpansion: &allowExpansion,
+		MountOptions:         mountOptions,
+	}
+	// Allowed topologies are only evaluated when binding waits for a
+	// consumer; with immediate binding they would be ignored.
+	if lateBinding || topologies != nil {
+		createOpts.AllowedTopologies = topologies
+		bindingMode := storageV1.VolumeBindingWaitForFirstConsumer
+		createOpts.VolumeBindingMode = &bindingMode
+	}
+	_, err := gTestEnv.KubeInt.StorageV1().StorageClasses().Create(context.TODO(), createOpts, metaV1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create storage class %s, error %v", scName, err)
+	}
+	trackResource("storageclass", scName, "")
+	return nil
+}
+
+// RmStorageClass deletes the given storage class.
+func RmStorageClass(scName string) error {
+	err := gTestEnv.KubeInt.StorageV1().StorageClasses().Delete(context.TODO(), scName, metaV1.DeleteOptions{})
+	if err == nil || k8serrors.IsNotFound(err) {
+		untrackResource("storageclass", scName, "")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete storage class %s, error %v", scName, err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package k8stest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openebs/mayastor/test/e2e/common/restclient"
+	"github.com/openebs/mayastor/test/e2e/common/timeouts"
+)
+
+// rebuildSampleSecs is the interval between the two samples the
+// rebuild progress helper takes to estimate throughput.
+const rebuildSampleSecs = 2
+
+// rebuildMinTimeoutSecs is the floor of the rebuild time budget, so
+// small volumes still get a workable window.
+const rebuildMinTimeoutSecs = 60
+
+// ScaleVolume sets the replica count of a volume, through the control
+// plane REST scale endpoint when one is served and by patching the
+// custom resource spec when the endpoint is absent or the control
+// plane is unreachable.
+func ScaleVolume(uuid string, replicaCount int) error {
+	if replicaCount < 1 {
+		return fmt.Errorf("invalid replica count %d for volume %s", replicaCount, uuid)
+	}
+	client, stop, err := ControlPlaneRestClient()
+	if err != nil {
+		return UpdateNumReplicas(uuid, replicaCount)
+	}
+	defer stop()
+	err = client.ScaleVolume(uuid, replicaCount)
+	if errors.Is(err, restclient.ErrNotSupported) {
+		return UpdateNumReplicas(uuid, replicaCount)
+	}
+	return err
+}
+
+// RebuildProgress is the rebuild state of one nexus child.
+type RebuildProgress struct {
+	Child string
+	// Percent is the rebuild percentage; 100 for an online child.
+	Percent int
+	// BytesPerSec is the copy throughput estimated from the progress
+	// delta between two samples; zero for a child not rebuilding.
+	BytesPerSec int64
+}
+
+// rebuildSample reads the rebuild percentage per nexus child from the
+// volume custom resource: 100 for an online child, the reported
+// rebuildProgress for a degraded one, 0 when the engine does not
+// report progress.
+func rebuildSample(uuid string) (map[string]int, int64, error) {
+	msv, err := GetMSV(uuid)
+	if err != nil {
+		return nil, 0, err
+	}
+	size := intFromUnstructured(msv.Object, "status", "size")
+	if size == 0 {
+		size = intFromUnstructured(msv.Object, "spec", "requiredBytes")
+	}
+	children, _, err := unstructured.NestedSlice(msv.Object, "status", "nexus", "children")
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed nexus children on MSV %s, error %v", uuid, err)
+	}
+	percents := map[string]int{}
+	for _, rawChild := range children {
+		child, ok := rawChild.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		uri, _ := child["uri"].(string)
+		state, _ := child["state"].(string)
+		if !childRebuilding(state) {
+			percents[uri] = 100
+			continue
+		}
+		// the dynamic client decodes integers as int64, but decoded
+		// JSON fixtures carry float64
+		switch progress := child["rebuildProgress"].(type) {
+		case int64:
+			percents[uri] = int(progress)
+		case float64:
+			percents[uri] = int(progress)
+		default:
+			percents[uri] = 0
+		}
+	}
+	return percents, size, nil
+}
+
+// GetRebuildProgress returns the rebuild percentage and estimated copy
+// throughput per nexus child of a volume. Throughput is derived from
+// two samples, so the call blocks for the sampling interval whenever a
+// rebuild is in progress.
+func GetRebuildProgress(uuid string) ([]RebuildProgress, error) {
+	before, size, err := rebuildSample(uuid)
+	if err != nil {
+		return nil, err
+	}
+	rebuilding := false
+	for _, percent := range before {
+		if percent < 100 {
+			rebuilding = true
+			break
+		}
+	}
+	after := before
+	if rebuilding {
+		time.Sleep(rebuildSampleSecs * time.Second)
+		after, _, err = rebuildSample(uuid)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var progress []RebuildProgress
+	for child, percent := range after {
+		entry := RebuildProgress{Child: child, Percent: percent}
+		if delta := percent - before[child]; delta > 0 {
+			entry.BytesPerSec = size * int64(delta) / 100 / rebuildSampleSecs
+		}
+		progress = append(progress, entry)
+	}
+	return progress, nil
+}
+
+// RebuildTimeoutSecs returns the rebuild time budget for a volume of
+// the given size, from the configured allowance per GiB, floored for
+// small volumes.
+func RebuildTimeoutSecs(sizeBytes int64) int {
+	const gib = 1024 * 1024 * 1024
+	secs := int((sizeBytes*int64(timeouts.RebuildSecsPerGib()) + gib - 1) / gib)
+	if secs < rebuildMinTimeoutSecs {
+		secs = rebuildMinTimeoutSecs
+	}
+	return secs
+}
+
+// WaitMsvRebuilt waits for all nexus children of a volume to be back
+// at 100 percent within the size-derived rebuild budget. The failure
+// message quotes the last observed progress.
+func WaitMsvRebuilt(uuid string) error {
+	const sleepTime = 5
+	msv, err := GetMsv(uuid)
+	if err != nil {
+		return err
+	}
+	size := msv.Status.Size
+	if size == 0 {
+		size = msv.Spec.RequiredBytes
+	}
+	timeoutSecs := RebuildTimeoutSecs(size)
+	var lagging []RebuildProgress
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		percents, _, err := rebuildSample(uuid)
+		if err != nil {
+			return err
+		}
+		lagging = nil
+		for child, percent := range percents {
+			if percent < 100 {
+				lagging = append(lagging, RebuildProgress{Child: child, Percent: percent})
+			}
+		}
+		if len(lagging) == 0 {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("volume %s not rebuilt within %d seconds, lagging children %v", uuid, timeoutSecs, lagging)
+}
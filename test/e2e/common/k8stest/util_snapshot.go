@@ -0,0 +1,171 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Volume snapshot helpers, built on the snapshot.storage.k8s.io API
+// served by the external snapshotter. The API graduated from v1beta1
+// to v1 and both flavours remain in the field, so the served version
+// is discovered once and all snapshot helpers route through it. The
+// CSI driver must advertise snapshot support for these to succeed
+// against a real cluster.
+
+const snapshotGroup = "snapshot.storage.k8s.io"
+
+var snapshotApiMu sync.Mutex
+var snapshotVersionDetected string
+
+// snapshotApiVersion returns the snapshot API version served by the
+// cluster, preferring v1 and falling back to v1beta1; v1 is assumed
+// when discovery finds neither, e.g. against the fake test
+// environment. The result is cached.
+func snapshotApiVersion() string {
+	snapshotApiMu.Lock()
+	defer snapshotApiMu.Unlock()
+	if snapshotVersionDetected != "" {
+		return snapshotVersionDetected
+	}
+	snapshotVersionDetected = "v1"
+	for _, version := range []string{"v1", "v1beta1"} {
+		if ApiServed(schema.GroupVersionResource{Group: snapshotGroup, Version: version, Resource: "volumesnapshots"}) {
+			snapshotVersionDetected = version
+			logf.Log.Info("discovered snapshot API version",
+				"groupVersion", snapshotGroup+"/"+version)
+			break
+		}
+	}
+	return snapshotVersionDetected
+}
+
+// ResetSnapshotApiCache discards the cached snapshot API version so the
+// next snapshot operation rediscovers it; of use when tests mutate the
+// fake discovery data.
+func ResetSnapshotApiCache() {
+	snapshotApiMu.Lock()
+	defer snapshotApiMu.Unlock()
+	snapshotVersionDetected = ""
+}
+
+// SnapshotApiVersion returns the apiVersion of the snapshot API in use,
+// e.g. "snapshot.storage.k8s.io/v1".
+func SnapshotApiVersion() string {
+	return snapshotGroup + "/" + snapshotApiVersion()
+}
+
+func snapClassGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: snapshotGroup, Version: snapshotApiVersion(), Resource: "volumesnapshotclasses"}
+}
+
+func snapGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: snapshotGroup, Version: snapshotApiVersion(), Resource: "volumesnapshots"}
+}
+
+// MkSnapshotClass creates a volume snapshot class for the mayastor CSI
+// driver with the Delete deletion policy.
+func MkSnapshotClass(className string) error {
+	snapClass := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": SnapshotApiVersion(),
+			"kind":       "VolumeSnapshotClass",
+			"metadata": map[string]interface{}{
+				"name": className,
+			},
+			"driver":         CSIProvisioner,
+			"deletionPolicy": "Delete",
+		},
+	}
+	_, err := gTestEnv.DynamicClient.Resource(snapClassGVR()).Create(context.TODO(), snapClass, metaV1.CreateOptions{})
+	return err
+}
+
+// RmSnapshotClass deletes a volume snapshot class by name.
+func RmSnapshotClass(className string) error {
+	return gTestEnv.DynamicClient.Resource(snapClassGVR()).Delete(context.TODO(), className, metaV1.DeleteOptions{})
+}
+
+// MkSnapshot creates a volume snapshot of the given PVC.
+func MkSnapshot(snapName string, className string, volName string, nameSpace string) error {
+	nameSpace = resolveNamespace(nameSpace)
+	snapshot := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": SnapshotApiVersion(),
+			"kind":       "VolumeSnapshot",
+			"metadata": map[string]interface{}{
+				"name":      snapName,
+				"namespace": nameSpace,
+			},
+			"spec": map[string]interface{}{
+				"volumeSnapshotClassName": className,
+				"source": map[string]interface{}{
+					"persistentVolumeClaimName": volName,
+				},
+			},
+		},
+	}
+	_, err := gTestEnv.DynamicClient.Resource(snapGVR()).Namespace(nameSpace).Create(context.TODO(), snapshot, metaV1.CreateOptions{})
+	return err
+}
+
+// RmSnapshot deletes a volume snapshot by name.
+func RmSnapshot(snapName string, nameSpace string) error {
+	nameSpace = resolveNamespace(nameSpace)
+	return gTestEnv.DynamicClient.Resource(snapGVR()).Namespace(nameSpace).Delete(context.TODO(), snapName, metaV1.DeleteOptions{})
+}
+
+// snapListWatch selects a single volume snapshot for watching.
+func snapListWatch(nameSpace string, snapName string) cache.ListerWatcher {
+	nameSpace = resolveNamespace(nameSpace)
+	selector := fields.OneTermEqualSelector("metadata.name", snapName).String()
+	return &cache.ListWatch{
+		ListFunc: func(opts metaV1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = selector
+			return gTestEnv.DynamicClient.Resource(snapGVR()).Namespace(nameSpace).List(context.TODO(), opts)
+		},
+		WatchFunc: func(opts metaV1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = selector
+			return gTestEnv.DynamicClient.Resource(snapGVR()).Namespace(nameSpace).Watch(context.TODO(), opts)
+		},
+	}
+}
+
+// WaitSnapshotReady waits for up to timeoutSecs for the snapshot to
+// report readyToUse, reacting to watch events rather than polling.
+func WaitSnapshotReady(snapName string, nameSpace string, timeoutSecs int) error {
+	_, err := WaitFor(fmt.Sprintf("snapshot %s ready", snapName),
+		snapListWatch(nameSpace, snapName), &unstructured.Unstructured{},
+		func(obj runtime.Object) (bool, error) {
+			raw, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return false, nil
+			}
+			ready, _, _ := unstructured.NestedBool(raw.Object, "status", "readyToUse")
+			return ready, nil
+		}, timeoutSecs)
+	return err
+}
+
+// RestorePVCFromSnapshot creates a PVC sourced from the given snapshot
+// and returns the create response from the API server. The requested
+// size must be at least the size of the snapshotted volume.
+func RestorePVCFromSnapshot(volName string, snapName string, scName string, volSizeMb int, nameSpace string) (*coreV1.PersistentVolumeClaim, error) {
+	apiGroup := snapshotGroup
+	return mkPVC(volName, volSizeMb, scName, nameSpace, coreV1.PersistentVolumeFilesystem,
+		&coreV1.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     "VolumeSnapshot",
+			Name:     snapName,
+		})
+}
@@ -0,0 +1,129 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// stsClaimName names the volume claim template used by MkStatefulSet;
+// kubernetes derives the per-replica PVC names from it.
+const stsClaimName = "data"
+
+// StatefulSetPvcName returns the name of the PVC backing the replica
+// with the given ordinal of a statefulset created by MkStatefulSet.
+func StatefulSetPvcName(stsName string, ordinal int) string {
+	return fmt.Sprintf("%s-%s-%d", stsClaimName, stsName, ordinal)
+}
+
+// MkStatefulSet creates a statefulset of pods running the configured
+// fio image, each replica backed by its own volume claimed from the
+// given storage class through a volume claim template. The pods sleep
+// until a workload is run with ExecPod, like the fio pods.
+func MkStatefulSet(stsName string, replicas int32, volSizeMb int, scName string, nameSpace string) (*appsV1.StatefulSet, error) {
+	nameSpace = resolveNamespace(nameSpace)
+	labels := map[string]string{"e2e-app": stsName}
+	createOpts := &appsV1.StatefulSet{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      stsName,
+			Namespace: nameSpace,
+		},
+		Spec: appsV1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: stsName,
+			Selector:    &metaV1.LabelSelector{MatchLabels: labels},
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: labels},
+				Spec: coreV1.PodSpec{
+					Containers: []coreV1.Container{
+						{
+							Name:    "app",
+							Image:   e2e_config.GetConfig().E2eFioImage,
+							Command: []string{"sleep", "1000000"},
+							VolumeMounts: []coreV1.VolumeMount{
+								{
+									Name:      stsClaimName,
+									MountPath: "/volume",
+								},
+							},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: []coreV1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metaV1.ObjectMeta{Name: stsClaimName},
+					Spec: coreV1.PersistentVolumeClaimSpec{
+						StorageClassName: &scName,
+						AccessModes:      []coreV1.PersistentVolumeAccessMode{coreV1.ReadWriteOnce},
+						Resources: coreV1.ResourceRequirements{
+							Requests: coreV1.ResourceList{
+								coreV1.ResourceStorage: resource.MustParse(fmt.Sprintf("%dM", volSizeMb)),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	sts, err := gTestEnv.KubeInt.AppsV1().StatefulSets(nameSpace).Create(context.TODO(), createOpts, metaV1.CreateOptions{})
+	if err == nil {
+		trackResource("statefulset", stsName, nameSpace)
+	}
+	return sts, err
+}
+
+// RmStatefulSet deletes the statefulset. The PVCs claimed through its
+// template survive the set - kubernetes never deletes them with it -
+// and must be removed separately.
+func RmStatefulSet(stsName string, nameSpace string) error {
+	nameSpace = resolveNamespace(nameSpace)
+	err := gTestEnv.KubeInt.AppsV1().StatefulSets(nameSpace).Delete(context.TODO(), stsName, metaV1.DeleteOptions{})
+	if err == nil || k8serrors.IsNotFound(err) {
+		untrackResource("statefulset", stsName, nameSpace)
+	}
+	return err
+}
+
+// GetStatefulSet retrieves a statefulset by name.
+func GetStatefulSet(stsName string, nameSpace string) (*appsV1.StatefulSet, error) {
+	return gTestEnv.KubeInt.AppsV1().StatefulSets(nameSpace).Get(context.TODO(), stsName, metaV1.GetOptions{})
+}
+
+// ScaleStatefulSet sets the replica count of the given statefulset.
+func ScaleStatefulSet(stsName string, nameSpace string, replicas int32) error {
+	sts, err := GetStatefulSet(stsName, nameSpace)
+	if err != nil {
+		return fmt.Errorf("failed to get statefulset %s, error %v", stsName, err)
+	}
+	sts.Spec.Replicas = &replicas
+	_, err = gTestEnv.KubeInt.AppsV1().StatefulSets(nameSpace).Update(context.TODO(), sts, metaV1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to scale statefulset %s to %d, error %v", stsName, replicas, err)
+	}
+	return nil
+}
+
+// WaitStatefulSetReady waits for up to timeoutSecs for all replicas of
+// the statefulset to be ready.
+func WaitStatefulSetReady(stsName string, nameSpace string, timeoutSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		sts, err := GetStatefulSet(stsName, nameSpace)
+		if err == nil && sts.Spec.Replicas != nil &&
+			sts.Status.ReadyReplicas == *sts.Spec.Replicas &&
+			sts.Status.ObservedGeneration >= sts.Generation {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("statefulset %s not ready after %d seconds", stsName, timeoutSecs)
+}
@@ -0,0 +1,88 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateStaticPv creates a PV pointing at an existing mayastor volume,
+// identified by its volume handle (the MSV uuid), with reclaim policy
+// Retain so deleting the PV leaves the underlying replica data alone.
+// Used for the static provisioning / volume adoption workflow.
+func CreateStaticPv(pvName string, volumeHandle string, volSizeMb int, scName string, protocol string) (*coreV1.PersistentVolume, error) {
+	volSize := fmt.Sprintf("%dM", volSizeMb)
+	pv := &coreV1.PersistentVolume{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: pvName,
+		},
+		Spec: coreV1.PersistentVolumeSpec{
+			AccessModes: []coreV1.PersistentVolumeAccessMode{coreV1.ReadWriteOnce},
+			Capacity: coreV1.ResourceList{
+				coreV1.ResourceStorage: resource.MustParse(volSize),
+			},
+			PersistentVolumeReclaimPolicy: coreV1.PersistentVolumeReclaimRetain,
+			StorageClassName:              scName,
+			PersistentVolumeSource: coreV1.PersistentVolumeSource{
+				CSI: &coreV1.CSIPersistentVolumeSource{
+					Driver:       CSIProvisioner,
+					VolumeHandle: volumeHandle,
+					VolumeAttributes: map[string]string{
+						"protocol": protocol,
+					},
+				},
+			},
+		},
+	}
+	return gTestEnv.KubeInt.CoreV1().PersistentVolumes().Create(context.TODO(), pv, metaV1.CreateOptions{})
+}
+
+// CreateStaticPvc creates a PVC bound to the given pre-provisioned PV.
+func CreateStaticPvc(volName string, pvName string, volSizeMb int, scName string, nameSpace string) (*coreV1.PersistentVolumeClaim, error) {
+	volSize := fmt.Sprintf("%dM", volSizeMb)
+	pvc := &coreV1.PersistentVolumeClaim{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      volName,
+			Namespace: nameSpace,
+		},
+		Spec: coreV1.PersistentVolumeClaimSpec{
+			StorageClassName: &scName,
+			VolumeName:       pvName,
+			AccessModes:      []coreV1.PersistentVolumeAccessMode{coreV1.ReadWriteOnce},
+			Resources: coreV1.ResourceRequirements{
+				Requests: coreV1.ResourceList{
+					coreV1.ResourceStorage: resource.MustParse(volSize),
+				},
+			},
+		},
+	}
+	return gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).Create(context.TODO(), pvc, metaV1.CreateOptions{})
+}
+
+// GetPV retrieves a PV by name.
+func GetPV(pvName string) (*coreV1.PersistentVolume, error) {
+	return gTestEnv.KubeInt.CoreV1().PersistentVolumes().Get(context.TODO(), pvName, metaV1.GetOptions{})
+}
+
+// DeletePV deletes a PV by name.
+func DeletePV(pvName string) error {
+	return gTestEnv.KubeInt.CoreV1().PersistentVolumes().Delete(context.TODO(), pvName, metaV1.DeleteOptions{})
+}
+
+// WaitPvPhase waits for up to timeoutSecs for the PV to reach the given
+// phase.
+func WaitPvPhase(pvName string, phase coreV1.PersistentVolumePhase, timeoutSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		pv, err := GetPV(pvName)
+		if err == nil && pv.Status.Phase == phase {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("PV %s did not reach phase %s after %d seconds", pvName, phase, timeoutSecs)
+}
@@ -0,0 +1,121 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// StuckPodOutcome records how one stuck terminating pod was handled.
+// Outcomes are appended to the run manifest so teardown hangs can be
+// tracked across CI runs.
+type StuckPodOutcome struct {
+	PodName   string `json:"podName"`
+	Namespace string `json:"namespace"`
+	// Cause is the diagnosed reason the pod would not terminate.
+	Cause string `json:"cause"`
+	// ForceDeleted is set if the pod was removed with grace period 0.
+	ForceDeleted bool `json:"forceDeleted"`
+	// Resolved is set if the pod is gone, by whatever means.
+	Resolved bool `json:"resolved"`
+}
+
+// diagnoseStuckPod works out why a terminating pod has not gone away:
+// pending finalizers, an unreachable node, or a volume teardown hang
+// where the containers have exited but the pod object remains.
+func diagnoseStuckPod(pod *coreV1.Pod) string {
+	if len(pod.Finalizers) != 0 {
+		return fmt.Sprintf("pending finalizers: %s", strings.Join(pod.Finalizers, ", "))
+	}
+	if pod.Spec.NodeName != "" {
+		ready, err := IsNodeReady(pod.Spec.NodeName)
+		if err == nil && !ready {
+			return fmt.Sprintf("node %s not ready", pod.Spec.NodeName)
+		}
+	}
+	containersDone := true
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated == nil {
+			containersDone = false
+			break
+		}
+	}
+	if containersDone && len(pod.Status.ContainerStatuses) != 0 {
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil {
+				return fmt.Sprintf("containers exited, likely volume teardown hang (claim %s)",
+					vol.PersistentVolumeClaim.ClaimName)
+			}
+		}
+		return "containers exited but pod object remains"
+	}
+	return "containers still running past grace period"
+}
+
+// DeleteStuckTerminatingPods waits up to timeoutSecs for all
+// terminating pods in the namespace to go away, diagnoses any that
+// remain, and — only if enabled by the forceDeleteStuckPods
+// configuration — escalates to force deletion. It returns a per-pod
+// outcome report, which is also appended to the run manifest. A pod
+// that survives even force deletion is reported as unresolved rather
+// than an error; the error return is reserved for API failures.
+func DeleteStuckTerminatingPods(nameSpace string, timeoutSecs int) ([]StuckPodOutcome, error) {
+	const sleepTime = 5
+	deadline := time.Now().Add(time.Duration(timeoutSecs) * time.Second)
+	var terminating []coreV1.Pod
+	for {
+		pods, err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).List(context.TODO(), metaV1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods in %s, error %v", nameSpace, err)
+		}
+		terminating = nil
+		for _, pod := range pods.Items {
+			if pod.DeletionTimestamp != nil {
+				terminating = append(terminating, pod)
+			}
+		}
+		if len(terminating) == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	if len(terminating) == 0 {
+		return nil, nil
+	}
+
+	forceAllowed := e2e_config.GetConfig().ForceDeleteStuckPods
+	var outcomes []StuckPodOutcome
+	for ix := range terminating {
+		pod := &terminating[ix]
+		outcome := StuckPodOutcome{
+			PodName:   pod.Name,
+			Namespace: nameSpace,
+			Cause:     diagnoseStuckPod(pod),
+		}
+		logf.Log.Info("pod stuck terminating", "pod", pod.Name, "cause", outcome.Cause, "forceDelete", forceAllowed)
+		if forceAllowed {
+			gracePeriod := int64(0)
+			err := gTestEnv.KubeInt.CoreV1().Pods(nameSpace).Delete(context.TODO(), pod.Name,
+				metaV1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+			if err != nil {
+				return outcomes, fmt.Errorf("failed to force delete pod %s, error %v", pod.Name, err)
+			}
+			outcome.ForceDeleted = true
+		}
+		_, err := GetPod(pod.Name, nameSpace)
+		outcome.Resolved = err != nil
+		outcomes = append(outcomes, outcome)
+	}
+	if err := manifest.AppendSection("stuckPods", outcomes); err != nil {
+		logf.Log.Info("failed to record stuck pod outcomes in the run manifest", "error", err)
+	}
+	return outcomes, nil
+}
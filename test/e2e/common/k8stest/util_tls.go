@@ -0,0 +1,114 @@
+package k8stest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GenerateSelfSignedCert generates a self-signed serving certificate
+// for the given common name and DNS names, returning PEM encoded
+// certificate and key. Used by certificate rotation tests; not suitable
+// for anything beyond test clusters.
+func GenerateSelfSignedCert(commonName string, dnsNames []string, validDays int) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key, error %v", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serial number, error %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              dnsNames,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Duration(validDays) * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate, error %v", err)
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPem, keyPem, nil
+}
+
+// ListTlsSecrets returns the names of the kubernetes.io/tls secrets in
+// the given namespace.
+func ListTlsSecrets(nameSpace string) ([]string, error) {
+	secrets, err := gTestEnv.KubeInt.CoreV1().Secrets(nameSpace).List(context.TODO(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets in %s, error %v", nameSpace, err)
+	}
+	var names []string
+	for _, secret := range secrets.Items {
+		if secret.Type == coreV1.SecretTypeTLS {
+			names = append(names, secret.Name)
+		}
+	}
+	return names, nil
+}
+
+// TlsSecretFingerprint returns the sha256 fingerprint of the
+// certificate held in the given kubernetes.io/tls secret, so rotations
+// can be detected.
+func TlsSecretFingerprint(secretName string, nameSpace string) (string, error) {
+	secret, err := gTestEnv.KubeInt.CoreV1().Secrets(nameSpace).Get(context.TODO(), secretName, metaV1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s, error %v", secretName, err)
+	}
+	cert, ok := secret.Data[coreV1.TLSCertKey]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no %s entry", secretName, coreV1.TLSCertKey)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(cert)), nil
+}
+
+// RotateTlsSecret replaces the certificate and key in the given
+// kubernetes.io/tls secret with a freshly generated self-signed pair
+// for the same common name and DNS names, simulating a certificate
+// rotation by an external issuer.
+func RotateTlsSecret(secretName string, nameSpace string) error {
+	secret, err := gTestEnv.KubeInt.CoreV1().Secrets(nameSpace).Get(context.TODO(), secretName, metaV1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s, error %v", secretName, err)
+	}
+	oldCert, ok := secret.Data[coreV1.TLSCertKey]
+	if !ok {
+		return fmt.Errorf("secret %s has no %s entry", secretName, coreV1.TLSCertKey)
+	}
+	block, _ := pem.Decode(oldCert)
+	if block == nil {
+		return fmt.Errorf("secret %s holds an unparseable certificate", secretName)
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate of secret %s, error %v", secretName, err)
+	}
+	certPem, keyPem, err := GenerateSelfSignedCert(parsed.Subject.CommonName, parsed.DNSNames, 30)
+	if err != nil {
+		return err
+	}
+	secret.Data[coreV1.TLSCertKey] = certPem
+	secret.Data[coreV1.TLSPrivateKeyKey] = keyPem
+	if _, err := gTestEnv.KubeInt.CoreV1().Secrets(nameSpace).Update(context.TODO(), secret, metaV1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update secret %s, error %v", secretName, err)
+	}
+	return nil
+}
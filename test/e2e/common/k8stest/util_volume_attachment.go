@@ -0,0 +1,73 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	storageV1 "k8s.io/api/storage/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeAttachmentsOfPv returns the volumeattachments referencing the
+// given persistent volume. With ReadWriteOnce access there must never be
+// more than one at a time.
+func VolumeAttachmentsOfPv(pvName string) ([]storageV1.VolumeAttachment, error) {
+	attachments, err := gTestEnv.KubeInt.StorageV1().VolumeAttachments().List(context.TODO(), metaV1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumeattachments, error %v", err)
+	}
+	var matched []storageV1.VolumeAttachment
+	for _, attachment := range attachments.Items {
+		if attachment.Spec.Source.PersistentVolumeName != nil &&
+			*attachment.Spec.Source.PersistentVolumeName == pvName {
+			matched = append(matched, attachment)
+		}
+	}
+	return matched, nil
+}
+
+// WaitVolumeAttached waits for up to timeoutSecs for the persistent
+// volume to be reported attached on the given node.
+func WaitVolumeAttached(pvName string, nodeName string, timeoutSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		attachments, err := VolumeAttachmentsOfPv(pvName)
+		if err != nil {
+			return err
+		}
+		for _, attachment := range attachments {
+			if attachment.Spec.NodeName == nodeName && attachment.Status.Attached {
+				return nil
+			}
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("PV %s not attached on node %s after %d seconds", pvName, nodeName, timeoutSecs)
+}
+
+// WaitVolumeDetached waits for up to timeoutSecs for the
+// volumeattachment of the persistent volume on the given node to go
+// away; used to verify the attach/detach controller reclaims the
+// attachment of a dead node once its pods are removed.
+func WaitVolumeDetached(pvName string, nodeName string, timeoutSecs int) error {
+	const sleepTime = 2
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		attachments, err := VolumeAttachmentsOfPv(pvName)
+		if err != nil {
+			return err
+		}
+		attached := false
+		for _, attachment := range attachments {
+			if attachment.Spec.NodeName == nodeName {
+				attached = true
+				break
+			}
+		}
+		if !attached {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("PV %s still attached on node %s after %d seconds", pvName, nodeName, timeoutSecs)
+}
@@ -0,0 +1,72 @@
+package k8stest
+
+import (
+	"fmt"
+
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+)
+
+// CreateVolumes provisions count volumes named <prefix>-<index> from
+// the given storage class and waits until all are bound. Returns the
+// PVC names.
+func CreateVolumes(scName string, prefix string, count int, volSizeMb int, nameSpace string, timeoutSecs int) ([]string, error) {
+	var volNames []string
+	for ix := 0; ix < count; ix++ {
+		volName := fmt.Sprintf("%s-%d", prefix, ix)
+		if _, err := CreatePVC(volName, volSizeMb, scName, nameSpace); err != nil {
+			return volNames, fmt.Errorf("failed to create PVC %s, error %v", volName, err)
+		}
+		volNames = append(volNames, volName)
+	}
+	for _, volName := range volNames {
+		if err := WaitPvcBound(volName, nameSpace, timeoutSecs); err != nil {
+			return volNames, err
+		}
+	}
+	return volNames, nil
+}
+
+// FillVolume writes sizeMb of data to the volume through a transient
+// fio pod, so the backing replicas actually consume pool space rather
+// than just reserving it.
+func FillVolume(volName string, nameSpace string, sizeMb int) error {
+	podName := volName + "-fill"
+	logmerge.RecordAction("filling volume %s with %dMiB", volName, sizeMb)
+	if _, err := CreateFioPod(podName, volName, nameSpace); err != nil {
+		return fmt.Errorf("failed to create fill pod for %s, error %v", volName, err)
+	}
+	defer func() {
+		_ = DeletePod(podName, nameSpace)
+	}()
+	if err := WaitPodRunning(podName, nameSpace, defTimeoutSecs); err != nil {
+		return err
+	}
+	_, err := ExecPod(podName, nameSpace, "fio",
+		"--name=fill", "--rw=write", "--direct=1", "--ioengine=libaio",
+		"--bs=1m", "--iodepth=16", "--numjobs=1",
+		"--filename=/volume/fio-fill-file",
+		fmt.Sprintf("--size=%dm", sizeMb))
+	if err != nil {
+		return fmt.Errorf("failed to fill volume %s, error %v", volName, err)
+	}
+	return nil
+}
+
+// ChurnVolumes alternates provisioning and deleting batches of volumes
+// from the given storage class, the load generator shared by tests
+// which need pool state exercised rather than any particular volume,
+// e.g. accounting and placement tests.
+func ChurnVolumes(scName string, prefix string, cycles int, volumesPerCycle int, volSizeMb int, nameSpace string, timeoutSecs int) error {
+	for cycle := 0; cycle < cycles; cycle++ {
+		if _, err := CreateVolumes(scName, prefix, volumesPerCycle, volSizeMb, nameSpace, timeoutSecs); err != nil {
+			return fmt.Errorf("churn cycle %d provisioning failed, error %v", cycle+1, err)
+		}
+		for ix := 0; ix < volumesPerCycle; ix++ {
+			volName := fmt.Sprintf("%s-%d", prefix, ix)
+			if err := DeletePVC(volName, nameSpace); err != nil {
+				return fmt.Errorf("churn cycle %d deletion failed, error %v", cycle+1, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilYaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// manifestFieldManager identifies the test framework as the owner of
+// fields it applies server-side.
+const manifestFieldManager = "mayastor-e2e"
+
+// renderManifest substitutes template variables - {{.name}} - into the
+// manifest. Unknown variables are an error rather than silently
+// rendering empty strings, unlike the envsubst pipelines this replaces.
+func renderManifest(contents []byte, vars map[string]string) ([]byte, error) {
+	if vars == nil {
+		return contents, nil
+	}
+	tmpl, err := template.New("manifest").Option("missingkey=error").Parse(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest template, error %v", err)
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, vars); err != nil {
+		return nil, fmt.Errorf("failed to render manifest template, error %v", err)
+	}
+	return []byte(rendered.String()), nil
+}
+
+// LoadManifestFile reads a possibly multi-document yaml manifest,
+// substitutes the given template variables into it, and returns one
+// unstructured object per document. Empty documents are skipped.
+func LoadManifestFile(filename string, dir string, vars map[string]string) ([]*unstructured.Unstructured, error) {
+	contents, err := os.ReadFile(path.Join(dir, filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s, error %v", filename, err)
+	}
+	rendered, err := renderManifest(contents, vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	var objects []*unstructured.Unstructured
+	for _, doc := range strings.Split(string(rendered), "\n---") {
+		jsonContents, err := utilYaml.ToJSON([]byte(doc))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document in %s, error %v", filename, err)
+		}
+		if string(jsonContents) == "null" {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(jsonContents); err != nil {
+			return nil, fmt.Errorf("failed to parse document in %s, error %v", filename, err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// manifestResource returns the dynamic client interface for the given
+// object, resolving its kind against server discovery and scoping it to
+// the object namespace - the test namespace when unset - for namespaced
+// resources.
+func manifestResource(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	groupResources, err := restmapper.GetAPIGroupResources(gTestEnv.KubeInt.Discovery())
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API resources, error %v", err)
+	}
+	gvk := obj.GroupVersionKind()
+	mapping, err := restmapper.NewDiscoveryRESTMapper(groupResources).RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve resource for %s, error %v", gvk.String(), err)
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		nameSpace := obj.GetNamespace()
+		if nameSpace == "" {
+			nameSpace = TestNamespace()
+		}
+		return gTestEnv.DynamicClient.Resource(mapping.Resource).Namespace(nameSpace), nil
+	}
+	return gTestEnv.DynamicClient.Resource(mapping.Resource), nil
+}
+
+// applyManifestFile server-side applies every document in the manifest,
+// optionally as a dry run.
+func applyManifestFile(filename string, dir string, vars map[string]string, dryRun bool) error {
+	objects, err := LoadManifestFile(filename, dir, vars)
+	if err != nil {
+		return err
+	}
+	options := metaV1.PatchOptions{
+		FieldManager: manifestFieldManager,
+		// Take ownership of conflicting fields, as kubectl apply did.
+		Force: func(force bool) *bool { return &force }(true),
+	}
+	if dryRun {
+		options.DryRun = []string{metaV1.DryRunAll}
+	}
+	for _, obj := range objects {
+		resource, err := manifestResource(obj)
+		if err != nil {
+			return err
+		}
+		contents, err := obj.MarshalJSON()
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s %s, error %v", obj.GetKind(), obj.GetName(), err)
+		}
+		_, err = resource.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, contents, options)
+		if err != nil {
+			return fmt.Errorf("failed to apply %s %s from %s, error %v", obj.GetKind(), obj.GetName(), filename, err)
+		}
+	}
+	return nil
+}
+
+// ApplyManifestFile server-side applies every document in the manifest
+// file, substituting the given template variables first.
+func ApplyManifestFile(filename string, dir string, vars map[string]string) error {
+	return applyManifestFile(filename, dir, vars, false)
+}
+
+// DryRunManifestFile submits every document in the manifest file to the
+// API server as a dry run, so the manifest is validated by the server
+// without creating any resources.
+func DryRunManifestFile(filename string, dir string, vars map[string]string) error {
+	return applyManifestFile(filename, dir, vars, true)
+}
+
+// WaitManifestWorkloadsReady waits for every workload declared in the
+// manifest file - deployments, daemonsets and statefulsets - to finish
+// rolling out, each within timeoutSecs. Documents of other kinds are
+// ignored.
+func WaitManifestWorkloadsReady(filename string, dir string, vars map[string]string, timeoutSecs int) error {
+	objects, err := LoadManifestFile(filename, dir, vars)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		nameSpace := obj.GetNamespace()
+		if nameSpace == "" {
+			nameSpace = TestNamespace()
+		}
+		var err error
+		switch obj.GetKind() {
+		case "Deployment":
+			err = WaitDeploymentReady(obj.GetName(), nameSpace, timeoutSecs)
+		case "DaemonSet":
+			err = WaitDaemonSetReady(obj.GetName(), nameSpace, timeoutSecs)
+		case "StatefulSet":
+			err = WaitStatefulSetReady(obj.GetName(), nameSpace, timeoutSecs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteManifestFile deletes every resource declared in the manifest
+// file, in reverse document order so dependents go before their
+// dependencies; resources already gone are not an error.
+func DeleteManifestFile(filename string, dir string, vars map[string]string) error {
+	objects, err := LoadManifestFile(filename, dir, vars)
+	if err != nil {
+		return err
+	}
+	for ix := len(objects) - 1; ix >= 0; ix-- {
+		obj := objects[ix]
+		resource, err := manifestResource(obj)
+		if err != nil {
+			return err
+		}
+		err = resource.Delete(context.TODO(), obj.GetName(), metaV1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s %s from %s, error %v", obj.GetKind(), obj.GetName(), filename, err)
+		}
+	}
+	return nil
+}
+
+// yamlDoc is the subset of a manifest needed to identify the resource type.
+type yamlDoc struct {
+	ApiVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+}
+
+// removedApis maps "apiVersion/Kind" to the kubernetes minor version in
+// which the API was removed. Generated manifests using any of these fail
+// to install on clusters at or beyond that version.
+var removedApis = map[string]uint{
+	"extensions/v1beta1/DaemonSet":                                        16,
+	"extensions/v1beta1/Deployment":                                       16,
+	"apiextensions.k8s.io/v1beta1/CustomResourceDefinition":               22,
+	"rbac.authorization.k8s.io/v1beta1/ClusterRole":                       22,
+	"rbac.authorization.k8s.io/v1beta1/ClusterRoleBinding":                22,
+	"storage.k8s.io/v1beta1/CSIDriver":                                    22,
+	"storage.k8s.io/v1beta1/CSINode":                                      22,
+	"storage.k8s.io/v1beta1/StorageClass":                                 22,
+	"admissionregistration.k8s.io/v1beta1/ValidatingWebhookConfiguration": 22,
+	"batch/v1beta1/CronJob":                                               25,
+	"policy/v1beta1/PodDisruptionBudget":                                  25,
+	"policy/v1beta1/PodSecurityPolicy":                                    25,
+}
+
+// CheckYamlApiVersions scans all yaml documents in the given file and
+// returns an error listing any resources declared with an API version
+// that has been removed at or before the given kubernetes minor version.
+func CheckYamlApiVersions(filename string, dir string, k8sMinorVersion uint) error {
+	contents, err := os.ReadFile(path.Join(dir, filename))
+	if err != nil {
+		return fmt.Errorf("failed to read %s, error %v", filename, err)
+	}
+	var offenders []string
+	for _, doc := range strings.Split(string(contents), "\n---") {
+		var parsed yamlDoc
+		if err := yaml.Unmarshal([]byte(doc), &parsed); err != nil {
+			logf.Log.Info("CheckYamlApiVersions: skipping unparseable document", "file", filename, "error", err)
+			continue
+		}
+		if parsed.ApiVersion == "" || parsed.Kind == "" {
+			continue
+		}
+		key := parsed.ApiVersion + "/" + parsed.Kind
+		if removedIn, ok := removedApis[key]; ok && k8sMinorVersion >= removedIn {
+			offenders = append(offenders,
+				fmt.Sprintf("%s %q uses %s, removed in kubernetes 1.%d", parsed.Kind, parsed.Metadata.Name, parsed.ApiVersion, removedIn))
+		}
+	}
+	if len(offenders) != 0 {
+		return fmt.Errorf("%s: %s", filename, strings.Join(offenders, "; "))
+	}
+	return nil
+}
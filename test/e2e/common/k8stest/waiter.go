@@ -0,0 +1,129 @@
+package k8stest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// Watch based waiter subsystem. Instead of polling with fixed sleeps,
+// waiters here react to watch events as they arrive and record the
+// observed transitions, so waits return immediately on the state change
+// and failures come with a timeline of what actually happened.
+
+// WaitEvent is one transition observed while waiting.
+type WaitEvent struct {
+	Time time.Time
+	Type watch.EventType
+	Name string
+}
+
+// WaitFor watches the resource selected by the given ListerWatcher
+// until the predicate is satisfied or the timeout expires. objType is
+// an empty instance of the expected object type, an unstructured one
+// for custom resources. The observed events are returned as a
+// timeline either way, and also recorded in the test action stream of
+// the merged component logs.
+func WaitFor(description string, lw cache.ListerWatcher, objType runtime.Object, predicate func(runtime.Object) (bool, error), timeoutSecs int) ([]WaitEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSecs)*time.Second)
+	defer cancel()
+
+	var timeline []WaitEvent
+	condition := func(event watch.Event) (bool, error) {
+		name := ""
+		if accessor, err := meta.Accessor(event.Object); err == nil {
+			name = accessor.GetName()
+		}
+		timeline = append(timeline, WaitEvent{Time: time.Now(), Type: event.Type, Name: name})
+		logmerge.RecordAction("waiting for %s: observed %s %s", description, event.Type, name)
+		runreport.RecordTransition(description, string(event.Type), name)
+		if event.Type == watch.Deleted || event.Type == watch.Error {
+			return false, nil
+		}
+		return predicate(event.Object)
+	}
+	_, err := watchtools.UntilWithSync(ctx, lw, objType, nil, condition)
+	if err != nil {
+		return timeline, fmt.Errorf("timed out waiting for %s after %d seconds, %d transitions observed, error %v",
+			description, timeoutSecs, len(timeline), err)
+	}
+	return timeline, nil
+}
+
+// podListWatch selects a single named pod for watching.
+func podListWatch(nameSpace string, podName string) cache.ListerWatcher {
+	nameSpace = resolveNamespace(nameSpace)
+	selector := fields.OneTermEqualSelector("metadata.name", podName).String()
+	return &cache.ListWatch{
+		ListFunc: func(opts metaV1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = selector
+			return gTestEnv.KubeInt.CoreV1().Pods(nameSpace).List(context.TODO(), opts)
+		},
+		WatchFunc: func(opts metaV1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = selector
+			return gTestEnv.KubeInt.CoreV1().Pods(nameSpace).Watch(context.TODO(), opts)
+		},
+	}
+}
+
+// pvcListWatch selects a single named PVC for watching.
+func pvcListWatch(nameSpace string, volName string) cache.ListerWatcher {
+	nameSpace = resolveNamespace(nameSpace)
+	selector := fields.OneTermEqualSelector("metadata.name", volName).String()
+	return &cache.ListWatch{
+		ListFunc: func(opts metaV1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = selector
+			return gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).List(context.TODO(), opts)
+		},
+		WatchFunc: func(opts metaV1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = selector
+			return gTestEnv.KubeInt.CoreV1().PersistentVolumeClaims(nameSpace).Watch(context.TODO(), opts)
+		},
+	}
+}
+
+// msvListWatch selects a single volume custom resource for watching.
+func msvListWatch(uuid string) cache.ListerWatcher {
+	selector := fields.OneTermEqualSelector("metadata.name", uuid).String()
+	return &cache.ListWatch{
+		ListFunc: func(opts metaV1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = selector
+			return gTestEnv.DynamicClient.Resource(msvGVR).Namespace(MayastorNamespace).List(context.TODO(), opts)
+		},
+		WatchFunc: func(opts metaV1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = selector
+			return gTestEnv.DynamicClient.Resource(msvGVR).Namespace(MayastorNamespace).Watch(context.TODO(), opts)
+		},
+	}
+}
+
+// WaitMsvState waits until the volume reports the wanted state,
+// reacting to CRD watch events rather than polling.
+func WaitMsvState(uuid string, wanted string, timeoutSecs int) error {
+	_, err := WaitFor(fmt.Sprintf("MSV %s state %s", uuid, wanted), msvListWatch(uuid),
+		&unstructured.Unstructured{},
+		func(obj runtime.Object) (bool, error) {
+			raw, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return false, nil
+			}
+			msv, err := MsvFromUnstructured(raw)
+			if err != nil {
+				return false, err
+			}
+			return msv.Status.State == wanted, nil
+		}, timeoutSecs)
+	return err
+}
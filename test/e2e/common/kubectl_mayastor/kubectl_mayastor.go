@@ -0,0 +1,129 @@
+// Package kubectl_mayastor invokes the kubectl-mayastor plugin and
+// parses its JSON output into Go structs, so suites can cross-verify the
+// plugin's view of volumes, pools and nodes against the CRD and REST API
+// state, and drive plugin-only operations such as volume scaling and
+// node drains. Invocations go through the kubectlshim runner, so tests
+// substitute a mock instead of needing the plugin binary.
+package kubectl_mayastor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/openebs/mayastor/test/e2e/common/kubectlshim"
+)
+
+// Volume is a volume as reported by kubectl mayastor get volumes.
+type Volume struct {
+	Spec struct {
+		NumReplicas int    `json:"num_replicas"`
+		Size        int64  `json:"size"`
+		Uuid        string `json:"uuid"`
+		Thin        bool   `json:"thin"`
+	} `json:"spec"`
+	State struct {
+		Status string `json:"status"`
+		Uuid   string `json:"uuid"`
+		Size   int64  `json:"size"`
+		Target struct {
+			Node string `json:"node"`
+		} `json:"target"`
+	} `json:"state"`
+}
+
+// Pool is a pool as reported by kubectl mayastor get pools.
+type Pool struct {
+	Id    string `json:"id"`
+	State struct {
+		Node     string `json:"node"`
+		Status   string `json:"status"`
+		Capacity int64  `json:"capacity"`
+		Used     int64  `json:"used"`
+	} `json:"state"`
+}
+
+// Node is a node as reported by kubectl mayastor get nodes.
+type Node struct {
+	Id   string `json:"id"`
+	Spec struct {
+		GrpcEndpoint string   `json:"grpcEndpoint"`
+		Cordon       struct { // drain/cordon labels applied to the node
+			DrainLabels  []string `json:"drainlabels"`
+			CordonLabels []string `json:"cordonlabels"`
+		} `json:"cordondrainstate"`
+	} `json:"spec"`
+	State struct {
+		Status string `json:"status"`
+	} `json:"state"`
+}
+
+// run invokes the plugin with JSON output and decodes into out.
+func run(out interface{}, args ...string) error {
+	fullArgs := append([]string{"mayastor"}, args...)
+	fullArgs = append(fullArgs, "-o", "json")
+	stdout, err := kubectlshim.Run(fullArgs...)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(stdout), out); err != nil {
+		return fmt.Errorf("failed to parse kubectl mayastor %s output, error %v", args[0], err)
+	}
+	return nil
+}
+
+// GetVolumes lists all volumes known to the plugin.
+func GetVolumes() ([]Volume, error) {
+	var volumes []Volume
+	if err := run(&volumes, "get", "volumes"); err != nil {
+		return nil, err
+	}
+	return volumes, nil
+}
+
+// GetVolume retrieves one volume by uuid.
+func GetVolume(uuid string) (Volume, error) {
+	var volume Volume
+	if err := run(&volume, "get", "volume", uuid); err != nil {
+		return Volume{}, err
+	}
+	return volume, nil
+}
+
+// GetPools lists all pools known to the plugin.
+func GetPools() ([]Pool, error) {
+	var pools []Pool
+	if err := run(&pools, "get", "pools"); err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
+
+// GetNodes lists all io-engine nodes known to the plugin.
+func GetNodes() ([]Node, error) {
+	var nodes []Node
+	if err := run(&nodes, "get", "nodes"); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// ScaleVolume changes the replica count of a volume.
+func ScaleVolume(uuid string, replicas int) error {
+	return run(nil, "scale", "volume", uuid, strconv.Itoa(replicas))
+}
+
+// DrainNode starts a control plane drain of the node under the given
+// drain label; volume targets on the node are moved away.
+func DrainNode(nodeName string, label string) error {
+	return run(nil, "drain", "node", nodeName, label)
+}
+
+// UncordonNode removes the given drain or cordon label from the node,
+// making it schedulable for volume targets again.
+func UncordonNode(nodeName string, label string) error {
+	return run(nil, "uncordon", "node", nodeName, label)
+}
@@ -0,0 +1,71 @@
+package kubectl_mayastor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/kubectlshim"
+)
+
+const volumesJson = `[
+  {
+    "spec": {"num_replicas": 2, "size": 536870912, "uuid": "11111111-2222-3333-4444-555555555555", "thin": false},
+    "state": {"status": "Online", "uuid": "11111111-2222-3333-4444-555555555555", "size": 536870912,
+              "target": {"node": "worker-1"}}
+  }
+]`
+
+func TestGetVolumes(t *testing.T) {
+	var gotArgs []string
+	restore := kubectlshim.SetRunner(func(args []string, opts kubectlshim.Options) (string, string, int, error) {
+		gotArgs = args
+		return volumesJson, "", 0, nil
+	})
+	defer restore()
+
+	volumes, err := GetVolumes()
+	if err != nil {
+		t.Fatalf("GetVolumes failed: %v", err)
+	}
+	if want := "mayastor get volumes -o json"; strings.Join(gotArgs, " ") != want {
+		t.Errorf("expected args %q, got %q", want, strings.Join(gotArgs, " "))
+	}
+	if len(volumes) != 1 {
+		t.Fatalf("expected 1 volume, got %d", len(volumes))
+	}
+	volume := volumes[0]
+	if volume.Spec.NumReplicas != 2 || volume.Spec.Size != 536870912 {
+		t.Errorf("unexpected volume spec %+v", volume.Spec)
+	}
+	if volume.State.Status != "Online" || volume.State.Target.Node != "worker-1" {
+		t.Errorf("unexpected volume state %+v", volume.State)
+	}
+}
+
+func TestScaleVolumeArgs(t *testing.T) {
+	var gotArgs []string
+	restore := kubectlshim.SetRunner(func(args []string, opts kubectlshim.Options) (string, string, int, error) {
+		gotArgs = args
+		return "", "", 0, nil
+	})
+	defer restore()
+
+	if err := ScaleVolume("11111111-2222-3333-4444-555555555555", 3); err != nil {
+		t.Fatalf("ScaleVolume failed: %v", err)
+	}
+	want := "mayastor scale volume 11111111-2222-3333-4444-555555555555 3 -o json"
+	if strings.Join(gotArgs, " ") != want {
+		t.Errorf("expected args %q, got %q", want, strings.Join(gotArgs, " "))
+	}
+}
+
+func TestGetVolumesError(t *testing.T) {
+	restore := kubectlshim.SetRunner(func(args []string, opts kubectlshim.Options) (string, string, int, error) {
+		return "", "plugin not installed", 1, nil
+	})
+	defer restore()
+
+	if _, err := GetVolumes(); err == nil {
+		t.Error("expected an error when the plugin exits non-zero")
+	}
+}
@@ -0,0 +1,109 @@
+// Package kubectlshim wraps the kubectl invocations which remain in the
+// test library during the transition to pure client-go access. The shim
+// pins the locale so output is parseable regardless of the host
+// configuration, normalises CRLF line endings, applies context and
+// namespace flags consistently, and returns structured errors carrying
+// the exit code and stderr. The underlying runner is replaceable so
+// callers can be unit tested without a kubectl binary.
+package kubectlshim
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Error is returned when kubectl exits non-zero, carrying enough
+// context to diagnose the failure without re-running the command.
+type Error struct {
+	Args     []string
+	ExitCode int
+	Stderr   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("kubectl %s failed, exit code %d, stderr %s",
+		strings.Join(e.Args, " "), e.ExitCode, strings.TrimSpace(e.Stderr))
+}
+
+// Options control how a kubectl invocation is run.
+type Options struct {
+	// Context selects a kubeconfig context, passed as --context.
+	Context string
+	// Namespace is passed as --namespace.
+	Namespace string
+	// Dir is the working directory for the invocation.
+	Dir string
+	// Stdin is fed to kubectl on standard input.
+	Stdin string
+}
+
+// Runner executes a kubectl command line and returns stdout, stderr and
+// the exit code. The default runner executes the real binary; tests
+// substitute their own with SetRunner.
+type Runner func(args []string, opts Options) (string, string, int, error)
+
+var runner Runner = execRunner
+
+// SetRunner replaces the runner, typically with a mock, and returns a
+// function restoring the previous one.
+func SetRunner(r Runner) func() {
+	previous := runner
+	runner = r
+	return func() { runner = previous }
+}
+
+// execRunner runs the real kubectl binary with the locale pinned to C,
+// so status words and number formatting do not vary with the host
+// locale.
+func execRunner(args []string, opts Options) (string, string, int, error) {
+	cmd := exec.Command("kubectl", args...)
+	cmd.Dir = opts.Dir
+	cmd.Env = append(cmd.Environ(), "LC_ALL=C", "LANG=C")
+	if opts.Stdin != "" {
+		cmd.Stdin = strings.NewReader(opts.Stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	exitCode := 0
+	if exitError, ok := err.(*exec.ExitError); ok {
+		exitCode = exitError.ExitCode()
+		err = nil
+	}
+	return stdout.String(), stderr.String(), exitCode, err
+}
+
+// normalise converts CRLF line endings (seen with kubectl plugins and
+// Windows-built binaries) to plain LF.
+func normalise(out string) string {
+	return strings.ReplaceAll(out, "\r\n", "\n")
+}
+
+// RunWith invokes kubectl with the given arguments and options, and
+// returns the normalised stdout. A non-zero exit yields an *Error.
+func RunWith(opts Options, args ...string) (string, error) {
+	fullArgs := []string{}
+	if opts.Context != "" {
+		fullArgs = append(fullArgs, "--context", opts.Context)
+	}
+	if opts.Namespace != "" {
+		fullArgs = append(fullArgs, "--namespace", opts.Namespace)
+	}
+	fullArgs = append(fullArgs, args...)
+	stdout, stderr, exitCode, err := runner(fullArgs, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to run kubectl %s, error %v", strings.Join(fullArgs, " "), err)
+	}
+	if exitCode != 0 {
+		return normalise(stdout), &Error{Args: fullArgs, ExitCode: exitCode, Stderr: normalise(stderr)}
+	}
+	return normalise(stdout), nil
+}
+
+// Run invokes kubectl with the given arguments and default options.
+func Run(args ...string) (string, error) {
+	return RunWith(Options{}, args...)
+}
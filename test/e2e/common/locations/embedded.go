@@ -0,0 +1,48 @@
+package locations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// Packaged payload, populated by `go generate ./common/locations` before
+// building a standalone test binary; contains only a README otherwise.
+//
+//go:embed all:embedded
+var packagedFS embed.FS
+
+// extractPackaged writes the embedded payload out to a temporary
+// directory laid out like a mayastor tree and returns its path. Fails
+// if the payload was not generated before the binary was built.
+func extractPackaged() (string, error) {
+	if _, err := fs.Stat(packagedFS, "embedded/scripts"); err != nil {
+		return "", fmt.Errorf("binary was built without the packaged payload, run go generate ./common/locations first")
+	}
+	extractDir, err := os.MkdirTemp("", "mayastor-e2e-payload-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create extraction directory, error %v", err)
+	}
+	err = fs.WalkDir(packagedFS, "embedded", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// strip the leading "embedded/" so the payload extracts as a
+		// mayastor tree
+		target := path.Join(extractDir, filePath[len("embedded"):])
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		contents, readErr := packagedFS.ReadFile(filePath)
+		if readErr != nil {
+			return readErr
+		}
+		return os.WriteFile(target, contents, 0755)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to extract packaged payload, error %v", err)
+	}
+	return extractDir, nil
+}
@@ -0,0 +1,8 @@
+package locations
+
+// Packaged mode: running go generate here copies the mayastor scripts
+// and any generated deployment yamls into the embedded payload, so a
+// test binary built afterwards can run standalone on CI workers without
+// a mayastor source checkout.
+
+//go:generate sh -c "rm -rf embedded/scripts embedded/artifacts && cp -r ../../../../scripts embedded/scripts && if [ -d ../../../../artifacts/install/yamls ]; then mkdir -p embedded/artifacts/install && cp -r ../../../../artifacts/install/yamls embedded/artifacts/install/yamls; fi"
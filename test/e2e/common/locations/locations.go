@@ -0,0 +1,90 @@
+// Package locations resolves the directories the test framework takes
+// scripts and deployment yamls from. The mayastor root directory is
+// resolved in order from:
+//  1. the e2e_root_dir environment variable,
+//  2. auto-discovery relative to this source file for in-tree runs,
+//  3. the embedded payload, when the test binary was built in packaged
+//     mode (see generate.go), extracted to a temporary directory.
+//
+// Resolution failures return errors naming what was tried, rather than
+// panicking on an assumed layout.
+package locations
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+)
+
+var resolveOnce sync.Once
+var resolvedRoot string
+var resolveErr error
+
+func isDir(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// sourceTreeRoot returns the path to the root of the mayastor source
+// tree derived from the location of this source file, valid for in-tree
+// runs only.
+func sourceTreeRoot() string {
+	_, filename, _, _ := runtime.Caller(0)
+	return path.Clean(path.Join(path.Dir(filename), "..", "..", "..", ".."))
+}
+
+func resolveRoot() {
+	if envRoot := os.Getenv("e2e_root_dir"); envRoot != "" {
+		if !isDir(path.Join(envRoot, "scripts")) {
+			resolveErr = fmt.Errorf("e2e_root_dir is set to %q but it does not contain a scripts directory", envRoot)
+			return
+		}
+		resolvedRoot = envRoot
+		return
+	}
+
+	if srcRoot := sourceTreeRoot(); isDir(path.Join(srcRoot, "scripts")) {
+		resolvedRoot = srcRoot
+		return
+	}
+
+	packagedRoot, err := extractPackaged()
+	if err == nil {
+		resolvedRoot = packagedRoot
+		return
+	}
+
+	resolveErr = fmt.Errorf(
+		"cannot resolve the mayastor root directory: e2e_root_dir is not set, "+
+			"%q is not a mayastor source tree, and no embedded payload is built in (%v)",
+		sourceTreeRoot(), err)
+}
+
+// GetMayastorRootDir returns the path to the root of the mayastor tree,
+// source checkout or extracted packaged payload.
+func GetMayastorRootDir() (string, error) {
+	resolveOnce.Do(resolveRoot)
+	return resolvedRoot, resolveErr
+}
+
+// GetMayastorScriptsDir returns the path to the mayastor scripts
+// directory.
+func GetMayastorScriptsDir() (string, error) {
+	root, err := GetMayastorRootDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(root, "scripts"), nil
+}
+
+// GetGeneratedYamlsDir returns the path to the directory where
+// deployment yaml files are generated by generate-deploy-yamls.sh
+func GetGeneratedYamlsDir() (string, error) {
+	root, err := GetMayastorRootDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(root, "artifacts", "install", "yamls"), nil
+}
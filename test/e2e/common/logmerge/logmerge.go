@@ -0,0 +1,128 @@
+// Package logmerge merges the logs of the mayastor components and the
+// test's own actions into a single time-ordered, component-tagged
+// stream, normalising the differing timestamp formats, so
+// multi-component race failures can be analysed without manually
+// aligning log files.
+package logmerge
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one log line attributed to a component at a point in time.
+type Entry struct {
+	Time      time.Time
+	Component string
+	Line      string
+}
+
+// timestampFormats are tried in order against the prefix of each log
+// line; they cover the component log formats in use: RFC3339 with and
+// without sub-second digits (moac, csi sidecars), and the io-engine's
+// date-less tracing format handled separately below.
+var timestampFormats = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+}
+
+// tokenRegexp extracts the leading token of a line, stripping log level
+// brackets, e.g. "[2021-05-04T12:00:00.123Z" or "2021-05-04T12:00:00Z".
+var tokenRegexp = regexp.MustCompile(`^\[?([0-9][0-9:TZtz.+\- ]+[0-9Zz])`)
+
+// klogRegexp matches the klog prefix used by kubelet style components,
+// e.g. "I0504 12:00:00.123456".
+var klogRegexp = regexp.MustCompile(`^[IWEF](\d{2})(\d{2}) (\d{2}:\d{2}:\d{2}\.\d+)`)
+
+// ParseTimestamp extracts the timestamp of a log line, normalising the
+// formats used by the different components. The second return is false
+// for continuation lines with no timestamp of their own.
+func ParseTimestamp(line string, year int) (time.Time, bool) {
+	if match := klogRegexp.FindStringSubmatch(line); match != nil {
+		stamp := fmt.Sprintf("%d-%s-%sT%sZ", year, match[1], match[2], match[3])
+		if parsed, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+			return parsed, true
+		}
+	}
+	match := tokenRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, false
+	}
+	token := match[1]
+	for _, format := range timestampFormats {
+		if parsed, err := time.Parse(format, token); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Merge converts per-component log texts into a single stream ordered
+// by time. Lines without a timestamp (stack traces, continuations)
+// inherit the timestamp of the preceding line of their component, so
+// they stay adjacent to it after the merge. Lines preceding any
+// timestamp in their stream are dropped.
+func Merge(streams map[string]string) []Entry {
+	year := time.Now().Year()
+	var entries []Entry
+	for component, text := range streams {
+		var lastSeen time.Time
+		haveSeen := false
+		for _, line := range strings.Split(text, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if stamp, ok := ParseTimestamp(line, year); ok {
+				lastSeen = stamp
+				haveSeen = true
+			}
+			if !haveSeen {
+				continue
+			}
+			entries = append(entries, Entry{Time: lastSeen, Component: component, Line: line})
+		}
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].Time.Before(entries[b].Time)
+	})
+	return entries
+}
+
+var actionsMutex sync.Mutex
+var actions strings.Builder
+
+// RecordAction adds an entry to the test's own action stream, so the
+// merged output shows what the test was doing between the component
+// log lines. Helpers call this at the point of cluster-visible actions
+// (pod deletion, node taint, fio start, ...).
+func RecordAction(format string, args ...interface{}) {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	actions.WriteString(fmt.Sprintf("%s %s\n",
+		time.Now().UTC().Format(time.RFC3339Nano), fmt.Sprintf(format, args...)))
+}
+
+// ActionStream returns the accumulated test action log, for inclusion
+// as the "test" component of a merge.
+func ActionStream() string {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	return actions.String()
+}
+
+// Format renders merged entries one per line, with a normalised
+// timestamp and the component tag.
+func Format(entries []Entry) string {
+	var out strings.Builder
+	for _, entry := range entries {
+		out.WriteString(fmt.Sprintf("%s [%-12s] %s\n",
+			entry.Time.UTC().Format("2006-01-02T15:04:05.000Z"), entry.Component, entry.Line))
+	}
+	return out.String()
+}
@@ -0,0 +1,158 @@
+// Package loki pulls component logs for a test's time window, from a
+// Loki server when one is configured and directly from the pod logs
+// when not, and offers grep-like matchers over them. Disruptive suites
+// use it to assert the absence of panics, allocation failures or nvmf
+// keep-alive timeouts after the dust has settled.
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+)
+
+// queryTimeout bounds one Loki query.
+const queryTimeout = 60 * time.Second
+
+// queryLimit is the maximum number of log lines one query returns.
+const queryLimit = 5000
+
+// componentSelectors maps component names to the app label their pods
+// carry, mirroring the selectors the log merger uses.
+var componentSelectors = map[string]string{
+	"io-engine": "app=mayastor",
+	"csi":       "app=mayastor-csi",
+	"moac":      "app=moac",
+}
+
+// LogsForWindow returns the log lines of the given component over the
+// given time window: from Loki when an endpoint is configured, from the
+// pod logs otherwise. The pod log fallback misses logs of pods which
+// were restarted or deleted during the window - one of the reasons to
+// run Loki in CI.
+func LogsForWindow(component string, from time.Time, to time.Time) ([]string, error) {
+	selector, ok := componentSelectors[component]
+	if !ok {
+		return nil, fmt.Errorf("unknown component %q", component)
+	}
+	if endpoint := e2e_config.GetConfig().Loki.Endpoint; endpoint != "" {
+		return queryLoki(endpoint, selector, from, to)
+	}
+	return logsFromPods(selector, from)
+}
+
+// lokiResponse is the subset of the Loki query_range response the
+// client consumes.
+type lokiResponse struct {
+	Data struct {
+		Result []struct {
+			Values [][2]string `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// queryLoki runs a query_range against the Loki HTTP API, selecting on
+// the app label of the component.
+func queryLoki(endpoint string, selector string, from time.Time, to time.Time) ([]string, error) {
+	labelValue := strings.TrimPrefix(selector, "app=")
+	params := url.Values{}
+	params.Set("query", fmt.Sprintf(`{app=%q}`, labelValue))
+	params.Set("start", strconv.FormatInt(from.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(to.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(queryLimit))
+	queryUrl := fmt.Sprintf("%s/loki/api/v1/query_range?%s", strings.TrimSuffix(endpoint, "/"), params.Encode())
+
+	client := http.Client{Timeout: queryTimeout}
+	resp, err := client.Get(queryUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loki, error %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the loki response, error %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("loki query failed, status %d, body %s", resp.StatusCode, string(contents))
+	}
+	var decoded lokiResponse
+	if err := json.Unmarshal(contents, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to parse the loki response, error %v", err)
+	}
+	var lines []string
+	for _, stream := range decoded.Data.Result {
+		for _, value := range stream.Values {
+			lines = append(lines, value[1])
+		}
+	}
+	return lines, nil
+}
+
+// logsFromPods reads the logs of the component pods since the window
+// start.
+func logsFromPods(selector string, from time.Time) ([]string, error) {
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods matching %s, error %v", selector, err)
+	}
+	var lines []string
+	for _, pod := range pods.Items {
+		log, err := k8stest.GetPodLogSince(pod.Name, k8stest.MayastorNamespace, from)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(log, "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
+
+// Grep returns the lines matching the given regular expression.
+func Grep(lines []string, pattern string) ([]string, error) {
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q, error %v", pattern, err)
+	}
+	var matched []string
+	for _, line := range lines {
+		if matcher.MatchString(line) {
+			matched = append(matched, line)
+		}
+	}
+	return matched, nil
+}
+
+// AssertAbsent returns an error quoting the first match of any of the
+// given patterns, e.g. "panic", "ENOSPC" or "keep-alive timeout", so a
+// suite can fail with the offending log line in the failure message.
+func AssertAbsent(lines []string, patterns ...string) error {
+	var found []string
+	for _, pattern := range patterns {
+		matched, err := Grep(lines, pattern)
+		if err != nil {
+			return err
+		}
+		if len(matched) != 0 {
+			found = append(found, fmt.Sprintf("%q matched %d lines, first: %s",
+				pattern, len(matched), matched[0]))
+		}
+	}
+	if len(found) != 0 {
+		return fmt.Errorf("forbidden log lines present: %s", strings.Join(found, "; "))
+	}
+	return nil
+}
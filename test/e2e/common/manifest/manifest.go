@@ -0,0 +1,59 @@
+// Package manifest maintains the run manifest, a json document in the
+// reports directory which accumulates machine readable records (rebuild
+// history, disruption accounting, ...) across all suites of a test run,
+// so trends can be charted across CI runs.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+const manifestFileName = "e2e-run-manifest.json"
+
+var mutex sync.Mutex
+
+// AppendSection appends the given records to the named section of the
+// run manifest, creating the manifest and the section as needed. A
+// no-op if no reports directory is configured.
+func AppendSection(section string, records interface{}) error {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		return nil
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	manifestFile := path.Join(reportsDir, manifestFileName)
+	document := map[string][]json.RawMessage{}
+	if contents, err := os.ReadFile(manifestFile); err == nil {
+		if err := json.Unmarshal(contents, &document); err != nil {
+			return fmt.Errorf("run manifest %s is corrupt, error %v", manifestFile, err)
+		}
+	}
+
+	encoded, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to encode records for manifest section %s, error %v", section, err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(encoded, &items); err != nil {
+		// a single record rather than a list
+		items = []json.RawMessage{encoded}
+	}
+	document[section] = append(document[section], items...)
+
+	contents, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run manifest, error %v", err)
+	}
+	if err := os.WriteFile(manifestFile, contents, 0644); err != nil {
+		return fmt.Errorf("failed to write run manifest %s, error %v", manifestFile, err)
+	}
+	return nil
+}
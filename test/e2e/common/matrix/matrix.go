@@ -0,0 +1,128 @@
+// Package matrix generates test cases over the protocol, volume type
+// and binding mode dimensions configured in e2e_config, so suites
+// register one spec per combination instead of hand-writing near
+// identical It blocks. Cells the product does not support are excluded
+// by one central set of skip rules, so enabling a coverage gap once
+// support lands is a one line change here rather than a hunt through
+// the suites.
+package matrix
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+
+	"github.com/onsi/ginkgo/v2"
+)
+
+// VolumeTypeBlock identifies the raw block cell of the volume type
+// dimension; the other cells are the configured filesystems.
+const VolumeTypeBlock = "block"
+
+// Binding mode dimension values.
+const (
+	BindingImmediate = "immediate"
+	BindingLate      = "late"
+)
+
+// Case is one cell of the protocol x volume type x binding matrix.
+type Case struct {
+	Protocol   string
+	VolumeType string
+	Binding    string
+}
+
+// RawBlock reports whether the case consumes the volume as a raw block
+// device rather than a mounted filesystem.
+func (c Case) RawBlock() bool {
+	return c.VolumeType == VolumeTypeBlock
+}
+
+// Name returns the cell name used in spec descriptions, e.g.
+// "nvmf/ext4/immediate".
+func (c Case) Name() string {
+	return fmt.Sprintf("%s/%s/%s", c.Protocol, c.VolumeType, c.Binding)
+}
+
+// Suffix returns the cell name in a form usable in resource names,
+// e.g. "nvmf-ext4-immediate".
+func (c Case) Suffix() string {
+	return strings.ReplaceAll(c.Name(), "/", "-")
+}
+
+// ApplyTo configures a storage class builder for the case: protocol,
+// filesystem for the non block cells, and binding mode.
+func (c Case) ApplyTo(builder *k8stest.StorageClassBuilder) *k8stest.StorageClassBuilder {
+	builder = builder.WithProtocol(c.Protocol)
+	if !c.RawBlock() {
+		builder = builder.WithFsType(c.VolumeType)
+	}
+	if c.Binding == BindingLate {
+		builder = builder.WithLateBinding()
+	}
+	return builder
+}
+
+// SkipRule inspects a case and returns the reason to skip it, empty to
+// run it.
+type SkipRule func(Case) string
+
+// defaultSkipRules excludes the cells the product does not support.
+var defaultSkipRules = []SkipRule{
+	func(c Case) string {
+		if c.Protocol == "iscsi" && c.RawBlock() {
+			return "raw block volumes are not supported over iscsi"
+		}
+		return ""
+	},
+}
+
+// Entry is one generated cell with its resolved skip reason, empty for
+// a runnable cell.
+type Entry struct {
+	Case       Case
+	SkipReason string
+}
+
+// Entries returns the cross product of the configured dimensions, with
+// each cell's skip reason resolved against the default rules plus any
+// rules of the calling suite. The volume type dimension is the
+// configured filesystems plus raw block.
+func Entries(rules ...SkipRule) []Entry {
+	cfg := e2e_config.GetConfig()
+	volumeTypes := append(append([]string{}, cfg.FsTypes...), VolumeTypeBlock)
+	var entries []Entry
+	for _, protocol := range cfg.Protocols {
+		for _, volumeType := range volumeTypes {
+			for _, binding := range cfg.BindingModes {
+				entry := Entry{Case: Case{Protocol: protocol, VolumeType: volumeType, Binding: binding}}
+				for _, rule := range append(append([]SkipRule{}, defaultSkipRules...), rules...) {
+					if reason := rule(entry.Case); reason != "" {
+						entry.SkipReason = reason
+						break
+					}
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// ItPerCase registers one ginkgo It per matrix cell, described as
+// "<description> (<cell name>)". Excluded cells are registered too and
+// skip with their reason, so the report shows the full matrix rather
+// than silently narrowing it.
+func ItPerCase(description string, body func(Case), rules ...SkipRule) {
+	for _, entry := range Entries(rules...) {
+		entry := entry
+		ginkgo.It(fmt.Sprintf("%s (%s)", description, entry.Case.Name()), func() {
+			if entry.SkipReason != "" {
+				ginkgo.Skip(entry.SkipReason)
+			}
+			body(entry.Case)
+		})
+	}
+}
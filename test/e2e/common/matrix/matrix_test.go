@@ -0,0 +1,74 @@
+package matrix
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// scopedDimensions pins the matrix dimensions for the duration of a
+// test, so the expectations do not depend on the run configuration.
+func scopedDimensions(t *testing.T, protocols []string, fsTypes []string, bindings []string) {
+	e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+		cfg.Protocols = protocols
+		cfg.FsTypes = fsTypes
+		cfg.BindingModes = bindings
+	})
+	t.Cleanup(e2e_config.PopOverrideScope)
+}
+
+func TestEntriesCrossProduct(t *testing.T) {
+	scopedDimensions(t, []string{"nvmf", "iscsi"}, []string{"ext4", "xfs"}, []string{BindingImmediate, BindingLate})
+	entries := Entries()
+	// 2 protocols x (2 filesystems + block) x 2 bindings
+	if len(entries) != 12 {
+		t.Fatalf("expected 12 entries, got %d", len(entries))
+	}
+	names := map[string]bool{}
+	for _, entry := range entries {
+		names[entry.Case.Name()] = true
+	}
+	for _, want := range []string{"nvmf/ext4/immediate", "iscsi/block/late", "nvmf/block/immediate"} {
+		if !names[want] {
+			t.Errorf("missing entry %s", want)
+		}
+	}
+}
+
+func TestEntriesDefaultSkipRules(t *testing.T) {
+	scopedDimensions(t, []string{"iscsi"}, []string{"ext4"}, []string{BindingImmediate})
+	for _, entry := range Entries() {
+		skipped := entry.SkipReason != ""
+		if entry.Case.RawBlock() != skipped {
+			t.Errorf("entry %s: skip reason %q", entry.Case.Name(), entry.SkipReason)
+		}
+	}
+}
+
+func TestEntriesSuiteRules(t *testing.T) {
+	scopedDimensions(t, []string{"nvmf"}, []string{"ext4"}, []string{BindingImmediate, BindingLate})
+	entries := Entries(func(c Case) string {
+		if c.Binding == BindingLate {
+			return "suite does not support late binding"
+		}
+		return ""
+	})
+	for _, entry := range entries {
+		if entry.Case.Binding == BindingLate && entry.SkipReason == "" {
+			t.Errorf("entry %s not skipped by the suite rule", entry.Case.Name())
+		}
+	}
+}
+
+func TestCaseNaming(t *testing.T) {
+	c := Case{Protocol: "nvmf", VolumeType: VolumeTypeBlock, Binding: BindingLate}
+	if c.Name() != "nvmf/block/late" {
+		t.Errorf("unexpected name %q", c.Name())
+	}
+	if c.Suffix() != "nvmf-block-late" {
+		t.Errorf("unexpected suffix %q", c.Suffix())
+	}
+	if !c.RawBlock() {
+		t.Errorf("block volume type not recognised as raw block")
+	}
+}
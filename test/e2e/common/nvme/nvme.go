@@ -0,0 +1,141 @@
+// Package nvme inspects the NVMe-oF initiator state of cluster nodes by
+// running nvme-cli inside the csi node plugin pod, which shares the host
+// namespaces the connections live in. HA tests use it to assert the
+// initiator really switched paths after a nexus failover, rather than
+// inferring it from control plane state.
+package nvme
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+)
+
+// execTimeoutSecs bounds one nvme-cli invocation.
+const execTimeoutSecs = 60
+
+// Path is one controller path to a subsystem as reported by
+// nvme list-subsys, e.g. live and optimized, or connecting and
+// inaccessible while failover is in progress.
+type Path struct {
+	// Device is the controller instance, e.g. nvme0
+	Device string
+	// Transport is the transport type, e.g. tcp
+	Transport string
+	// Address is the transport address, e.g. traddr=10.0.0.1,trsvcid=8420
+	Address string
+	// State is the connection state, e.g. live or connecting
+	State string
+	// AnaState is the asymmetric namespace access state, e.g. optimized
+	// or inaccessible; empty when the subsystem is not ANA aware
+	AnaState string
+}
+
+// Subsystem is one NVMe-oF subsystem the node is connected to, with all
+// its controller paths.
+type Subsystem struct {
+	// Name is the subsystem instance, e.g. nvme-subsys0
+	Name string
+	// Nqn is the subsystem NQN; mayastor embeds the volume uuid in it
+	Nqn   string
+	Paths []Path
+}
+
+// ControllerCount returns the number of controller paths to the
+// subsystem.
+func (s Subsystem) ControllerCount() int {
+	return len(s.Paths)
+}
+
+// PathsByAnaState returns the paths in the given ANA state.
+func (s Subsystem) PathsByAnaState(anaState string) []Path {
+	var matched []Path
+	for _, path := range s.Paths {
+		if path.AnaState == anaState {
+			matched = append(matched, path)
+		}
+	}
+	return matched
+}
+
+// LivePaths returns the paths whose connection state is live.
+func (s Subsystem) LivePaths() []Path {
+	var matched []Path
+	for _, path := range s.Paths {
+		if path.State == "live" {
+			matched = append(matched, path)
+		}
+	}
+	return matched
+}
+
+// ParseListSubsys parses the plain text output of nvme list-subsys into
+// structured subsystems. Lines it does not recognise are skipped, so
+// output format drift degrades into missing detail rather than errors.
+func ParseListSubsys(output string) []Subsystem {
+	var subsystems []Subsystem
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if name, nqn, found := strings.Cut(trimmed, " - NQN="); found {
+			subsystems = append(subsystems, Subsystem{Name: name, Nqn: strings.TrimSpace(nqn)})
+			continue
+		}
+		rest, found := strings.CutPrefix(trimmed, "+- ")
+		if !found || len(subsystems) == 0 {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			continue
+		}
+		path := Path{Device: fields[0], Transport: fields[1]}
+		// The remaining fields are the transport address tokens, the
+		// connection state and, on ANA aware subsystems, the ANA state.
+		for _, field := range fields[2:] {
+			if strings.Contains(field, "=") {
+				if path.Address != "" {
+					path.Address += ","
+				}
+				path.Address += field
+			} else if path.State == "" {
+				path.State = field
+			} else {
+				path.AnaState = field
+			}
+		}
+		last := &subsystems[len(subsystems)-1]
+		last.Paths = append(last.Paths, path)
+	}
+	return subsystems
+}
+
+// ListSubsystems returns the NVMe-oF subsystems the given node is
+// connected to.
+func ListSubsystems(nodeName string) ([]Subsystem, error) {
+	podName, container, err := k8stest.CsiNodePod(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	stdout, _, err := k8stest.ExecInPod(k8stest.MayastorNamespace, podName, container,
+		[]string{"nvme", "list-subsys"}, execTimeoutSecs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run nvme list-subsys on node %s, error %v", nodeName, err)
+	}
+	return ParseListSubsys(stdout), nil
+}
+
+// SubsystemForVolume returns the subsystem of the given volume on the
+// given node; mayastor embeds the volume uuid in the subsystem NQN.
+func SubsystemForVolume(nodeName string, uuid string) (Subsystem, error) {
+	subsystems, err := ListSubsystems(nodeName)
+	if err != nil {
+		return Subsystem{}, err
+	}
+	for _, subsystem := range subsystems {
+		if strings.Contains(subsystem.Nqn, uuid) {
+			return subsystem, nil
+		}
+	}
+	return Subsystem{}, fmt.Errorf("node %s has no subsystem for volume %s", nodeName, uuid)
+}
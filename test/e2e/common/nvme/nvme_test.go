@@ -0,0 +1,53 @@
+package nvme
+
+import "testing"
+
+// Output of nvme list-subsys from a node with one ANA aware mayastor
+// subsystem with two paths, and one non-ANA subsystem.
+const listSubsysOutput = `
+nvme-subsys0 - NQN=nqn.2019-05.io.openebs:nexus-11111111-2222-3333-4444-555555555555
+\
+ +- nvme0 tcp traddr=10.0.0.1 trsvcid=8420 live optimized
+ +- nvme1 tcp traddr=10.0.0.2 trsvcid=8420 live inaccessible
+nvme-subsys1 - NQN=nqn.2014-08.org.nvmexpress.discovery
+\
+ +- nvme2 tcp traddr=10.0.0.3,trsvcid=8009 live
+`
+
+func TestParseListSubsys(t *testing.T) {
+	subsystems := ParseListSubsys(listSubsysOutput)
+	if len(subsystems) != 2 {
+		t.Fatalf("expected 2 subsystems, got %d", len(subsystems))
+	}
+	first := subsystems[0]
+	if first.Nqn != "nqn.2019-05.io.openebs:nexus-11111111-2222-3333-4444-555555555555" {
+		t.Errorf("unexpected NQN %q", first.Nqn)
+	}
+	if first.ControllerCount() != 2 {
+		t.Fatalf("expected 2 paths, got %d", first.ControllerCount())
+	}
+	if got := first.Paths[0]; got.Device != "nvme0" || got.Transport != "tcp" ||
+		got.Address != "traddr=10.0.0.1,trsvcid=8420" || got.State != "live" || got.AnaState != "optimized" {
+		t.Errorf("unexpected first path %+v", got)
+	}
+	if len(first.PathsByAnaState("optimized")) != 1 || len(first.PathsByAnaState("inaccessible")) != 1 {
+		t.Errorf("unexpected ANA states in %+v", first.Paths)
+	}
+	if len(first.LivePaths()) != 2 {
+		t.Errorf("expected 2 live paths, got %d", len(first.LivePaths()))
+	}
+	second := subsystems[1]
+	if second.ControllerCount() != 1 || second.Paths[0].AnaState != "" {
+		t.Errorf("unexpected discovery subsystem %+v", second)
+	}
+}
+
+func TestParseListSubsysEmpty(t *testing.T) {
+	if got := ParseListSubsys(""); len(got) != 0 {
+		t.Errorf("expected no subsystems from empty output, got %+v", got)
+	}
+	// A stray path line with no preceding subsystem must be skipped.
+	if got := ParseListSubsys(" +- nvme0 tcp traddr=10.0.0.1 live"); len(got) != 0 {
+		t.Errorf("expected no subsystems, got %+v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+
+	ginkgoTypes "github.com/onsi/ginkgo/v2/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// junit schema, limited to the elements consumed by our CI. Flaky specs,
+// i.e. specs which failed and then passed on a retry, are reported as
+// passed but carry a "flaky" property and the number of attempts so they
+// remain visible in the test report.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	ClassName  string          `xml:"classname,attr"`
+	Time       float64         `xml:"time,attr"`
+	Skipped    *junitMessage   `xml:"skipped,omitempty"`
+	Failure    *junitMessage   `xml:"failure,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func writeJUnitReport(description string, report ginkgoTypes.Report) {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		return
+	}
+	suite := junitTestSuite{
+		Name: description,
+		Time: report.RunTime.Seconds(),
+	}
+	for _, spec := range report.SpecReports {
+		if spec.LeafNodeType != ginkgoTypes.NodeTypeIt {
+			continue
+		}
+		suite.Tests++
+		testCase := junitTestCase{
+			Name:      spec.FullText(),
+			ClassName: description,
+			Time:      spec.RunTime.Seconds(),
+		}
+		switch spec.State {
+		case ginkgoTypes.SpecStateSkipped:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: spec.Failure.Message}
+		case ginkgoTypes.SpecStatePassed:
+			if spec.NumAttempts > 1 {
+				// Passed only on a retry: keep the flake visible.
+				testCase.Name += " [flaky]"
+				testCase.Properties = []junitProperty{
+					{Name: "flaky", Value: "true"},
+					{Name: "attempts", Value: fmt.Sprintf("%d", spec.NumAttempts)},
+				}
+			}
+		default:
+			suite.Failures++
+			testCase.Failure = &junitMessage{
+				Message: spec.Failure.Message,
+				Body:    spec.Failure.Location.String(),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	contents, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		logf.Log.Info("failed to marshal junit report", "error", err)
+		return
+	}
+	filename := path.Join(reportsDir, fmt.Sprintf("e2e.%s.junit.xml", artefactName(description)))
+	if err := os.WriteFile(filename, append([]byte(xml.Header), contents...), 0644); err != nil {
+		logf.Log.Info("failed to write junit report", "file", filename, "error", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package reporter wraps ginkgo suite invocation with the flake handling
+// and reporting policy of the e2e framework: failed specs are retried a
+// configurable number of times, full diagnostics are captured on the
+// first failure of a spec, and specs which only pass on a retry are
+// marked as flaky in the junit output instead of being silently masked.
+package reporter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/canary"
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/kubectlshim"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+
+	"github.com/onsi/ginkgo/v2"
+	ginkgoTypes "github.com/onsi/ginkgo/v2/types"
+	"github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// specs for which diagnostics have already been captured in this suite,
+// keyed by the full spec text, so retries do not capture twice
+var diagnosed = map[string]bool{}
+
+// RunSpecs runs the suite with the framework flake handling and junit
+// reporting configured; suites use this instead of calling
+// ginkgo.RunSpecs directly.
+func RunSpecs(t *testing.T, description string) {
+	suiteCfg, reporterCfg := ginkgo.GinkgoConfiguration()
+	if suiteCfg.FlakeAttempts <= 1 {
+		suiteCfg.FlakeAttempts = e2e_config.GetConfig().FlakeAttempts
+	}
+
+	// The canary prober re-attaches once the test environment is up
+	// (i.e. after BeforeSuite) and any probe failure is attributed to
+	// the spec during which it occurred.
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(k8stest.BeforeEachCheck()).To(gomega.Succeed(), "cluster not fit to run the spec")
+		gomega.Expect(canary.EnsureStarted()).To(gomega.Succeed(), "failed to start the canary")
+		// Resources created through the common helpers from here on are
+		// attributed to this spec by the leak tracker and the run report.
+		k8stest.SetCurrentSpecText(ginkgo.CurrentSpecReport().FullText())
+		runreport.SetCurrentSpec(ginkgo.CurrentSpecReport().FullText())
+	})
+	ginkgo.AfterEach(func() {
+		specText := ginkgo.CurrentSpecReport().FullText()
+		k8stest.SetCurrentSpecText("")
+		runreport.SetCurrentSpec("")
+		gomega.Expect(k8stest.AfterEachCheck(specText)).To(gomega.Succeed(),
+			"resources leaked by this spec")
+		gomega.Expect(canary.TakeFailures()).To(gomega.BeEmpty(),
+			"canary volume availability failures during this spec")
+	})
+
+	// JustAfterEach runs after every attempt of a spec, unlike
+	// ReportAfterEach which only sees the final outcome, so diagnostics
+	// are captured for the first failure even if a retry then passes.
+	ginkgo.JustAfterEach(func() {
+		report := ginkgo.CurrentSpecReport()
+		if report.Failed() && !diagnosed[report.FullText()] {
+			diagnosed[report.FullText()] = true
+			captureDiagnostics(description, report)
+		}
+	})
+
+	ginkgo.ReportAfterSuite("junit", func(report ginkgoTypes.Report) {
+		writeJUnitReport(description, report)
+		if err := runreport.Write(artefactName(description)); err != nil {
+			logf.Log.Info("failed to write run report", "error", err)
+		}
+	})
+
+	ginkgo.RunSpecs(t, description, suiteCfg, reporterCfg)
+}
+
+// sanitise a description for use in an artefact file name
+func artefactName(description string) string {
+	return strings.ToLower(strings.ReplaceAll(description, " ", "-"))
+}
+
+// captureDiagnostics records the state of the cluster at the point of a
+// spec failure into the reports directory.
+func captureDiagnostics(description string, report ginkgoTypes.SpecReport) {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		return
+	}
+	var diagnostics strings.Builder
+	diagnostics.WriteString(fmt.Sprintf("spec: %s\nattempt: %d\nfailure: %s\n",
+		report.FullText(), report.NumAttempts, report.Failure.Message))
+	for _, args := range [][]string{
+		{"get", "pods", "--all-namespaces", "-o", "wide"},
+		{"get", "pvc", "--all-namespaces"},
+		{"get", "pv"},
+		{"get", "events", "--all-namespaces", "--sort-by=.metadata.creationTimestamp"},
+	} {
+		out, err := kubectlshim.Run(args...)
+		diagnostics.WriteString(fmt.Sprintf("\n==== kubectl %s ====\n", strings.Join(args, " ")))
+		if err != nil {
+			diagnostics.WriteString(fmt.Sprintf("failed, error %v\n", err))
+		}
+		diagnostics.WriteString(out)
+	}
+	filename := path.Join(reportsDir,
+		fmt.Sprintf("diagnostics-%s-%d.txt", artefactName(description), len(diagnosed)))
+	if err := os.WriteFile(filename, []byte(diagnostics.String()), 0644); err != nil {
+		logf.Log.Info("failed to write diagnostics artefact", "file", filename, "error", err)
+	} else {
+		logf.Log.Info("captured failure diagnostics", "spec", report.FullText(), "artefact", filename)
+	}
+
+	// A full support bundle - pod logs, custom resources, events, node
+	// kernel logs - so the failure can be analysed without access to the
+	// cluster.
+	bundleTag := fmt.Sprintf("%s-%d", artefactName(description), len(diagnosed))
+	if bundleDir, err := k8stest.CollectSupportBundle(bundleTag); err != nil {
+		logf.Log.Info("failed to collect support bundle", "error", err)
+	} else if bundleDir != "" {
+		logf.Log.Info("collected support bundle", "spec", report.FullText(), "bundle", bundleDir)
+	}
+
+	// A single time-ordered stream of the component logs over the spec,
+	// so multi-component races can be analysed without manually aligning
+	// the per-pod logs.
+	mergedTag := fmt.Sprintf("%s-%d", artefactName(description), len(diagnosed))
+	if merged, err := k8stest.WriteMergedComponentLogs(mergedTag, report.StartTime); err != nil {
+		logf.Log.Info("failed to write merged log artefact", "error", err)
+	} else if merged != "" {
+		logf.Log.Info("captured merged component logs", "artefact", merged)
+	}
+}
@@ -0,0 +1,227 @@
+// Package restclient is a typed client for the mayastor control plane
+// REST API - volumes, pools, nodes and replicas - with configurable
+// retry and backoff, so tests can assert directly on control plane
+// state instead of scraping unstructured custom resources. The zero
+// instance is not usable; obtain one with New, typically through
+// k8stest.ControlPlaneRestClient which supplies the port-forwarded
+// endpoint.
+package restclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// ErrNotSupported is returned when the control plane does not serve the
+// requested endpoint, e.g. volume scaling on older deployments; callers
+// can then fall back to the custom resource route.
+var ErrNotSupported = errors.New("endpoint not supported by the control plane")
+
+// Volume is a volume as reported by the control plane.
+type Volume struct {
+	Uuid         string `json:"uuid"`
+	State        string `json:"state"`
+	Size         int64  `json:"size"`
+	ReplicaCount int    `json:"replicaCount"`
+	Node         string `json:"node"`
+}
+
+// Pool is a pool as reported by the control plane.
+type Pool struct {
+	Name     string   `json:"name"`
+	Node     string   `json:"node"`
+	Disks    []string `json:"disks"`
+	State    string   `json:"state"`
+	Capacity int64    `json:"capacity"`
+	Used     int64    `json:"used"`
+}
+
+// Node is an io-engine node as reported by the control plane.
+type Node struct {
+	Name         string `json:"name"`
+	GrpcEndpoint string `json:"grpcEndpoint"`
+	State        string `json:"state"`
+}
+
+// Nexus is a nexus as reported by the control plane.
+type Nexus struct {
+	Uuid      string `json:"uuid"`
+	Node      string `json:"node"`
+	State     string `json:"state"`
+	DeviceUri string `json:"deviceUri"`
+}
+
+// Replica is a replica as reported by the control plane.
+type Replica struct {
+	Uuid string `json:"uuid"`
+	Node string `json:"node"`
+	Pool string `json:"pool"`
+	Size int64  `json:"size"`
+	Uri  string `json:"uri"`
+}
+
+// Client issues typed requests against one control plane endpoint.
+type Client struct {
+	baseUrl string
+	client  http.Client
+	retries int
+	backoff time.Duration
+}
+
+// New returns a client for the control plane REST API rooted at
+// baseUrl, e.g. "http://127.0.0.1:3000", with retry behaviour from the
+// e2e configuration.
+func New(baseUrl string) *Client {
+	cfg := e2e_config.GetConfig().RestClient
+	return &Client{
+		baseUrl: baseUrl,
+		client:  http.Client{Timeout: time.Duration(cfg.TimeoutSecs) * time.Second},
+		retries: cfg.Retries,
+		backoff: time.Duration(cfg.BackoffMsecs) * time.Millisecond,
+	}
+}
+
+// retryable reports whether a request is worth repeating: transport
+// errors, server errors and throttling are; other client errors are
+// definitive.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError ||
+		resp.StatusCode == http.StatusTooManyRequests
+}
+
+// get issues a GET with retry and doubling backoff, decoding the JSON
+// response into the given value.
+func (c *Client) get(path string, into interface{}) error {
+	url := c.baseUrl + path
+	backoff := c.backoff
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = c.client.Get(url)
+		if !retryable(resp, err) || attempt >= c.retries {
+			break
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("GET %s failed after %d attempts, error %v", url, c.retries+1, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s failed, status %s", url, resp.Status)
+	}
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response of GET %s, error %v", url, err)
+	}
+	if err := json.Unmarshal(contents, into); err != nil {
+		return fmt.Errorf("failed to parse response of GET %s, error %v", url, err)
+	}
+	return nil
+}
+
+// put issues a PUT with a JSON body and the same retry behaviour as
+// get. A 404 or 405 maps to ErrNotSupported so callers can tell an
+// absent endpoint from a failing one.
+func (c *Client) put(path string, body interface{}) error {
+	url := c.baseUrl + path
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request body for PUT %s, error %v", url, err)
+	}
+	backoff := c.backoff
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		var req *http.Request
+		req, err = http.NewRequest(http.MethodPut, url, bytes.NewReader(encoded))
+		if err != nil {
+			return fmt.Errorf("failed to build PUT %s, error %v", url, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err = c.client.Do(req)
+		if !retryable(resp, err) || attempt >= c.retries {
+			break
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("PUT %s failed after %d attempts, error %v", url, c.retries+1, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return fmt.Errorf("PUT %s: %w", url, ErrNotSupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PUT %s failed, status %s", url, resp.Status)
+	}
+	return nil
+}
+
+// GetVolumes lists all volumes known to the control plane.
+func (c *Client) GetVolumes() ([]Volume, error) {
+	var volumes []Volume
+	err := c.get("/v1/volumes", &volumes)
+	return volumes, err
+}
+
+// GetVolume retrieves one volume by uuid.
+func (c *Client) GetVolume(uuid string) (*Volume, error) {
+	var volume Volume
+	if err := c.get("/v1/volumes/"+uuid, &volume); err != nil {
+		return nil, err
+	}
+	return &volume, nil
+}
+
+// GetPools lists all pools known to the control plane.
+func (c *Client) GetPools() ([]Pool, error) {
+	var pools []Pool
+	err := c.get("/v1/pools", &pools)
+	return pools, err
+}
+
+// GetNodes lists all io-engine nodes known to the control plane.
+func (c *Client) GetNodes() ([]Node, error) {
+	var nodes []Node
+	err := c.get("/v1/nodes", &nodes)
+	return nodes, err
+}
+
+// GetNexuses lists all nexuses known to the control plane.
+func (c *Client) GetNexuses() ([]Nexus, error) {
+	var nexuses []Nexus
+	err := c.get("/v1/nexuses", &nexuses)
+	return nexuses, err
+}
+
+// ScaleVolume sets the replica count of a volume through the scale
+// endpoint.
+func (c *Client) ScaleVolume(uuid string, replicaCount int) error {
+	return c.put(fmt.Sprintf("/v1/volumes/%s/scale", uuid),
+		map[string]int{"replicaCount": replicaCount})
+}
+
+// GetReplicas lists all replicas known to the control plane.
+func (c *Client) GetReplicas() ([]Replica, error) {
+	var replicas []Replica
+	err := c.get("/v1/replicas", &replicas)
+	return replicas, err
+}
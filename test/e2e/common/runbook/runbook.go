@@ -0,0 +1,67 @@
+// Package runbook encodes documented operational procedures as
+// executable step sequences with an assertion after every step, so the
+// runbooks published to users stay provably in sync with product
+// behaviour. Each step outcome is recorded in the run manifest.
+package runbook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Step is one numbered action of a runbook; Run performs the action
+// and returns an error if the documented outcome was not reached.
+type Step struct {
+	// Name matches the step heading in the published runbook.
+	Name string
+	Run  func() error
+}
+
+// Runbook is an executable rendition of one documented procedure.
+type Runbook struct {
+	// Name matches the title of the published runbook.
+	Name  string
+	Steps []Step
+}
+
+// StepResult records the outcome of one executed step.
+type StepResult struct {
+	Runbook      string `json:"runbook"`
+	Step         string `json:"step"`
+	DurationSecs int    `json:"durationSecs"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Execute runs the steps in order, stopping at the first failure, and
+// returns the per-step results alongside the failure, if any. Results
+// are also appended to the run manifest.
+func (rb Runbook) Execute() ([]StepResult, error) {
+	var results []StepResult
+	var failure error
+	for ix, step := range rb.Steps {
+		logf.Log.Info("runbook step", "runbook", rb.Name, "step", fmt.Sprintf("%d: %s", ix+1, step.Name))
+		start := time.Now()
+		err := step.Run()
+		result := StepResult{
+			Runbook:      rb.Name,
+			Step:         step.Name,
+			DurationSecs: int(time.Since(start).Seconds()),
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failure = fmt.Errorf("runbook %q failed at step %d (%s), error %v", rb.Name, ix+1, step.Name, err)
+		}
+		results = append(results, result)
+		if failure != nil {
+			break
+		}
+	}
+	if err := manifest.AppendSection("runbooks", results); err != nil {
+		logf.Log.Info("failed to record runbook results in the run manifest", "error", err)
+	}
+	return results, failure
+}
@@ -0,0 +1,236 @@
+// Package runreport accumulates a structured per-spec record of a test
+// run - the volumes each spec created, the state transitions the watch
+// framework observed, the faults injected and the fio results - and
+// writes it as json and html artefacts in the reports directory, so a
+// nightly failure can be triaged without replaying the logs. The common
+// helpers feed it as a side effect; suites do not call it directly.
+package runreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// Volume is one volume created by a spec.
+type Volume struct {
+	Time time.Time `json:"time"`
+	Name string    `json:"name"`
+	// StorageClass used for the claim, empty for the cluster default.
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// Transition is one watch event observed while a spec waited for a
+// state change.
+type Transition struct {
+	Time time.Time `json:"time"`
+	// Waiting describes what the spec was waiting for.
+	Waiting string `json:"waiting"`
+	// Event is the watch event type observed.
+	Event string `json:"event"`
+	// Resource is the name of the object the event concerned.
+	Resource string `json:"resource"`
+}
+
+// Fault is one fault injected during a spec.
+type Fault struct {
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Target string    `json:"target"`
+}
+
+// FioResult is the outcome of one fio run.
+type FioResult struct {
+	Time time.Time `json:"time"`
+	Pod  string    `json:"pod"`
+	// BandwidthBytes is the aggregate read+write bandwidth in bytes
+	// per second.
+	BandwidthBytes float64 `json:"bandwidthBytes"`
+}
+
+// SpecRecord collects everything recorded while one spec ran; an empty
+// spec text holds suite level setup.
+type SpecRecord struct {
+	Spec        string       `json:"spec"`
+	Volumes     []Volume     `json:"volumes,omitempty"`
+	Transitions []Transition `json:"transitions,omitempty"`
+	Faults      []Fault      `json:"faults,omitempty"`
+	FioResults  []FioResult  `json:"fioResults,omitempty"`
+}
+
+var mutex sync.Mutex
+var records = map[string]*SpecRecord{}
+var specOrder []string
+var currentSpec string
+
+// SetCurrentSpec records the spec now running, so subsequent records
+// are attributed to it. The reporter sets it from the ginkgo
+// lifecycle; an empty text marks suite level setup.
+func SetCurrentSpec(text string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	currentSpec = text
+}
+
+// current returns the record of the running spec, creating it on first
+// use; the caller holds the mutex.
+func current() *SpecRecord {
+	record, found := records[currentSpec]
+	if !found {
+		record = &SpecRecord{Spec: currentSpec}
+		records[currentSpec] = record
+		specOrder = append(specOrder, currentSpec)
+	}
+	return record
+}
+
+// RecordVolume notes a volume created by the running spec.
+func RecordVolume(name string, storageClass string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	record := current()
+	record.Volumes = append(record.Volumes, Volume{
+		Time: time.Now(), Name: name, StorageClass: storageClass,
+	})
+}
+
+// RecordTransition notes a watch event observed by the waiter
+// subsystem while the running spec waited for a state change.
+func RecordTransition(waiting string, event string, resource string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	record := current()
+	record.Transitions = append(record.Transitions, Transition{
+		Time: time.Now(), Waiting: waiting, Event: event, Resource: resource,
+	})
+}
+
+// RecordFault notes a fault injected during the running spec.
+func RecordFault(kind string, target string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	record := current()
+	record.Faults = append(record.Faults, Fault{
+		Time: time.Now(), Kind: kind, Target: target,
+	})
+}
+
+// RecordFioResult notes the outcome of a fio run of the running spec.
+func RecordFioResult(pod string, bandwidthBytes float64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	record := current()
+	record.FioResults = append(record.FioResults, FioResult{
+		Time: time.Now(), Pod: pod, BandwidthBytes: bandwidthBytes,
+	})
+}
+
+// Records returns a snapshot of the per-spec records in the order the
+// specs first recorded something.
+func Records() []SpecRecord {
+	mutex.Lock()
+	defer mutex.Unlock()
+	var snapshot []SpecRecord
+	for _, spec := range specOrder {
+		snapshot = append(snapshot, *records[spec])
+	}
+	return snapshot
+}
+
+// Reset discards all accumulated records; used by framework tests.
+func Reset() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	records = map[string]*SpecRecord{}
+	specOrder = nil
+	currentSpec = ""
+}
+
+// htmlTemplate renders the report as a single self-contained page, one
+// section per spec.
+var htmlTemplate = template.Must(template.New("runreport").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Records}}
+<h2>{{if .Spec}}{{.Spec}}{{else}}suite setup{{end}}</h2>
+{{if .Volumes}}
+<h3>volumes</h3>
+<table>
+<tr><th>time</th><th>name</th><th>storage class</th></tr>
+{{range .Volumes}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Name}}</td><td>{{.StorageClass}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Transitions}}
+<h3>transitions</h3>
+<table>
+<tr><th>time</th><th>waiting for</th><th>event</th><th>resource</th></tr>
+{{range .Transitions}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Waiting}}</td><td>{{.Event}}</td><td>{{.Resource}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Faults}}
+<h3>faults</h3>
+<table>
+<tr><th>time</th><th>kind</th><th>target</th></tr>
+{{range .Faults}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Kind}}</td><td>{{.Target}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .FioResults}}
+<h3>fio results</h3>
+<table>
+<tr><th>time</th><th>pod</th><th>bandwidth (bytes/s)</th></tr>
+{{range .FioResults}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Pod}}</td><td>{{printf "%.0f" .BandwidthBytes}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// Write renders the accumulated records into the reports directory, as
+// runreport-<description>.json and .html. A no-op if no reports
+// directory is configured.
+func Write(description string) error {
+	reportsDir := e2e_config.GetConfig().ReportsDir
+	if reportsDir == "" {
+		return nil
+	}
+	snapshot := Records()
+
+	contents, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run report, error %v", err)
+	}
+	jsonFile := path.Join(reportsDir, fmt.Sprintf("runreport-%s.json", description))
+	if err := os.WriteFile(jsonFile, contents, 0644); err != nil {
+		return fmt.Errorf("failed to write run report %s, error %v", jsonFile, err)
+	}
+
+	htmlFile := path.Join(reportsDir, fmt.Sprintf("runreport-%s.html", description))
+	rendered, err := os.Create(htmlFile)
+	if err != nil {
+		return fmt.Errorf("failed to create run report %s, error %v", htmlFile, err)
+	}
+	defer func() { _ = rendered.Close() }()
+	if err := htmlTemplate.Execute(rendered, struct {
+		Title   string
+		Records []SpecRecord
+	}{Title: description, Records: snapshot}); err != nil {
+		return fmt.Errorf("failed to render run report %s, error %v", htmlFile, err)
+	}
+	return nil
+}
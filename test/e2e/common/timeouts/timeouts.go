@@ -0,0 +1,39 @@
+// Package timeouts is the single accessor for the configured wait
+// budgets, so helpers and suites share one set of tunables instead of
+// scattering timeout literals, and slow hardware is accommodated by
+// configuration alone.
+package timeouts
+
+import (
+	"fmt"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// PvcBindSecs is the wait budget for a claim to bind.
+func PvcBindSecs() int {
+	return e2e_config.GetConfig().Timeouts.PvcBindSecs
+}
+
+// PodReadySecs is the wait budget for a test pod to run.
+func PodReadySecs() int {
+	return e2e_config.GetConfig().Timeouts.PodReadySecs
+}
+
+// UninstallSecs bounds a mayastor uninstall.
+func UninstallSecs() int {
+	return e2e_config.GetConfig().Timeouts.UninstallSecs
+}
+
+// UninstallDuration is the uninstall budget in the duration string form
+// command line tools such as helm take.
+func UninstallDuration() string {
+	return fmt.Sprintf("%ds", UninstallSecs())
+}
+
+// RebuildSecsPerGib is the rebuild time allowance per GiB of volume
+// size; k8stest.RebuildTimeoutSecs derives volume specific budgets
+// from it.
+func RebuildSecsPerGib() int {
+	return e2e_config.GetConfig().Rebuild.SecsPerGib
+}
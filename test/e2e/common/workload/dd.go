@@ -0,0 +1,66 @@
+package workload
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+)
+
+// ddWorkload streams a seed file to the volume with dd in a loop; the
+// seed checksum is recorded on the volume so Verify can compare the last
+// copy against it.
+type ddWorkload struct {
+	podWorkload
+}
+
+// ddStartScript seeds 64MiB of random data, records its checksum, then
+// keeps copying it onto the volume until the deadline passes.
+const ddStartScript = `
+set -e
+end=$(( $(date +%s) + $1 ))
+dd if=/dev/urandom of=/volume/workload-seed bs=1M count=64 conv=fsync 2> /dev/null
+md5sum /volume/workload-seed | cut -d' ' -f1 > /volume/workload-seed.md5
+while [ $(date +%s) -lt $end ]; do
+	dd if=/volume/workload-seed of=/volume/workload-copy bs=1M conv=fsync 2> /dev/null
+done
+`
+
+// ddVerifyScript compares the last copy against the recorded seed
+// checksum.
+const ddVerifyScript = `
+set -e
+sum=$(md5sum /volume/workload-copy | cut -d' ' -f1)
+test "$sum" = "$(cat /volume/workload-seed.md5)"
+`
+
+func (w *ddWorkload) Name() string {
+	return "dd"
+}
+
+func (w *ddWorkload) MakePod(podName string, volName string, nameSpace string) error {
+	w.podName = podName
+	w.nameSpace = nameSpace
+	podDef := k8stest.NewVolumePodSpec(podName, volName, e2e_config.GetConfig().E2eFioImage,
+		[]string{"sleep", "1000000"})
+	if _, err := k8stest.CreatePod(podDef, nameSpace); err != nil {
+		return fmt.Errorf("failed to create dd workload pod %s, error %v", podName, err)
+	}
+	return k8stest.WaitPodRunning(podName, nameSpace, makePodTimeoutSecs)
+}
+
+func (w *ddWorkload) Start(durationSecs int) {
+	w.start(func() error {
+		_, err := k8stest.ExecPod(w.podName, w.nameSpace,
+			"sh", "-c", ddStartScript, "dd-workload", strconv.Itoa(durationSecs))
+		return err
+	})
+}
+
+func (w *ddWorkload) Verify() error {
+	if _, err := k8stest.ExecPod(w.podName, w.nameSpace, "sh", "-c", ddVerifyScript); err != nil {
+		return fmt.Errorf("dd verification failed in pod %s, error %v", w.podName, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package workload
+
+import (
+	"fmt"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+)
+
+// fioWorkload drives fio random writes with crc32 verification headers,
+// so Verify can re-read and check every written block.
+type fioWorkload struct {
+	podWorkload
+}
+
+func (w *fioWorkload) Name() string {
+	return "fio"
+}
+
+func (w *fioWorkload) MakePod(podName string, volName string, nameSpace string) error {
+	w.podName = podName
+	w.nameSpace = nameSpace
+	if _, err := k8stest.CreateFioPod(podName, volName, nameSpace); err != nil {
+		return fmt.Errorf("failed to create fio workload pod %s, error %v", podName, err)
+	}
+	return k8stest.WaitPodRunning(podName, nameSpace, makePodTimeoutSecs)
+}
+
+// fioArgs are shared between the write pass and the verify pass; fio
+// requires them to match for verify_only to locate the headers.
+func fioArgs() []string {
+	return []string{
+		"--name=workload", "--rw=randwrite", "--direct=1", "--ioengine=libaio",
+		"--bs=4k", "--iodepth=16", "--numjobs=1",
+		"--verify=crc32", "--filename=/volume/workload-fio", "--size=64m",
+	}
+}
+
+func (w *fioWorkload) Start(durationSecs int) {
+	w.start(func() error {
+		args := append([]string{"fio"}, fioArgs()...)
+		args = append(args, "--time_based", fmt.Sprintf("--runtime=%d", durationSecs))
+		_, err := k8stest.ExecPod(w.podName, w.nameSpace, args...)
+		return err
+	})
+}
+
+func (w *fioWorkload) Verify() error {
+	args := append([]string{"fio"}, fioArgs()...)
+	args = append(args, "--verify_only")
+	if _, err := k8stest.ExecPod(w.podName, w.nameSpace, args...); err != nil {
+		return fmt.Errorf("fio verification failed in pod %s, error %v", w.podName, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package workload
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+)
+
+// sqliteWorkload exercises the volume with database style I/O: small
+// transactional inserts into a sqlite database on the volume, verified
+// with the sqlite integrity check.
+type sqliteWorkload struct {
+	podWorkload
+}
+
+// sqliteStartScript inserts 4KiB rows until the deadline passes.
+const sqliteStartScript = `
+set -e
+end=$(( $(date +%s) + $1 ))
+sqlite3 /volume/workload.db 'create table if not exists records (k integer primary key autoincrement, v blob);'
+while [ $(date +%s) -lt $end ]; do
+	sqlite3 /volume/workload.db 'insert into records (v) values (randomblob(4096));'
+done
+`
+
+// sqliteVerifyScript runs the database integrity check and requires at
+// least one row to have been written.
+const sqliteVerifyScript = `
+set -e
+test "$(sqlite3 /volume/workload.db 'pragma integrity_check;')" = "ok"
+test "$(sqlite3 /volume/workload.db 'select count(*) from records;')" -gt 0
+`
+
+func (w *sqliteWorkload) Name() string {
+	return "sqlite"
+}
+
+func (w *sqliteWorkload) MakePod(podName string, volName string, nameSpace string) error {
+	w.podName = podName
+	w.nameSpace = nameSpace
+	podDef := k8stest.NewVolumePodSpec(podName, volName, e2e_config.GetConfig().E2eSqliteImage,
+		[]string{"sleep", "1000000"})
+	if _, err := k8stest.CreatePod(podDef, nameSpace); err != nil {
+		return fmt.Errorf("failed to create sqlite workload pod %s, error %v", podName, err)
+	}
+	return k8stest.WaitPodRunning(podName, nameSpace, makePodTimeoutSecs)
+}
+
+func (w *sqliteWorkload) Start(durationSecs int) {
+	w.start(func() error {
+		_, err := k8stest.ExecPod(w.podName, w.nameSpace,
+			"sh", "-c", sqliteStartScript, "sqlite-workload", strconv.Itoa(durationSecs))
+		return err
+	})
+}
+
+func (w *sqliteWorkload) Verify() error {
+	if _, err := k8stest.ExecPod(w.podName, w.nameSpace, "sh", "-c", sqliteVerifyScript); err != nil {
+		return fmt.Errorf("sqlite verification failed in pod %s, error %v", w.podName, err)
+	}
+	return nil
+}
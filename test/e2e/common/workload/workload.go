@@ -0,0 +1,75 @@
+// Package workload abstracts the I/O generator run against a volume, so
+// suites can be parameterised over fio, dd based streaming or a database
+// style workload from the e2e configuration instead of hardcoding fio
+// and its argument list.
+package workload
+
+import (
+	"fmt"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+)
+
+// makePodTimeoutSecs bounds the wait for a workload pod to come up.
+const makePodTimeoutSecs = 240
+
+// Workload is one I/O generator bound to a volume.
+type Workload interface {
+	// Name returns the workload name as used in the configuration.
+	Name() string
+	// MakePod creates the workload pod with the volume mounted and
+	// waits for it to be running.
+	MakePod(podName string, volName string, nameSpace string) error
+	// Start kicks off the workload asynchronously; it writes to the
+	// volume for durationSecs.
+	Start(durationSecs int)
+	// Wait blocks until a started workload completes and returns its
+	// error, if any.
+	Wait() error
+	// Verify re-reads the data written by the workload and checks its
+	// integrity.
+	Verify() error
+}
+
+// New returns the named workload implementation.
+func New(name string) (Workload, error) {
+	switch name {
+	case "fio":
+		return &fioWorkload{}, nil
+	case "dd":
+		return &ddWorkload{}, nil
+	case "sqlite":
+		return &sqliteWorkload{}, nil
+	}
+	return nil, fmt.Errorf("unknown workload %q", name)
+}
+
+// Default returns the workload selected by the e2e configuration.
+func Default() (Workload, error) {
+	return New(e2e_config.GetConfig().DefaultWorkload)
+}
+
+// podWorkload carries the pod identity and completion channel shared by
+// the workload implementations.
+type podWorkload struct {
+	podName   string
+	nameSpace string
+	done      chan error
+}
+
+// start runs the workload body in a goroutine, delivering its error to
+// Wait.
+func (w *podWorkload) start(run func() error) {
+	w.done = make(chan error, 1)
+	go func() {
+		w.done <- run()
+	}()
+}
+
+// Wait blocks until a started workload completes.
+func (w *podWorkload) Wait() error {
+	if w.done == nil {
+		return fmt.Errorf("workload in pod %s was not started", w.podName)
+	}
+	return <-w.done
+}
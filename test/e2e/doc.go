@@ -0,0 +1,29 @@
+// Package e2e is the root of the mayastor end-to-end test library and
+// suites.
+//
+// The packages under common/ form the importable test library:
+//
+//   - common/e2e_config: run configuration, loaded from yaml and the
+//     environment, with per-suite override scopes.
+//   - common/k8stest: cluster helpers — storage classes, PVCs, pods,
+//     fio workloads, mayastor custom resources, fault injection.
+//   - common/locations: locates scripts and generated yamls, whether
+//     running from the source tree or from a packaged binary.
+//   - common/deploy: renders mayastor install yamls from templates.
+//   - common/reporter: ginkgo suite runner with junit output, failure
+//     diagnostics and the canary volume; the only package which
+//     requires ginkgo, everything else returns plain errors so
+//     downstream consumers can use their own test framework.
+//
+// External consumers import these with the canonical module path, e.g.
+//
+//	import "github.com/openebs/mayastor/test/e2e/common/k8stest"
+//
+// and call k8stest.SetupTestEnv() before using the cluster helpers.
+// The suites under src/ consume the library the same way and double as
+// usage examples.
+//
+// Exported identifiers in common/ are the supported API surface;
+// breaking changes to them are flagged in release notes. Everything
+// under src/ is internal to the mayastor CI and may change freely.
+package e2e
@@ -0,0 +1,106 @@
+// Air-gapped installation test. CI mirrors all images into the
+// in-cluster registry beforehand; with airGap.enabled the
+// configuration points the deployment and the test workloads at that
+// registry. The suite blocks all egress from the test and mayastor
+// namespaces with NetworkPolicies, then provisions a volume and runs
+// IO, proving the documented air-gapped install instructions work
+// without reaching any external registry. Skipped unless air-gapped
+// mode is configured.
+package air_gapped_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/deploy"
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 300
+const fioRunTimeSecs = 30
+const volSizeMb = 256
+
+const egressPolicyName = "e2e-air-gap-deny-egress"
+
+func airGappedTest() {
+	cfg := e2e_config.GetConfig()
+	if !cfg.AirGap.Enabled {
+		Skip("air-gapped mode not configured")
+	}
+	Expect(cfg.AirGap.Registry).ToNot(BeEmpty(), "air-gapped mode enabled without a registry")
+
+	// The generated install yamls and the test workload image must all
+	// reference the in-cluster registry.
+	params := deploy.ParamsFromConfig()
+	Expect(params.ImageName("mayastor")).To(HavePrefix(cfg.AirGap.Registry+"/"),
+		"deployment images not redirected to the air-gap registry")
+	Expect(strings.HasPrefix(cfg.E2eFioImage, cfg.AirGap.Registry+"/")).To(BeTrue(),
+		"fio image not redirected to the air-gap registry")
+
+	By("blocking all egress from the test and mayastor namespaces")
+	for _, ns := range []string{nameSpace, k8stest.MayastorNamespace} {
+		Expect(k8stest.ApplyDenyEgressPolicy(egressPolicyName, ns, map[string]string{})).To(Succeed())
+	}
+	defer func() {
+		for _, ns := range []string{nameSpace, k8stest.MayastorNamespace} {
+			Expect(k8stest.RemoveNetworkPolicy(egressPolicyName, ns)).To(Succeed())
+		}
+	}()
+
+	scName := "air-gap-sc"
+	volName := "air-gap-vol"
+	podName := "air-gap-fio"
+	Expect(k8stest.MkStorageClass(scName, cfg.DefaultReplicaCount, "nvmf")).To(Succeed())
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed(),
+		"provisioning failed with egress blocked")
+
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed(),
+		"workload image did not pull from the in-cluster registry")
+
+	pod, err := k8stest.GetPod(podName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	for _, container := range pod.Spec.Containers {
+		Expect(container.Image).To(HavePrefix(cfg.AirGap.Registry+"/"),
+			fmt.Sprintf("container %s pulled from outside the air gap", container.Name))
+	}
+
+	_, err = k8stest.RunFio(podName, nameSpace, fioRunTimeSecs)
+	Expect(err).ToNot(HaveOccurred(), "IO failed in the air-gapped deployment")
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestAirGapped(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Air-gapped install")
+}
+
+var _ = Describe("Air-gapped installation", func() {
+	It("should provision and serve IO with egress blocked", func() {
+		airGappedTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
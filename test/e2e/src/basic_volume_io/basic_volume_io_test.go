@@ -0,0 +1,190 @@
+// Basic volume I/O. The suite provisions a volume, runs the workload
+// generator selected by the e2e configuration against it and verifies
+// the written data, covering the provision, attach, I/O and verify path
+// with whichever generator the run is parameterised with. The whole
+// sequence is repeated over the configured protocol, volume type and
+// binding mode matrix generated by the common matrix runner.
+// A remount after the workload verifies the unmount left the
+// filesystem clean; on iSCSI the initiator sessions are checked for a
+// clean login and logout.
+package basic_volume_io_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/iscsi"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/matrix"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+	"github.com/openebs/mayastor/test/e2e/common/timeouts"
+	"github.com/openebs/mayastor/test/e2e/common/workload"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 240
+
+const volSizeMb = 512
+const ioDurationSecs = 120
+
+// waitPodGone waits for the deleted workload pod to terminate, so the
+// volume is unmounted before it is mounted again.
+func waitPodGone(podName string) {
+	const sleepTime = 2
+	for ix := 0; ix < defTimeoutSecs/sleepTime; ix++ {
+		if _, err := k8stest.GetPod(podName, nameSpace); err != nil {
+			return
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	Fail(fmt.Sprintf("pod %s still present after %d seconds", podName, defTimeoutSecs))
+}
+
+// validateIscsiSessions asserts the node running the given pod holds an
+// iSCSI session for the volume, so the attach really logged in rather
+// than e.g. reusing a stale device.
+func validateIscsiSessions(podName string, uuid string) {
+	pod, err := k8stest.GetPod(podName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(iscsi.ValidateLogin(pod.Spec.NodeName, uuid)).To(Succeed())
+}
+
+func basicVolumeIoTest(cell matrix.Case) {
+	generator, err := workload.Default()
+	Expect(err).ToNot(HaveOccurred(), "invalid workload %q in the e2e configuration",
+		e2e_config.GetConfig().DefaultWorkload)
+
+	scName := fmt.Sprintf("basic-volume-io-%s-sc", cell.Suffix())
+	volName := fmt.Sprintf("basic-volume-io-%s-vol", cell.Suffix())
+	Expect(cell.ApplyTo(k8stest.NewStorageClassBuilder(scName).
+		WithReplicas(k8stest.DefaultReplicaCount())).
+		Build()).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+
+	// The pod is created before waiting on the claim: with late binding
+	// the claim only binds once a consumer is scheduled.
+	podName := volName + "-client"
+	Expect(generator.MakePod(podName, volName, nameSpace)).To(Succeed())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, timeouts.PvcBindSecs())).To(Succeed())
+
+	if cell.Protocol == "iscsi" {
+		uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+		validateIscsiSessions(podName, uuid)
+	}
+
+	By("running the " + generator.Name() + " workload")
+	generator.Start(ioDurationSecs)
+	Expect(generator.Wait()).To(Succeed(), "%s workload failed", generator.Name())
+
+	By("verifying the written data")
+	Expect(generator.Verify()).To(Succeed(), "%s verification failed", generator.Name())
+
+	// Deleting the pod unmounts the volume; kubelet runs fsck on the
+	// device before mounting it again, so a successful remount asserts
+	// the unmount left a clean filesystem.
+	By("remounting the volume after a clean unmount")
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	waitPodGone(podName)
+	remountPodName := volName + "-remount"
+	Expect(generator.MakePod(remountPodName, volName, nameSpace)).To(Succeed())
+	defer func() {
+		_ = k8stest.DeletePod(remountPodName, nameSpace)
+	}()
+	Expect(generator.Verify()).To(Succeed(), "%s verification failed after remount", generator.Name())
+}
+
+// rawBlockVolumeIoTest runs fio directly against the volume device of a
+// raw block claim; the file based workload generators do not apply. On
+// iSCSI the initiator is additionally checked for a session while the
+// pod holds the device and for a clean logout once it is gone.
+func rawBlockVolumeIoTest(cell matrix.Case) {
+	scName := fmt.Sprintf("basic-volume-io-%s-sc", cell.Suffix())
+	volName := fmt.Sprintf("basic-volume-io-%s-vol", cell.Suffix())
+	Expect(cell.ApplyTo(k8stest.NewStorageClassBuilder(scName).
+		WithReplicas(k8stest.DefaultReplicaCount())).
+		Build()).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err := k8stest.CreateRawBlockPVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+
+	podName := volName + "-client"
+	_, err = k8stest.CreateRawBlockFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, timeouts.PvcBindSecs())).To(Succeed())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, timeouts.PodReadySecs())).To(Succeed())
+
+	By("verifying the volume device is present in the pod")
+	_, err = k8stest.ExecPod(podName, nameSpace, "ls", k8stest.RawBlockDevicePath)
+	Expect(err).ToNot(HaveOccurred(), "volume device %s not present in pod %s",
+		k8stest.RawBlockDevicePath, podName)
+
+	uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pod, err := k8stest.GetPod(podName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	appNode := pod.Spec.NodeName
+	if cell.Protocol == "iscsi" {
+		Expect(iscsi.ValidateLogin(appNode, uuid)).To(Succeed())
+	}
+
+	By("running fio against the volume device")
+	_, err = k8stest.RunFioRawBlock(podName, nameSpace, ioDurationSecs, "--verify=crc32c")
+	Expect(err).ToNot(HaveOccurred(), "fio failed on the raw block volume")
+
+	By("detaching the volume")
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	waitPodGone(podName)
+	if cell.Protocol == "iscsi" {
+		Expect(iscsi.ValidateLogout(appNode, uuid)).To(Succeed())
+	}
+}
+
+func TestBasicVolumeIo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Basic volume I/O")
+}
+
+var _ = Describe("Mayastor basic volume I/O", func() {
+	matrix.ItPerCase("should run and verify the workload on a volume", func(cell matrix.Case) {
+		if cell.RawBlock() {
+			rawBlockVolumeIoTest(cell)
+		} else {
+			basicVolumeIoTest(cell)
+		}
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
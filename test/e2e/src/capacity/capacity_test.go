@@ -0,0 +1,137 @@
+// Capacity accounting tests: pool usage must rise when volumes are
+// provisioned, agree with the sizes of the replicas placed on the pools,
+// and return to its starting point when the volumes are deleted - for
+// both thick and thin provisioning.
+package capacity_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 120
+
+// volSizesMb are the volume sizes provisioned together, varied so the
+// accounting is checked against an uneven aggregate rather than a
+// multiple of one size.
+var volSizesMb = []int{64, 128, 256}
+
+// waitPoolUsage polls until the cluster-wide pool usage drops back to
+// the given level, returning an error with the figures on timeout.
+func waitPoolUsage(wantUsedBytes int64, timeoutSecs int) error {
+	const sleepTime = 2
+	var usedBytes int64
+	for ix := 0; ix < (timeoutSecs+sleepTime-1)/sleepTime; ix++ {
+		total, err := k8stest.TotalPoolUsage()
+		if err != nil {
+			return err
+		}
+		usedBytes = total.UsedBytes
+		if usedBytes <= wantUsedBytes {
+			return nil
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	return fmt.Errorf("pool usage is %d bytes, expected it to return to %d bytes", usedBytes, wantUsedBytes)
+}
+
+// capacityTest provisions a set of volumes, verifies the pool accounting
+// while they exist, deletes them and verifies the space is returned.
+func capacityTest(thin bool) {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	if len(pools) == 0 {
+		Skip("cluster has no mayastor pools")
+	}
+	baseline, err := k8stest.TotalPoolUsage()
+	Expect(err).ToNot(HaveOccurred())
+
+	scName := "capacity-sc"
+	params := map[string]string{}
+	if thin {
+		scName = "capacity-thin-sc"
+		params["thin"] = "true"
+	}
+	Expect(k8stest.MkStorageClassWithParams(scName, 1, "nvmf", params)).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	var volSumBytes int64
+	for ix, volSizeMb := range volSizesMb {
+		volName := fmt.Sprintf("capacity-vol-%d", ix)
+		_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+		volSumBytes += int64(volSizeMb) * 1024 * 1024
+	}
+	defer func() {
+		for ix := range volSizesMb {
+			_ = k8stest.DeletePVC(fmt.Sprintf("capacity-vol-%d", ix), nameSpace)
+		}
+	}()
+	for ix := range volSizesMb {
+		volName := fmt.Sprintf("capacity-vol-%d", ix)
+		Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	}
+
+	// With thick provisioning the whole volume is allocated up front;
+	// with thin provisioning nothing has been written yet, so allocation
+	// must stay below the nominal volume sizes.
+	total, err := k8stest.TotalPoolUsage()
+	Expect(err).ToNot(HaveOccurred())
+	deltaBytes := total.UsedBytes - baseline.UsedBytes
+	if thin {
+		Expect(deltaBytes).To(BeNumerically("<", volSumBytes),
+			"thin volumes allocated %d bytes up front for %d nominal bytes", deltaBytes, volSumBytes)
+	} else {
+		Expect(deltaBytes).To(BeNumerically(">=", volSumBytes),
+			"thick volumes allocated %d bytes for %d nominal bytes", deltaBytes, volSumBytes)
+	}
+
+	// The per-pool usage must be accounted for by the replicas placed
+	// on each pool.
+	mismatches, err := k8stest.CheckReplicaAccounting(thin)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(mismatches).To(BeEmpty(), "pool usage disagrees with replica sizes")
+
+	// Deleting the volumes must return the space to the pools.
+	for ix := range volSizesMb {
+		Expect(k8stest.DeletePVC(fmt.Sprintf("capacity-vol-%d", ix), nameSpace)).To(Succeed())
+	}
+	Expect(waitPoolUsage(baseline.UsedBytes, defTimeoutSecs)).To(Succeed())
+	if baseline.UsedBytes == 0 {
+		Expect(k8stest.CheckPoolsUnused()).To(Succeed())
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Capacity accounting")
+}
+
+var _ = Describe("Mayastor pool capacity accounting", func() {
+	It("should account for thick provisioned volumes and release the space on deletion", func() {
+		capacityTest(false)
+	})
+
+	It("should account for thin provisioned volumes and release the space on deletion", func() {
+		capacityTest(true)
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
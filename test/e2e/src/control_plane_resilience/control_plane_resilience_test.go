@@ -0,0 +1,138 @@
+// Control plane store resilience. Motivated by field reports of etcd
+// clusters never recovering after an outage: the suite takes etcd down
+// entirely while a volume is serving I/O, verifies the data path is
+// unaffected and that a provisioning request issued during the outage
+// completes once etcd is back, then kills the whole etcd quorum at once
+// and requires it to re-form.
+package control_plane_resilience_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 300
+
+const scName = "cp-resilience-sc"
+const volName = "cp-resilience-vol"
+const outageVolName = "cp-resilience-outage-vol"
+const volSizeMb = 512
+
+// ioDuringOutageSecs is how long fio runs while etcd is down.
+const ioDuringOutageSecs = 60
+
+func etcdOutageTest() {
+	if _, err := k8stest.GetStatefulSet(k8stest.EtcdStatefulSetName, k8stest.MayastorNamespace); err != nil {
+		Skip("cluster has no etcd statefulset")
+	}
+
+	Expect(k8stest.MkStorageClass(scName, k8stest.DefaultReplicaCount(), "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	podName := volName + "-client"
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	replicas, err := k8stest.EtcdReplicaCount()
+	Expect(err).ToNot(HaveOccurred())
+
+	By("taking etcd down")
+	Expect(k8stest.ScaleStatefulSet(k8stest.EtcdStatefulSetName, k8stest.MayastorNamespace, 0)).To(Succeed())
+	defer func() {
+		// etcd must be restored whatever the outcome.
+		_ = k8stest.ScaleStatefulSet(k8stest.EtcdStatefulSetName, k8stest.MayastorNamespace, replicas)
+		Expect(k8stest.WaitEtcdHealthy(defTimeoutSecs)).To(Succeed())
+	}()
+
+	By("running I/O through the outage")
+	_, err = k8stest.ExecPod(podName, nameSpace, "fio",
+		"--name=outage", "--rw=randwrite", "--direct=1", "--ioengine=libaio",
+		"--bs=4k", "--iodepth=16", "--numjobs=1",
+		"--filename=/volume/fio-data", "--size=128m", "--verify=crc32",
+		"--time_based", fmt.Sprintf("--runtime=%d", ioDuringOutageSecs))
+	Expect(err).ToNot(HaveOccurred(), "I/O failed while etcd was down")
+
+	// A provisioning request issued during the outage must not be lost;
+	// it completes once etcd is back.
+	By("provisioning during the outage")
+	_, err = k8stest.CreatePVC(outageVolName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(outageVolName, nameSpace)
+	}()
+
+	By("restoring etcd")
+	Expect(k8stest.ScaleStatefulSet(k8stest.EtcdStatefulSetName, k8stest.MayastorNamespace, replicas)).To(Succeed())
+	Expect(k8stest.WaitEtcdHealthy(defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.WaitPvcBound(outageVolName, nameSpace, defTimeoutSecs)).To(Succeed(),
+		"provisioning did not resume after the etcd outage")
+
+	// A full quorum kill must re-form without intervention.
+	By("killing the whole etcd quorum at once")
+	Expect(k8stest.DeleteAllEtcdPods()).To(Succeed())
+	Expect(k8stest.WaitEtcdHealthy(defTimeoutSecs)).To(Succeed())
+}
+
+func TestControlPlaneResilience(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Control plane store resilience")
+}
+
+// natsRestartTest bounces the NATS message bus and verifies volumes do
+// not degrade just because the bus went away for a while.
+func natsRestartTest() {
+	if _, err := k8stest.GetDeployment(k8stest.NatsDeploymentName, k8stest.MayastorNamespace); err != nil {
+		Skip("cluster has no NATS deployment")
+	}
+	By("restarting NATS")
+	Expect(k8stest.ScaleDeployment(k8stest.NatsDeploymentName, k8stest.MayastorNamespace, 0)).To(Succeed())
+	Expect(k8stest.WaitPodsGoneBySelector(k8stest.MayastorNamespace, "app="+k8stest.NatsDeploymentName, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.ScaleDeployment(k8stest.NatsDeploymentName, k8stest.MayastorNamespace, 1)).To(Succeed())
+	Expect(k8stest.WaitDeploymentReady(k8stest.NatsDeploymentName, k8stest.MayastorNamespace, defTimeoutSecs)).To(Succeed())
+
+	msvs, err := k8stest.ListMsv()
+	Expect(err).ToNot(HaveOccurred())
+	for _, msv := range msvs {
+		Expect(msv.Status.State).ToNot(Equal("faulted"),
+			"MSV %s faulted across the NATS restart", msv.Name)
+	}
+}
+
+var _ = Describe("Mayastor control plane store resilience", func() {
+	It("should serve I/O through an etcd outage and resume provisioning after it", func() {
+		etcdOutageTest()
+	})
+
+	It("should tolerate a NATS message bus restart", func() {
+		natsRestartTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,105 @@
+// Disaster recovery test: the control plane volume records are wiped
+// while replica data remains on the pools, the control plane is
+// restarted to re-import pools and replicas, and the documented
+// adoption procedure (encoded in the k8stest library) is exercised to
+// re-establish access; data accessibility is verified afterwards.
+package control_plane_wipe_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 300
+const volSizeMb = 512
+
+func controlPlaneWipeTest() {
+	scName := "cp-wipe-sc"
+	Expect(k8stest.MkStorageClassWithReclaimPolicy(scName, e2e_config.GetConfig().DefaultReplicaCount,
+		"nvmf", coreV1.PersistentVolumeReclaimRetain)).To(Succeed())
+
+	// Provision a volume and stamp it with data.
+	volName := "cp-wipe-vol"
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	pvc, err := k8stest.GetPVC(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pv, err := k8stest.GetPV(pvc.Spec.VolumeName)
+	Expect(err).ToNot(HaveOccurred())
+	volumeHandle := pv.Spec.CSI.VolumeHandle
+
+	podName := "cp-wipe-fio"
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+	_, err = k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		"dd if=/dev/urandom of=/volume/data bs=1M count=32 && sync")
+	Expect(err).ToNot(HaveOccurred())
+	sumBefore, err := k8stest.ExecPod(podName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred())
+
+	// Unmount and drop the kubernetes objects, then wipe the control
+	// plane volume records. Replica data stays on the pools.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.WaitPvPhase(pv.Name, coreV1.VolumeReleased, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.DeletePV(pv.Name)).To(Succeed())
+
+	By("wiping the control plane volume records")
+	Expect(k8stest.WipeControlPlaneVolumes()).To(Succeed())
+	By("restarting the control plane to re-import pools and replicas")
+	Expect(k8stest.RestartControlPlane(defTimeoutSecs)).To(Succeed())
+
+	// Exercise the documented adoption procedure and verify the data
+	// survived.
+	By("adopting the surviving volume")
+	adoptedVolName := "cp-wipe-adopted-vol"
+	Expect(k8stest.AdoptVolume(volumeHandle, adoptedVolName, volSizeMb, scName, "nvmf",
+		nameSpace, defTimeoutSecs)).To(Succeed())
+
+	adoptedPodName := "cp-wipe-fio-adopted"
+	_, err = k8stest.CreateFioPod(adoptedPodName, adoptedVolName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(adoptedPodName, nameSpace, defTimeoutSecs)).To(Succeed())
+	sumAfter, err := k8stest.ExecPod(adoptedPodName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred(), "failed to read back data on the adopted volume")
+	Expect(sumAfter).To(Equal(sumBefore), "data lost across control plane wipe and adoption")
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(adoptedPodName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(adoptedVolName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePV("adopted-" + volumeHandle)).To(Succeed())
+	Expect(k8stest.DeleteMsv(volumeHandle)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestControlPlaneWipe(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Control plane wipe")
+}
+
+var _ = Describe("Volume adoption after control plane wipe", func() {
+	It("should re-import and serve data after the volume records are wiped", func() {
+		controlPlaneWipeTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,81 @@
+// CSI capabilities conformance check. The e2e suites make assumptions
+// about the mayastor CSI driver: volumes are staged before publish,
+// attach goes through the controller, and volume expansion is not
+// supported. This suite reads the CSIDriver object and queries the node
+// plugin's advertised capabilities over its socket, failing early when
+// the driver and the tests drift apart.
+package csi_capabilities_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	storageV1 "k8s.io/api/storage/v1"
+)
+
+func csiDriverObjectTest() {
+	driver, err := k8stest.GetCsiDriver(k8stest.CSIProvisioner)
+	Expect(err).ToNot(HaveOccurred(), "CSIDriver object not registered")
+
+	// Attach goes through the control plane; the volume is published to
+	// the node by moac before the node plugin stages it.
+	Expect(driver.Spec.AttachRequired).ToNot(BeNil())
+	Expect(*driver.Spec.AttachRequired).To(BeTrue(), "suites assume controller attach")
+
+	// Only persistent volumes are exercised; an ephemeral lifecycle mode
+	// appearing here would be untested surface.
+	for _, mode := range driver.Spec.VolumeLifecycleModes {
+		Expect(mode).To(Equal(storageV1.VolumeLifecyclePersistent),
+			"unexpected volume lifecycle mode %s", mode)
+	}
+}
+
+func nodePluginCapabilitiesTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(nodes).ToNot(BeEmpty(), "no mayastor nodes found")
+
+	// The capability set is a property of the image, so one node
+	// suffices.
+	capabilities, err := k8stest.NodePluginCapabilities(nodes[0])
+	Expect(err).ToNot(HaveOccurred(), "failed to query node plugin capabilities")
+
+	// Suites stage volumes and collect usage stats via kubelet.
+	Expect(capabilities).To(ContainElement("STAGE_UNSTAGE_VOLUME"),
+		"node plugin does not advertise staging")
+	Expect(capabilities).To(ContainElement("GET_VOLUME_STATS"),
+		"node plugin does not advertise volume stats")
+
+	// Expansion is not supported; if the driver starts advertising it
+	// the suites need expansion coverage before this is relaxed.
+	Expect(capabilities).ToNot(ContainElement("EXPAND_VOLUME"),
+		"node plugin advertises expansion which no suite covers")
+}
+
+func TestCsiCapabilities(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "CSI capabilities")
+}
+
+var _ = Describe("CSI driver capabilities", func() {
+	It("should register a CSIDriver object matching suite assumptions", func() {
+		csiDriverObjectTest()
+	})
+	It("should advertise the node plugin capabilities suites rely on", func() {
+		nodePluginCapabilitiesTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
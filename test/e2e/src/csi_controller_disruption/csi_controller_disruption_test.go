@@ -0,0 +1,97 @@
+// Dynamic provisioning under API server disruption. The CSI controller
+// pod is cut off from the API server with a deny-all egress network
+// policy, PVC creates are issued during the outage, then connectivity is
+// restored and the suite asserts the external-provisioner retries bring
+// every volume to Bound, with exactly one MSV per volume and no
+// duplicates left behind.
+package csi_controller_disruption_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 300
+const egressPolicyName = "e2e-deny-csi-controller-egress"
+const outageSecs = 60
+const volumeCount = 5
+
+// label carried by the control plane / CSI controller pod
+var csiControllerLabels = map[string]string{"app": "moac"}
+
+func controllerDisruptionTest() {
+	scName := "csi-disruption-sc"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+
+	msvsBefore, err := k8stest.ListMsvs()
+	Expect(err).ToNot(HaveOccurred())
+
+	// Cut the CSI controller off from the API server.
+	By("disrupting CSI controller connectivity")
+	Expect(k8stest.ApplyDenyEgressPolicy(egressPolicyName, k8stest.MayastorNamespace, csiControllerLabels)).To(Succeed())
+
+	// Issue PVC creates during the outage.
+	var volNames []string
+	for ix := 0; ix < volumeCount; ix++ {
+		volName := fmt.Sprintf("csi-disruption-vol-%d", ix)
+		_, err := k8stest.CreatePVC(volName, 256, scName, nameSpace)
+		Expect(err).ToNot(HaveOccurred(), "failed to create PVC %s", volName)
+		volNames = append(volNames, volName)
+	}
+	time.Sleep(outageSecs * time.Second)
+
+	// Restore connectivity; the external-provisioner must retry and
+	// bind every volume.
+	By("restoring CSI controller connectivity")
+	Expect(k8stest.RemoveNetworkPolicy(egressPolicyName, k8stest.MayastorNamespace)).To(Succeed())
+	for _, volName := range volNames {
+		Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed(),
+			"PVC %s not bound after connectivity was restored", volName)
+	}
+
+	// Exactly one MSV per created volume, retries must not have left
+	// duplicates.
+	msvsAfter, err := k8stest.ListMsvs()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(len(msvsAfter)-len(msvsBefore)).To(Equal(volumeCount),
+		"unexpected MSV count, duplicates or leaks after provisioning retries")
+
+	// Cleanup.
+	for _, volName := range volNames {
+		Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	}
+	remaining, err := k8stest.DeleteAllPvcs(nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(remaining).To(BeZero())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestCsiControllerDisruption(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "CSI controller disruption")
+}
+
+var _ = Describe("Provisioning under API server disruption", func() {
+	It("should bind all volumes created during an outage without duplicate MSVs", func() {
+		controllerDisruptionTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
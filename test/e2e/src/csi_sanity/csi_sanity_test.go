@@ -0,0 +1,53 @@
+// CSI sanity conformance run. The upstream csi-sanity tool exercises
+// the CSI gRPC contract - identity, controller and node services -
+// directly over the driver sockets, catching spec regressions the
+// workload suites would only hit indirectly, if at all. The tool runs
+// in a pod on the moac node and its results are surfaced here.
+package csi_sanity_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func csiSanityTest() {
+	result, err := k8stest.RunCsiSanity()
+	Expect(err).ToNot(HaveOccurred(), "failed to run csi-sanity")
+
+	logf.Log.Info("csi-sanity run complete",
+		"passed", result.Passed, "failed", result.Failed, "skipped", result.Skipped)
+	// A run where nothing passed means the tool never reached the
+	// driver, not a conformant driver.
+	Expect(result.Passed).To(BeNumerically(">", 0), "csi-sanity ran no specs")
+	Expect(result.Failed).To(BeZero(),
+		"csi-sanity failures:\n%s", strings.Join(result.FailedSpecs, "\n"))
+}
+
+func TestCsiSanity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "CSI sanity")
+}
+
+var _ = Describe("CSI spec conformance", func() {
+	It("should pass the csi-sanity conformance run", func() {
+		csiSanityTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,159 @@
+// Data locality test: the same single replica volume is exercised by an
+// application on the node hosting its nexus and by one kept away from
+// it, and the fio completion latencies of both placements are recorded
+// via the run manifest so the local versus remote data path cost can be
+// charted across CI runs. The suite also verifies the scheduler honours
+// the local=true storage class parameter by placing the data on the
+// node the application was scheduled to.
+package data_locality_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 300
+const fioRunTimeSecs = 60
+const volSizeMb = 512
+
+// measureLatency runs fio in the pod and returns the mean completion
+// latency in microseconds.
+func measureLatency(podName string) float64 {
+	out, err := k8stest.RunFio(podName, nameSpace, fioRunTimeSecs)
+	Expect(err).ToNot(HaveOccurred(), "fio failed in pod %s", podName)
+	latency, err := k8stest.FioMeanLatencyUsecs(out)
+	Expect(err).ToNot(HaveOccurred())
+	return latency
+}
+
+func localityLatencyTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodes) < 2 {
+		Skip("need at least two mayastor nodes to compare local and remote access")
+	}
+
+	scName := "data-locality-sc"
+	volName := "data-locality-vol"
+	// A single replica, so remote access really crosses the network
+	// rather than hitting a local replica through a remote nexus.
+	Expect(k8stest.MkStorageClass(scName, 1, "nvmf")).To(Succeed())
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	nexusNode, err := k8stest.GetMsvNexusNode(uuid)
+	Expect(err).ToNot(HaveOccurred())
+
+	By(fmt.Sprintf("measuring local access latency on nexus node %s", nexusNode))
+	localPod := "data-locality-fio-local"
+	_, err = k8stest.CreateFioPodOnMsvNexusNode(localPod, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(localPod, nameSpace, defTimeoutSecs)).To(Succeed())
+	pod, err := k8stest.GetPod(localPod, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pod.Spec.NodeName).To(Equal(nexusNode), "local pod not on the nexus node")
+	localLatency := measureLatency(localPod)
+	Expect(k8stest.DeletePod(localPod, nameSpace)).To(Succeed())
+
+	By("measuring remote access latency away from the nexus node")
+	remotePod := "data-locality-fio-remote"
+	_, err = k8stest.CreateFioPodAwayFromMsvNexusNode(remotePod, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(remotePod, nameSpace, defTimeoutSecs)).To(Succeed())
+	pod, err = k8stest.GetPod(remotePod, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pod.Spec.NodeName).ToNot(Equal(nexusNode), "remote pod landed on the nexus node")
+	remoteLatency := measureLatency(remotePod)
+	Expect(k8stest.DeletePod(remotePod, nameSpace)).To(Succeed())
+
+	// The figures are recorded rather than compared: the latency gap
+	// depends on the infrastructure, and a hard threshold here would
+	// only produce flakes.
+	By(fmt.Sprintf("local latency %.1fus, remote latency %.1fus", localLatency, remoteLatency))
+	err = manifest.AppendSection("dataLocalityLatency", []map[string]interface{}{
+		{"mode": "local", "latencyUsecs": localLatency},
+		{"mode": "remote", "latencyUsecs": remoteLatency},
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	// Cleanup.
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func localParamTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodes) < 2 {
+		Skip("need at least two mayastor nodes to make locality observable")
+	}
+	appNode := nodes[0]
+
+	scName := "data-locality-local-sc"
+	volName := "data-locality-local-vol"
+	podName := "data-locality-local-fio"
+	// Late binding, so the scheduler picks the application node before
+	// the volume is provisioned and locality can be honoured.
+	err = k8stest.NewStorageClassBuilder(scName).
+		WithReplicas(1).
+		WithLocal(true).
+		WithLateBinding().
+		Build()
+	Expect(err).ToNot(HaveOccurred())
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+
+	_, err = k8stest.CreateFioPodOnNode(podName, volName, nameSpace, appNode)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	nexusNode, err := k8stest.GetMsvNexusNode(uuid)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(nexusNode).To(Equal(appNode), "nexus not on the application node")
+	replicaNodes, err := k8stest.MsvReplicaNodes(uuid)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(replicaNodes).To(ContainElement(appNode), "no replica on the application node")
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestDataLocality(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Data locality")
+}
+
+var _ = Describe("Local versus remote volume access", func() {
+	It("should record the latency of local and remote target placement", func() {
+		localityLatencyTest()
+	})
+
+	It("should honour the local storage class parameter", func() {
+		localParamTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,111 @@
+// Default storage class selection test. A mayastor storage class is
+// annotated as the cluster default and the suite verifies that PVCs
+// created without a storageClassName are provisioned by mayastor. With
+// two defaults present, kubernetes 1.25+ assigns the most recently
+// created default while older versions leave the claim pending; the
+// suite asserts the behaviour matching the server version.
+package default_sc_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const volSizeMb = 256
+
+// verifyMayastorProvisioned asserts the bound PV of the claim was
+// provisioned by the mayastor CSI driver.
+func verifyMayastorProvisioned(volName string) {
+	pvc, err := k8stest.GetPVC(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pv, err := k8stest.GetPV(pvc.Spec.VolumeName)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pv.Spec.CSI).ToNot(BeNil())
+	Expect(pv.Spec.CSI.Driver).To(Equal(k8stest.CSIProvisioner),
+		"volume %s not provisioned by mayastor", volName)
+}
+
+func singleDefaultTest() {
+	scName := "default-sc"
+	Expect(k8stest.MkDefaultStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	defer func() {
+		Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+	}()
+
+	volName := "default-sc-vol"
+	_, err := k8stest.CreatePVC(volName, volSizeMb, "", nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed(),
+		"claim without storageClassName not bound via the default class")
+	verifyMayastorProvisioned(volName)
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+}
+
+func twoDefaultsTest() {
+	scFirst := "default-sc-first"
+	scSecond := "default-sc-second"
+	Expect(k8stest.MkDefaultStorageClass(scFirst, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	Expect(k8stest.MkDefaultStorageClass(scSecond, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	defer func() {
+		Expect(k8stest.RmStorageClass(scFirst)).To(Succeed())
+		Expect(k8stest.RmStorageClass(scSecond)).To(Succeed())
+	}()
+
+	volName := "default-sc-dual-vol"
+	_, err := k8stest.CreatePVC(volName, volSizeMb, "", nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	}()
+
+	minorVersion, err := k8stest.GetServerMinorVersion()
+	Expect(err).ToNot(HaveOccurred())
+	if minorVersion >= 25 {
+		// KEP-2589: the most recently created default wins.
+		Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed(),
+			"claim not bound with two default classes on kubernetes 1.%d", minorVersion)
+		verifyMayastorProvisioned(volName)
+	} else {
+		// Older admission rejects ambiguous defaults, the claim stays
+		// pending with no storage class assigned.
+		Expect(k8stest.WaitPvcBound(volName, nameSpace, 60)).ToNot(Succeed(),
+			"claim bound despite ambiguous default classes on kubernetes 1.%d", minorVersion)
+		phase, err := k8stest.GetPvcStatusPhase(volName, nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(phase).To(Equal(coreV1.ClaimPending))
+	}
+}
+
+func TestDefaultSc(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Default storage class")
+}
+
+var _ = Describe("Default storage class selection", func() {
+	It("should provision claims without a storageClassName via the default class", func() {
+		singleDefaultTest()
+	})
+	It("should behave per the server version when two defaults exist", func() {
+		twoDefaultsTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
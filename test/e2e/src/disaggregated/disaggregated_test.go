@@ -0,0 +1,145 @@
+// Disaggregated deployment test: io-engine on dedicated storage nodes,
+// applications on compute nodes. The suite converts one mayastor node
+// into a compute-only node by removing the engine label, taints the
+// remaining storage nodes against application pods, then verifies that
+// replicas land only on storage nodes, that a compute node application
+// reaches its volume over remote NVMe-oF, and records the remote
+// access fio bandwidth separately from hyperconverged figures via the
+// run manifest. Labels and taints are restored afterwards.
+package disaggregated_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 300
+const fioRunTimeSecs = 60
+const volSizeMb = 512
+
+// storageTaintKey keeps application pods off the dedicated storage
+// nodes for the duration of the suite.
+const storageTaintKey = "openebs.io/e2e-storage-only"
+
+// storageNodeIPs returns the addresses of the current mayastor nodes.
+func storageNodeIPs() map[string]bool {
+	locs, err := k8stest.GetNodeLocs()
+	Expect(err).ToNot(HaveOccurred())
+	ips := map[string]bool{}
+	for _, loc := range locs {
+		if loc.MayastorNode {
+			ips[loc.IPv4Address] = true
+		}
+	}
+	return ips
+}
+
+func disaggregatedTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodes) < 3 {
+		Skip("need at least three mayastor nodes to dedicate one to compute")
+	}
+	computeNode := nodes[len(nodes)-1]
+	storageNodes := nodes[:len(nodes)-1]
+
+	By(fmt.Sprintf("dedicating %s to compute, %v to storage", computeNode, storageNodes))
+	Expect(k8stest.SetMayastorNodeLabel(computeNode, false)).To(Succeed())
+	for _, nodeName := range storageNodes {
+		Expect(k8stest.TaintNode(nodeName, storageTaintKey, "true", coreV1.TaintEffectNoSchedule)).To(Succeed())
+	}
+	defer func() {
+		Expect(k8stest.SetMayastorNodeLabel(computeNode, true)).To(Succeed())
+		for _, nodeName := range storageNodes {
+			Expect(k8stest.UntaintNode(nodeName, storageTaintKey)).To(Succeed())
+		}
+	}()
+
+	// Allow the io-engine to drain off the compute node before
+	// provisioning, so no replica can land there.
+	time.Sleep(30 * time.Second)
+
+	scName := "disaggregated-sc"
+	volName := "disaggregated-vol"
+	podName := "disaggregated-fio"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// Every nexus child must be served from a storage node.
+	pvc, err := k8stest.GetPVC(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pv, err := k8stest.GetPV(pvc.Spec.VolumeName)
+	Expect(err).ToNot(HaveOccurred())
+	uuid := pv.Spec.CSI.VolumeHandle
+	children, err := k8stest.GetMsvNexusChildren(uuid)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(children).ToNot(BeEmpty())
+	storageIPs := storageNodeIPs()
+	for _, uri := range children {
+		if target, err := k8stest.ParseNvmfURI(uri); err == nil {
+			Expect(storageIPs[target.Address]).To(BeTrue(),
+				"replica %s not on a storage node", uri)
+		}
+	}
+
+	// The application runs on the compute node and reaches the volume
+	// remotely.
+	_, err = k8stest.CreateFioPodOnNode(podName, volName, nameSpace, computeNode)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed(),
+		"application pod not running on the compute node")
+	pod, err := k8stest.GetPod(podName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pod.Spec.NodeName).To(Equal(computeNode))
+
+	out, err := k8stest.RunFio(podName, nameSpace, fioRunTimeSecs)
+	Expect(err).ToNot(HaveOccurred(), "fio over remote NVMe-oF failed")
+	bandwidth, err := k8stest.FioBandwidthBytes(out)
+	Expect(err).ToNot(HaveOccurred())
+	By(fmt.Sprintf("remote access fio bandwidth %f", bandwidth))
+	err = manifest.AppendSection("disaggregatedBandwidth", map[string]interface{}{
+		"mode":      "disaggregated",
+		"bandwidth": bandwidth,
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestDisaggregated(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Disaggregated deployment")
+}
+
+var _ = Describe("App node versus storage node separation", func() {
+	It("should serve compute node applications from storage nodes only", func() {
+		disaggregatedTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,156 @@
+// Disk fault injection test. One pool is recreated on a dm-flakey
+// wrapper of its backing device, so the device periodically fails I/O.
+// A replicated volume with a replica on the flaky pool is written to
+// until the pool leaves the online state and the volume degrades, then
+// the fault is removed, the pool recreated on the raw device and the
+// volume must return to healthy.
+package disk_fault_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/faults"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 300
+
+const scName = "disk-fault-sc"
+const volName = "disk-fault-vol"
+const volSizeMb = 512
+
+// errPercent is the fraction of time the flaky device fails I/O.
+const errPercent = 30
+
+// devicePath extracts the block device path from a pool disk
+// specification, e.g. "aio:///dev/sdb" => "/dev/sdb".
+func devicePath(disk string) string {
+	if ix := strings.Index(disk, "://"); ix != -1 {
+		return disk[ix+3:]
+	}
+	return disk
+}
+
+func waitPoolState(poolName string, wanted func(string) bool) string {
+	var state string
+	var err error
+	const sleepTime = 5
+	for ix := 0; ix < defTimeoutSecs/sleepTime; ix++ {
+		state, err = k8stest.GetMsPoolState(poolName)
+		if err == nil && wanted(state) {
+			return state
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	Expect(err).ToNot(HaveOccurred(), "failed to get state of pool %s", poolName)
+	return state
+}
+
+func diskFaultTest() {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	if len(pools) < 2 {
+		Skip("test requires at least 2 mayastor pools")
+	}
+
+	poolName := pools[0].GetName()
+	poolNode, poolDisk, err := k8stest.MsPoolNodeAndDisk(pools[0])
+	Expect(err).ToNot(HaveOccurred())
+	device := devicePath(poolDisk)
+
+	// Recreate the pool on a flakey wrapper of its backing device.
+	By(fmt.Sprintf("recreating pool %s on a flaky device on node %s", poolName, poolNode))
+	Expect(k8stest.DeleteMsPool(poolName)).To(Succeed())
+	flakyDevice, err := faults.MakeDeviceFlaky(poolNode, device, errPercent)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		// Whatever the outcome the pool must end up back on the raw
+		// device with the wrapper removed.
+		_ = k8stest.DeleteMsPool(poolName)
+		_ = faults.RestoreDevice(poolNode)
+		if _, err := k8stest.GetMsPoolState(poolName); err != nil {
+			_ = k8stest.CreateMsPool(poolName, poolNode, poolDisk)
+		}
+		Expect(k8stest.WaitMsPoolOnline(poolName, defTimeoutSecs)).To(Succeed())
+	}()
+	Expect(k8stest.CreateMsPool(poolName, poolNode, flakyDevice)).To(Succeed())
+	Expect(k8stest.WaitMsPoolOnline(poolName, defTimeoutSecs)).To(Succeed())
+
+	// A 2 replica volume, with data, one replica lands on the flaky
+	// pool given the pool count check above.
+	Expect(k8stest.MkStorageClass(scName, 2, "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+	pvc, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	uuid := string(pvc.ObjectMeta.UID)
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	podName := volName + "-client"
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// Write through the fault until the disk errors surface: the pool
+	// must leave the online state and the volume must degrade, not
+	// fail.
+	By("writing through the injected disk errors")
+	go func() {
+		defer GinkgoRecover()
+		// Errors are expected here once the device goes down.
+		_, _ = k8stest.ExecPod(podName, nameSpace, "fio",
+			"--name=disk-fault", "--rw=randwrite", "--direct=1", "--ioengine=libaio",
+			"--bs=4k", "--iodepth=16", "--numjobs=1",
+			"--filename=/volume/fio-data", "--size=256m",
+			"--time_based", fmt.Sprintf("--runtime=%d", defTimeoutSecs))
+	}()
+
+	state := waitPoolState(poolName, func(s string) bool { return !strings.EqualFold(s, "online") })
+	Expect(strings.EqualFold(state, "online")).To(BeFalse(),
+		"pool %s still online despite injected disk errors", poolName)
+	Expect(k8stest.WaitMsvState(uuid, "degraded", defTimeoutSecs)).To(Succeed())
+
+	// Remove the fault: retire the flaky pool, unwrap the device and
+	// recreate the pool on it; the volume must repair onto it.
+	By("removing the disk fault and repairing the volume")
+	Expect(k8stest.DeleteMsPool(poolName)).To(Succeed())
+	Expect(faults.RestoreDevice(poolNode)).To(Succeed())
+	Expect(k8stest.CreateMsPool(poolName, poolNode, poolDisk)).To(Succeed())
+	Expect(k8stest.WaitMsPoolOnline(poolName, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.WaitMsvState(uuid, "healthy", defTimeoutSecs)).To(Succeed())
+}
+
+func TestDiskFault(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Disk fault injection")
+}
+
+var _ = Describe("Mayastor disk fault handling", func() {
+	It("should degrade and repair a volume across injected disk errors", func() {
+		diskFaultTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
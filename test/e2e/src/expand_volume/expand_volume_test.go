@@ -0,0 +1,117 @@
+// Online volume expansion. The suite grows a filesystem volume while
+// fio is writing to it, then verifies the expansion propagated through
+// the PVC, PV and MSV and that the filesystem itself was resized, all
+// without interrupting the running I/O.
+package expand_volume_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+
+const scName = "expand-volume-sc"
+const volName = "expand-volume-vol"
+const volSizeMb = 512
+const expandedSizeMb = 1024
+
+// fio keeps writing for this long while the expansion happens.
+const fioRuntimeSecs = 60
+
+// fsSizeMb returns the size in MiB of the filesystem mounted at /volume
+// in the given pod, as reported by df.
+func fsSizeMb(podName string) int {
+	out, err := k8stest.ExecPod(podName, nameSpace, "df", "-P", "-m", "/volume")
+	Expect(err).ToNot(HaveOccurred())
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	Expect(len(lines)).To(BeNumerically(">=", 2), "unparsable df output %q", out)
+	fields := strings.Fields(lines[len(lines)-1])
+	Expect(len(fields)).To(BeNumerically(">=", 2), "unparsable df output %q", out)
+	sizeMb, err := strconv.Atoi(fields[1])
+	Expect(err).ToNot(HaveOccurred(), "unparsable df size %q", fields[1])
+	return sizeMb
+}
+
+func expandVolumeTest() {
+	Expect(k8stest.MkExpandableStorageClass(scName, k8stest.DefaultReplicaCount(), "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	podName := volName + "-client"
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+	initialFsSizeMb := fsSizeMb(podName)
+
+	By("starting fio against the volume")
+	fioDone := make(chan error, 1)
+	go func() {
+		defer GinkgoRecover()
+		_, err := k8stest.ExecPod(podName, nameSpace, "fio",
+			"--name=expand", "--rw=randwrite", "--direct=1", "--ioengine=libaio",
+			"--bs=4k", "--iodepth=16", "--numjobs=1",
+			"--filename=/volume/fio-data", "--size=128m",
+			"--time_based", fmt.Sprintf("--runtime=%d", fioRuntimeSecs),
+			"--verify=crc32")
+		fioDone <- err
+	}()
+
+	By(fmt.Sprintf("expanding the volume to %dMB", expandedSizeMb))
+	Expect(k8stest.ExpandPVC(volName, expandedSizeMb, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	By("waiting for the filesystem to be resized")
+	grownFsSizeMb := initialFsSizeMb
+	for ix := 0; ix < defTimeoutSecs/2; ix++ {
+		grownFsSizeMb = fsSizeMb(podName)
+		if grownFsSizeMb > initialFsSizeMb {
+			break
+		}
+		time.Sleep(2 * time.Second)
+	}
+	Expect(grownFsSizeMb).To(BeNumerically(">", initialFsSizeMb),
+		"filesystem was not resized, still %dMiB", grownFsSizeMb)
+
+	By("waiting for fio to complete")
+	Expect(<-fioDone).ToNot(HaveOccurred(), "fio failed while the volume was expanded")
+}
+
+func TestExpandVolume(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Online volume expansion")
+}
+
+var _ = Describe("Mayastor volume expansion", func() {
+	It("should grow a filesystem volume while I/O is running", func() {
+		expandVolumeTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,151 @@
+// Graceful node shutdown test. A replica-hosting node running an
+// application workload is rebooted through systemd, so the kubelet
+// graceful shutdown path runs: kubelet's shutdown inhibitor delays the
+// reboot while pods are evicted cleanly. The suite verifies the
+// workload is rescheduled within the graceful period, the volume
+// degrades and recovers cleanly, and no forced volume detach was
+// needed — complementing the abrupt failure tests which kill nodes
+// without warning.
+package graceful_shutdown_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const volSizeMb = 256
+
+// gracefulPeriodSecs is the budget within which the evicted workload
+// must be running again; it corresponds to the kubelet
+// shutdownGracePeriod plus scheduling and staging time.
+const gracefulPeriodSecs = 180
+
+// rebootTimeoutSecs bounds the node's return to ready after the reboot.
+const rebootTimeoutSecs = 600
+
+// appNode returns the node running the single pod of the deployment.
+func appNode(deployName string) string {
+	pods, err := k8stest.ListPodsBySelector(nameSpace, "app="+deployName)
+	Expect(err).ToNot(HaveOccurred())
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == coreV1.PodRunning {
+			return pod.Spec.NodeName
+		}
+	}
+	return ""
+}
+
+func gracefulShutdownTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodes) < 2 {
+		Skip("test requires at least 2 mayastor nodes")
+	}
+
+	scName := "graceful-shutdown-sc"
+	volName := "graceful-shutdown-vol"
+	deployName := "graceful-shutdown-app"
+	Expect(k8stest.MkStorageClass(scName, 2, "nvmf")).To(Succeed())
+	defer func() {
+		Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+	}()
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	pvc, err := k8stest.GetPVC(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pv, err := k8stest.GetPV(pvc.Spec.VolumeName)
+	Expect(err).ToNot(HaveOccurred())
+	uuid := pv.Spec.CSI.VolumeHandle
+
+	Expect(k8stest.CreateVolumeDeployment(deployName, volName, nameSpace,
+		e2e_config.GetConfig().E2eFioImage)).To(Succeed())
+	defer func() {
+		Expect(k8stest.DeleteDeployment(deployName, nameSpace)).To(Succeed())
+	}()
+	Expect(k8stest.WaitDeploymentReady(deployName, nameSpace, defTimeoutSecs)).To(Succeed())
+	testNode := appNode(deployName)
+	Expect(testNode).ToNot(BeEmpty(), "no running pod for deployment %s", deployName)
+
+	// The graceful path only exists when kubelet holds a shutdown
+	// inhibitor; without the feature this suite has nothing to test.
+	inhibitors, err := k8stest.RunCommandOnNode(testNode, "systemd-inhibit --list")
+	Expect(err).ToNot(HaveOccurred())
+	if !strings.Contains(inhibitors, "kubelet") {
+		Skip("kubelet graceful node shutdown is not enabled on the cluster")
+	}
+
+	shutdownAt := time.Now()
+	By(fmt.Sprintf("rebooting node %s gracefully", testNode))
+	// systemd-run detaches the reboot from the exec pod, which is itself
+	// evicted during the shutdown.
+	_, err = k8stest.RunCommandOnNode(testNode, "systemd-run --on-active=5 systemctl reboot")
+	Expect(err).ToNot(HaveOccurred())
+
+	By("waiting for the workload to be rescheduled")
+	rescheduled := false
+	for ix := 0; ix < gracefulPeriodSecs/5; ix++ {
+		time.Sleep(5 * time.Second)
+		if node := appNode(deployName); node != "" && node != testNode {
+			rescheduled = true
+			break
+		}
+	}
+	Expect(rescheduled).To(BeTrue(),
+		"workload not rescheduled off %s within %d seconds", testNode, gracefulPeriodSecs)
+
+	// With a replica gone the volume must degrade, not fault.
+	Expect(k8stest.WaitMsvState(uuid, "degraded", defTimeoutSecs)).To(Succeed(),
+		"volume did not degrade when its replica node shut down")
+
+	By(fmt.Sprintf("waiting for node %s to return", testNode))
+	Expect(k8stest.WaitNodeReady(testNode, rebootTimeoutSecs)).To(Succeed())
+	Expect(k8stest.WaitMsvState(uuid, "healthy", defTimeoutSecs)).To(Succeed(),
+		"volume did not recover after the node returned")
+	Expect(k8stest.WaitDeploymentReady(deployName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// A clean eviction unmounts and detaches through CSI; the attach
+	// detach controller must never have had to force a detach.
+	forced, err := k8stest.EventsMatching("", shutdownAt, "force")
+	Expect(err).ToNot(HaveOccurred())
+	for _, event := range forced {
+		Expect(event.Message).ToNot(ContainSubstring("detach"),
+			"forced detach event seen: %s %s", event.Reason, event.Message)
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Graceful node shutdown")
+}
+
+var _ = Describe("graceful node shutdown", func() {
+	It("should evict, reschedule and recover without forced detach", func() {
+		gracefulShutdownTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,132 @@
+// HA nexus failover: with a two replica volume under I/O, taking down
+// the io-engine hosting the nexus must move the target to another node
+// within the configured switchover SLO, without the application seeing
+// I/O errors. The initiator side of the switch is verified with nvme-cli
+// rather than inferred from control plane state.
+package ha_failover_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/nvme"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+	"github.com/openebs/mayastor/test/e2e/common/workload"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 240
+
+const scName = "ha-failover-sc"
+const volName = "ha-failover-vol"
+const volSizeMb = 512
+
+// ioEngineDaemonSet is the daemonset the killed pod is recreated by.
+const ioEngineDaemonSet = "mayastor"
+
+// killNexusPod deletes the io-engine pod on the given node and returns
+// its name.
+func killNexusPod(nodeName string) string {
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, "app=mayastor")
+	Expect(err).ToNot(HaveOccurred())
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == nodeName {
+			Expect(k8stest.DeletePod(pod.Name, k8stest.MayastorNamespace)).To(Succeed())
+			return pod.Name
+		}
+	}
+	Fail(fmt.Sprintf("no io-engine pod found on node %s", nodeName))
+	return ""
+}
+
+func haFailoverTest() {
+	nodeNames, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodeNames) < 2 {
+		Skip("HA failover needs at least 2 mayastor nodes")
+	}
+	switchoverTimeoutSecs := e2e_config.GetConfig().HaFailover.SwitchoverTimeoutSecs
+
+	generator, err := workload.New("fio")
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(k8stest.MkStorageClass(scName, 2, "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	podName := volName + "-client"
+	Expect(generator.MakePod(podName, volName, nameSpace)).To(Succeed())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+
+	uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	nexusNode, err := k8stest.GetMsvNexusNode(uuid)
+	Expect(err).ToNot(HaveOccurred())
+	appPod, err := k8stest.GetPod(podName, k8stest.TestNamespace())
+	Expect(err).ToNot(HaveOccurred())
+	appNode := appPod.Spec.NodeName
+
+	// The initiator must be connected before the failover is induced.
+	subsystem, err := nvme.SubsystemForVolume(appNode, uuid)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(subsystem.LivePaths()).ToNot(BeEmpty(), "no live path to the volume before failover")
+
+	// Run I/O across the whole failover window; the workload failing
+	// would be the application seeing I/O errors.
+	By("starting fio and taking down the io-engine hosting the nexus")
+	generator.Start(switchoverTimeoutSecs + 60)
+	killNexusPod(nexusNode)
+
+	By("waiting for the target to be republished on another node")
+	newNode, err := k8stest.WaitMsvNexusMoved(uuid, nexusNode, switchoverTimeoutSecs)
+	Expect(err).ToNot(HaveOccurred(), "switchover SLO of %d seconds missed", switchoverTimeoutSecs)
+	Expect(newNode).ToNot(Equal(nexusNode))
+
+	// The initiator must have followed the target to its new home.
+	subsystem, err = nvme.SubsystemForVolume(appNode, uuid)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(subsystem.LivePaths()).ToNot(BeEmpty(), "no live path to the volume after failover")
+
+	By("verifying the application saw no I/O errors")
+	Expect(generator.Wait()).To(Succeed(), "fio reported I/O errors across the failover")
+	Expect(generator.Verify()).To(Succeed(), "data verification failed after the failover")
+
+	// The daemonset recreates the killed io-engine; leave the cluster
+	// whole for the next spec.
+	Expect(k8stest.WaitDaemonSetReady(ioEngineDaemonSet, k8stest.MayastorNamespace, defTimeoutSecs)).To(Succeed())
+}
+
+func TestHaFailover(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "HA nexus failover")
+}
+
+var _ = Describe("Mayastor HA nexus failover", func() {
+	It("should republish the target on another node without I/O errors", func() {
+		haFailoverTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,967 @@
+// The harness validation suite exercises the test framework itself against
+// fake in-memory clients, so framework regressions are caught independently
+// of product bugs. It must not require a cluster or a mayastor installation.
+package harness_validation_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/faults"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/kubectlshim"
+	"github.com/openebs/mayastor/test/e2e/common/logmerge"
+	"github.com/openebs/mayastor/test/e2e/common/loki"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+	"github.com/openebs/mayastor/test/e2e/common/restclient"
+	"github.com/openebs/mayastor/test/e2e/common/runreport"
+	"github.com/openebs/mayastor/test/e2e/common/workload"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coreV1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestHarnessValidation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Harness validation")
+}
+
+var _ = BeforeSuite(func() {
+	k8stest.SetupFakeTestEnv()
+})
+
+var _ = Describe("e2e configuration", func() {
+	It("should apply defaults when no configuration file is set", func() {
+		cfg := e2e_config.GetConfig()
+		Expect(cfg.PoolDevice).ToNot(BeEmpty())
+		Expect(cfg.Registry).ToNot(BeEmpty())
+		Expect(cfg.ImageTag).ToNot(BeEmpty())
+		Expect(cfg.DefaultReplicaCount).To(BeNumerically(">", 0))
+	})
+})
+
+var _ = Describe("e2e configuration override scopes", func() {
+	It("should layer and unwind per-suite overrides", func() {
+		base := e2e_config.GetConfig()
+
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.DefaultReplicaCount = base.DefaultReplicaCount + 1
+		})
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.ImageTag = "override-tag"
+		})
+
+		// Both scopes must be visible, innermost last.
+		cfg := e2e_config.GetConfig()
+		Expect(cfg.DefaultReplicaCount).To(Equal(base.DefaultReplicaCount + 1))
+		Expect(cfg.ImageTag).To(Equal("override-tag"))
+
+		// Popping unwinds scopes innermost first.
+		e2e_config.PopOverrideScope()
+		cfg = e2e_config.GetConfig()
+		Expect(cfg.DefaultReplicaCount).To(Equal(base.DefaultReplicaCount + 1))
+		Expect(cfg.ImageTag).To(Equal(base.ImageTag))
+
+		e2e_config.PopOverrideScope()
+		Expect(e2e_config.GetConfig()).To(Equal(base))
+	})
+})
+
+var _ = Describe("yaml API version checking", func() {
+	It("should flag manifests using removed API versions", func() {
+		dir := GinkgoT().TempDir()
+		manifest := `apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: mayastor-pdb
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: mayastor
+`
+		err := os.WriteFile(path.Join(dir, "pdb.yaml"), []byte(manifest), 0644)
+		Expect(err).ToNot(HaveOccurred())
+
+		// policy/v1beta1 was removed in 1.25, valid before that.
+		Expect(k8stest.CheckYamlApiVersions("pdb.yaml", dir, 24)).To(Succeed())
+		Expect(k8stest.CheckYamlApiVersions("pdb.yaml", dir, 25)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("manifest loading", func() {
+	It("should split documents and substitute template variables", func() {
+		dir := GinkgoT().TempDir()
+		manifest := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{.name}}
+---
+# a comment-only document is skipped
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{.name}}-config
+  namespace: {{.namespace}}
+`
+		err := os.WriteFile(path.Join(dir, "manifest.yaml"), []byte(manifest), 0644)
+		Expect(err).ToNot(HaveOccurred())
+
+		objects, err := k8stest.LoadManifestFile("manifest.yaml", dir, map[string]string{
+			"name":      "mayastor",
+			"namespace": "mayastor-ns",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(objects).To(HaveLen(2))
+		Expect(objects[0].GetKind()).To(Equal("ServiceAccount"))
+		Expect(objects[0].GetName()).To(Equal("mayastor"))
+		Expect(objects[1].GetName()).To(Equal("mayastor-config"))
+		Expect(objects[1].GetNamespace()).To(Equal("mayastor-ns"))
+	})
+
+	It("should reject undefined template variables", func() {
+		dir := GinkgoT().TempDir()
+		err := os.WriteFile(path.Join(dir, "manifest.yaml"),
+			[]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{.nonesuch}}\n"), 0644)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = k8stest.LoadManifestFile("manifest.yaml", dir, map[string]string{"name": "mayastor"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should pass manifests through untouched when no variables are given", func() {
+		dir := GinkgoT().TempDir()
+		err := os.WriteFile(path.Join(dir, "manifest.yaml"),
+			[]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: plain\n"), 0644)
+		Expect(err).ToNot(HaveOccurred())
+
+		objects, err := k8stest.LoadManifestFile("manifest.yaml", dir, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(objects).To(HaveLen(1))
+		Expect(objects[0].GetName()).To(Equal("plain"))
+	})
+})
+
+var _ = Describe("nvmf URI parsing", func() {
+	It("should handle IPv4 and bracketed IPv6 addresses", func() {
+		target, err := k8stest.ParseNvmfURI("nvmf://10.0.0.1:8420/nqn.2019-05.io.openebs:vol-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Address).To(Equal("10.0.0.1"))
+		Expect(target.Port).To(Equal("8420"))
+		Expect(target.Nqn).To(Equal("nqn.2019-05.io.openebs:vol-1"))
+		Expect(target.IsIPv6()).To(BeFalse())
+
+		target, err = k8stest.ParseNvmfURI("nvmf://[fd00:dead:beef::1]:8420/nqn.2019-05.io.openebs:vol-2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Address).To(Equal("fd00:dead:beef::1"))
+		Expect(target.Port).To(Equal("8420"))
+		Expect(target.IsIPv6()).To(BeTrue())
+
+		_, err = k8stest.ParseNvmfURI("iscsi://10.0.0.1:3260/iqn")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should bracket IPv6 addresses when joining host and port", func() {
+		Expect(k8stest.FormatHostPort("10.0.0.1", 8420)).To(Equal("10.0.0.1:8420"))
+		Expect(k8stest.FormatHostPort("fd00::1", 8420)).To(Equal("[fd00::1]:8420"))
+	})
+})
+
+var _ = Describe("run report collation", func() {
+	AfterEach(func() {
+		// The reporter lifecycle re-establishes the spec attribution for
+		// the next spec; only the records fabricated here are discarded.
+		runreport.Reset()
+	})
+
+	It("should collate records per spec and render json and html artefacts", func() {
+		runreport.Reset()
+		reportsDir := GinkgoT().TempDir()
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.ReportsDir = reportsDir
+		})
+		defer e2e_config.PopOverrideScope()
+
+		runreport.SetCurrentSpec("spec one")
+		runreport.RecordVolume("vol-1", "mayastor-nvmf")
+		runreport.RecordTransition("PVC vol-1 bound", "MODIFIED", "vol-1")
+		runreport.SetCurrentSpec("spec two")
+		runreport.RecordFault("pod-kill", "mayastor-abcde")
+		runreport.RecordFioResult("fio-pod", 125000000)
+
+		records := runreport.Records()
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].Spec).To(Equal("spec one"))
+		Expect(records[0].Volumes).To(HaveLen(1))
+		Expect(records[0].Transitions).To(HaveLen(1))
+		Expect(records[1].Spec).To(Equal("spec two"))
+		Expect(records[1].Faults).To(HaveLen(1))
+		Expect(records[1].FioResults).To(HaveLen(1))
+
+		Expect(runreport.Write("harness")).To(Succeed())
+		contents, err := os.ReadFile(path.Join(reportsDir, "runreport-harness.json"))
+		Expect(err).ToNot(HaveOccurred())
+		var parsed []runreport.SpecRecord
+		Expect(json.Unmarshal(contents, &parsed)).To(Succeed())
+		Expect(parsed).To(HaveLen(2))
+		Expect(parsed[0].Volumes[0].Name).To(Equal("vol-1"))
+		Expect(parsed[1].Faults[0].Kind).To(Equal("pod-kill"))
+
+		rendered, err := os.ReadFile(path.Join(reportsDir, "runreport-harness.html"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(rendered)).To(ContainSubstring("spec two"))
+		Expect(string(rendered)).To(ContainSubstring("pod-kill"))
+	})
+
+	It("should not write artefacts when no reports directory is configured", func() {
+		runreport.Reset()
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.ReportsDir = ""
+		})
+		defer e2e_config.PopOverrideScope()
+		runreport.RecordFault("pod-kill", "mayastor-abcde")
+		Expect(runreport.Write("harness")).To(Succeed())
+	})
+})
+
+var _ = Describe("residual host state parsing", func() {
+	It("should spot listeners on the nvmf port in the socket table", func() {
+		// 20E4 is 8420; state 0A is LISTEN, 01 is ESTABLISHED.
+		sockets := "  sl  local_address rem_address   st\n" +
+			"   0: 00000000:20E4 00000000:0000 0A\n" +
+			"   1: 0100007F:0016 0100007F:8000 01\n"
+		Expect(k8stest.HasTcpListener(sockets, 8420)).To(BeTrue())
+		Expect(k8stest.HasTcpListener(sockets, 22)).To(BeFalse())
+		Expect(k8stest.HasTcpListener("", 8420)).To(BeFalse())
+	})
+
+	It("should extract hugepage counts from meminfo", func() {
+		meminfo := "MemTotal:       16000000 kB\n" +
+			"HugePages_Total:    1024\n" +
+			"HugePages_Free:      512\n" +
+			"Hugepagesize:       2048 kB\n"
+		total, free := k8stest.ParseHugePages(meminfo)
+		Expect(total).To(Equal(1024))
+		Expect(free).To(Equal(512))
+	})
+})
+
+var _ = Describe("kubectl shim", func() {
+	It("should normalise CRLF output and apply flags consistently", func() {
+		var seenArgs []string
+		restore := kubectlshim.SetRunner(func(args []string, opts kubectlshim.Options) (string, string, int, error) {
+			seenArgs = args
+			return "NAME\r\nmayastor-pdb\r\n", "", 0, nil
+		})
+		defer restore()
+
+		out, err := kubectlshim.RunWith(kubectlshim.Options{Context: "e2e", Namespace: "mayastor"},
+			"get", "pdb")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("NAME\nmayastor-pdb\n"), "CRLF line endings not normalised")
+		Expect(seenArgs).To(Equal([]string{"--context", "e2e", "--namespace", "mayastor", "get", "pdb"}))
+	})
+
+	It("should return a structured error on non-zero exit", func() {
+		restore := kubectlshim.SetRunner(func(args []string, opts kubectlshim.Options) (string, string, int, error) {
+			return "", "error: the server doesn't have a resource type \"nonesuch\"\r\n", 1, nil
+		})
+		defer restore()
+
+		_, err := kubectlshim.Run("get", "nonesuch")
+		Expect(err).To(HaveOccurred())
+		var kubectlError *kubectlshim.Error
+		Expect(errors.As(err, &kubectlError)).To(BeTrue(), "error is not a *kubectlshim.Error")
+		Expect(kubectlError.ExitCode).To(Equal(1))
+		Expect(kubectlError.Stderr).ToNot(ContainSubstring("\r"))
+	})
+})
+
+var _ = Describe("fio latency log parsing", func() {
+	It("should parse samples and translate units and directions", func() {
+		log := "1000, 250000, 0, 4096, 0\r\n2000, 1500000, 1, 4096, 0\n\n"
+		samples, err := k8stest.ParseFioLatencyLog(strings.ReplaceAll(log, "\r\n", "\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(samples).To(HaveLen(2))
+		Expect(samples[0].TimeMsecs).To(Equal(1000))
+		Expect(samples[0].LatencyUsecs).To(Equal(250.0))
+		Expect(samples[0].Direction).To(Equal("read"))
+		Expect(samples[1].Direction).To(Equal("write"))
+
+		_, err = k8stest.ParseFioLatencyLog("not a latency log")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should extract mean completion latency from fio output", func() {
+		output := "  read: IOPS=1000, BW=4000KiB/s\n" +
+			"    clat (usec): min=92, max=10430, avg=250.50, stdev=40.21\n" +
+			"  write: IOPS=1000, BW=4000KiB/s\n" +
+			"    clat (msec): min=1, max=20, avg=1.50, stdev=0.40\n"
+		latency, err := k8stest.FioMeanLatencyUsecs(output)
+		Expect(err).ToNot(HaveOccurred())
+		// (250.5 + 1500) / 2
+		Expect(latency).To(BeNumerically("~", 875.25, 0.01))
+
+		_, err = k8stest.FioMeanLatencyUsecs("no latency here")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should account stall time from latency samples", func() {
+		samples := []k8stest.FioLatencySample{
+			{TimeMsecs: 1000, LatencyUsecs: 200, Direction: "read"},
+			{TimeMsecs: 2000, LatencyUsecs: 2000000, Direction: "read"},
+			{TimeMsecs: 3000, LatencyUsecs: 1500000, Direction: "write"},
+		}
+		Expect(k8stest.IoStallTimeFromLatency(samples, 1000000)).To(Equal(2 * time.Second))
+	})
+})
+
+var _ = Describe("pool metrics parsing", func() {
+	It("should extract pool capacity and usage from Prometheus output", func() {
+		metrics := `# HELP pool_total_size_bytes total size of the pool
+pool_total_size_bytes{name="pool-on-node-1",node="node-1"} 1.073741824e+10
+pool_used_size_bytes{name="pool-on-node-1",node="node-1"} 536870912
+other_metric{name="pool-on-node-1"} 1
+`
+		records := k8stest.ParsePoolMetrics(metrics)
+		Expect(records).To(HaveLen(1))
+		Expect(records["pool-on-node-1"].CapacityBytes).To(Equal(int64(10737418240)))
+		Expect(records["pool-on-node-1"].UsedBytes).To(Equal(int64(536870912)))
+	})
+})
+
+var _ = Describe("bulk PVC deletion", func() {
+	It("should delete all PVCs in a namespace and report none remaining", func() {
+		const nameSpace = "harness-validation"
+		for ix := 0; ix < 20; ix++ {
+			_, err := k8stest.CreatePVC(fmt.Sprintf("harness-pvc-%d", ix), 64, "fake-sc", nameSpace)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		pvcs, err := k8stest.ListPvcs(nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvcs.Items).To(HaveLen(20))
+
+		remaining, err := k8stest.DeleteAllPvcs(nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remaining).To(BeZero())
+
+		pvcs, err = k8stest.ListPvcs(nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvcs.Items).To(BeEmpty())
+	})
+})
+
+var _ = Describe("log merging", func() {
+	It("should normalise the component timestamp formats", func() {
+		for _, line := range []string{
+			"2021-05-04T12:00:01.123456789Z moac rfc3339 nano",
+			"2021-05-04T12:00:01Z sidecar rfc3339 no subseconds",
+			"[2021-05-04T12:00:01.123Z  INFO io-engine bracketed",
+			"2021-05-04 12:00:01.123 space separated",
+		} {
+			stamp, ok := logmerge.ParseTimestamp(line, 2021)
+			Expect(ok).To(BeTrue(), "failed to parse %q", line)
+			Expect(stamp.Year()).To(Equal(2021))
+			Expect(stamp.Second()).To(Equal(1))
+		}
+		stamp, ok := logmerge.ParseTimestamp("I0504 12:00:01.123456 1 controller.go:42] klog", 2021)
+		Expect(ok).To(BeTrue())
+		Expect(stamp.Month()).To(Equal(time.May))
+		Expect(stamp.Second()).To(Equal(1))
+	})
+
+	It("should order entries across components and keep continuations adjacent", func() {
+		merged := logmerge.Merge(map[string]string{
+			"moac": "2021-05-04T12:00:02Z second\ncontinuation line\n",
+			"io-engine": "[2021-05-04T12:00:01.000Z  INFO first\n" +
+				"[2021-05-04T12:00:03.000Z  INFO third\n",
+			"test": "before any timestamp is dropped\n",
+		})
+		Expect(merged).To(HaveLen(4))
+		Expect(merged[0].Component).To(Equal("io-engine"))
+		Expect(merged[1].Line).To(ContainSubstring("second"))
+		Expect(merged[2].Line).To(Equal("continuation line"))
+		Expect(merged[3].Line).To(ContainSubstring("third"))
+		Expect(logmerge.Format(merged)).To(ContainSubstring("[moac"))
+	})
+
+	It("should include recorded test actions in the merged stream", func() {
+		logmerge.RecordAction("deleting pod %s", "fio-0")
+		merged := logmerge.Merge(map[string]string{"test": logmerge.ActionStream()})
+		Expect(merged).ToNot(BeEmpty())
+		Expect(merged[len(merged)-1].Line).To(ContainSubstring("deleting pod fio-0"))
+	})
+})
+
+var _ = Describe("csc capability parsing", func() {
+	It("should extract capability names from csc output", func() {
+		out := "&csi.NodeServiceCapability_Rpc{Type:STAGE_UNSTAGE_VOLUME}\n" +
+			"GET_VOLUME_STATS\nSTAGE_UNSTAGE_VOLUME\n"
+		Expect(k8stest.ParseCscCapabilities(out)).To(Equal(
+			[]string{"STAGE_UNSTAGE_VOLUME", "GET_VOLUME_STATS"}))
+	})
+})
+
+var _ = Describe("csi-sanity output parsing", func() {
+	It("should extract counts and failed spec names from a failing run", func() {
+		out := "Summarizing 2 Failures:\n\n" +
+			"[Fail] Controller Service [It] should fail when no name is provided\n" +
+			"[Fail] Node Service NodeStageVolume [It] should fail when no volume id is provided\n\n" +
+			"Ran 65 of 73 Specs in 42.001 seconds\n" +
+			"FAIL! -- 63 Passed | 2 Failed | 0 Pending | 8 Skipped\n"
+		result, err := k8stest.ParseCsiSanityOutput(out)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Passed).To(Equal(63))
+		Expect(result.Failed).To(Equal(2))
+		Expect(result.Skipped).To(Equal(8))
+		Expect(result.FailedSpecs).To(HaveLen(2))
+		Expect(result.FailedSpecs[0]).To(ContainSubstring("Controller Service"))
+	})
+
+	It("should report output without a summary as an error", func() {
+		_, err := k8stest.ParseCsiSanityOutput("panic: connection refused")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("harness leak check", func() {
+	// settledSnapshot waits for transient goroutines of earlier specs to
+	// exit, so the baseline is not inflated.
+	settledSnapshot := func() k8stest.LeakSnapshot {
+		baseline := k8stest.TakeLeakSnapshot()
+		for ix := 0; ix < 20; ix++ {
+			time.Sleep(250 * time.Millisecond)
+			next := k8stest.TakeLeakSnapshot()
+			if next.Goroutines >= baseline.Goroutines {
+				return baseline
+			}
+			baseline = next
+		}
+		return baseline
+	}
+
+	It("should pass when the footprint returns to the baseline", func() {
+		baseline := settledSnapshot()
+		Expect(k8stest.CheckLeaks(baseline, 2)).To(Succeed())
+	})
+
+	It("should detect leaked goroutines beyond the slack", func() {
+		baseline := settledSnapshot()
+		release := make(chan struct{})
+		leaked := e2e_config.GetConfig().LeakCheck.GoroutineSlack + 50
+		for ix := 0; ix < leaked; ix++ {
+			go func() { <-release }()
+		}
+		Expect(k8stest.CheckLeaks(baseline, 1)).ToNot(Succeed())
+		close(release)
+		Expect(k8stest.CheckLeaks(baseline, 5)).To(Succeed())
+	})
+})
+
+var _ = Describe("control plane REST client", func() {
+	It("should retry transient server errors with backoff", func() {
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.RestClient.BackoffMsecs = 10
+		})
+		defer e2e_config.PopOverrideScope()
+
+		failures := 2
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests <= failures {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			Expect(r.URL.Path).To(Equal("/v1/pools"))
+			fmt.Fprint(w, `[{"name":"pool-a","node":"node-1","capacity":100,"used":25,"state":"online"}]`)
+		}))
+		defer server.Close()
+
+		pools, err := restclient.New(server.URL).GetPools()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(Equal(failures + 1))
+		Expect(pools).To(HaveLen(1))
+		Expect(pools[0].Name).To(Equal("pool-a"))
+		Expect(pools[0].Used).To(Equal(int64(25)))
+	})
+
+	It("should not retry definitive client errors", func() {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := restclient.New(server.URL).GetVolume("no-such-uuid")
+		Expect(err).To(HaveOccurred())
+		Expect(requests).To(Equal(1))
+	})
+})
+
+var _ = Describe("typed MSV conversion", func() {
+	mkRawMsv := func(replicaKey string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       "MayastorVolume",
+			"metadata": map[string]interface{}{
+				"name": "11111111-e2e0-0000-0000-000000000001",
+			},
+			"spec": map[string]interface{}{
+				replicaKey:       int64(2),
+				"requiredBytes":  int64(1024),
+				"preferredNodes": []interface{}{"node-1"},
+			},
+			"status": map[string]interface{}{
+				"state": "healthy",
+				"size":  int64(1024),
+				"nexus": map[string]interface{}{
+					"node":  "node-1",
+					"state": "NEXUS_ONLINE",
+					"children": []interface{}{
+						map[string]interface{}{"uri": "bdev:///repl-1", "state": "CHILD_ONLINE"},
+					},
+				},
+				"replicas": []interface{}{
+					map[string]interface{}{"node": "node-1", "pool": "pool-1", "uri": "bdev:///repl-1", "offline": false},
+					map[string]interface{}{"node": "node-2", "pool": "pool-2", "uri": "nvmf://repl-2", "offline": true},
+				},
+			},
+		}}
+	}
+
+	It("should convert spec and status across API field layouts", func() {
+		for _, replicaKey := range []string{"replicaCount", "numReplicas"} {
+			msv, err := k8stest.MsvFromUnstructured(mkRawMsv(replicaKey))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(msv.Spec.ReplicaCount).To(Equal(2), "replica key %s", replicaKey)
+			Expect(msv.Spec.PreferredNodes).To(Equal([]string{"node-1"}))
+			Expect(msv.Status.State).To(Equal("healthy"))
+			Expect(msv.Status.Nexus.Children).To(HaveLen(1))
+			Expect(msv.Status.Replicas[1].Offline).To(BeTrue())
+			Expect(msv.Status.Replicas[1].Pool).To(Equal("pool-2"))
+		}
+	})
+
+	It("should deep copy without sharing memory", func() {
+		msv, err := k8stest.MsvFromUnstructured(mkRawMsv("replicaCount"))
+		Expect(err).ToNot(HaveOccurred())
+		copied := msv.DeepCopy()
+		copied.Status.Replicas[0].Pool = "elsewhere"
+		copied.Spec.PreferredNodes[0] = "node-9"
+		Expect(msv.Status.Replicas[0].Pool).To(Equal("pool-1"))
+		Expect(msv.Spec.PreferredNodes[0]).To(Equal("node-1"))
+	})
+})
+
+var _ = Describe("watch based waiters", func() {
+	It("should return as soon as the watched state change arrives", func() {
+		podDef := k8stest.NewVolumePodSpec("waiter-pod", "waiter-vol", "dummy-image",
+			[]string{"sleep", "1"})
+		_, err := k8stest.CreatePod(podDef, "default")
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_ = k8stest.DeletePod("waiter-pod", "default")
+		}()
+
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(200 * time.Millisecond)
+			Expect(k8stest.DeletePod("waiter-pod", "default")).To(Succeed())
+			running := podDef.DeepCopy()
+			running.Status.Phase = coreV1.PodRunning
+			_, err := k8stest.CreatePod(running, "default")
+			Expect(err).ToNot(HaveOccurred())
+		}()
+
+		started := time.Now()
+		Expect(k8stest.WaitPodRunning("waiter-pod", "default", 30)).To(Succeed())
+		Expect(time.Since(started)).To(BeNumerically("<", 10*time.Second),
+			"waiter did not react to the watch event")
+	})
+
+	It("should time out with an error when the state never arrives", func() {
+		err := k8stest.WaitPodRunning("no-such-pod", "default", 1)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no-such-pod"))
+	})
+})
+
+var _ = Describe("pool CRD flavour discovery", func() {
+	AfterEach(func() {
+		k8stest.SetFakeServedResources(nil)
+		k8stest.ResetPoolApiCache()
+	})
+
+	It("should fall back to the MOAC-era flavour when none is served", func() {
+		k8stest.ResetPoolApiCache()
+		Expect(k8stest.PoolApiVersion()).To(Equal("openebs.io/v1alpha1"))
+	})
+
+	It("should prefer the newest served diskpool flavour", func() {
+		k8stest.SetFakeServedResources([]*metaV1.APIResourceList{
+			{GroupVersion: "openebs.io/v1beta1", APIResources: []metaV1.APIResource{{Name: "diskpools"}}},
+			{GroupVersion: "openebs.io/v1beta2", APIResources: []metaV1.APIResource{{Name: "diskpools"}}},
+		})
+		k8stest.ResetPoolApiCache()
+		Expect(k8stest.PoolApiVersion()).To(Equal("openebs.io/v1beta2"))
+	})
+})
+
+var _ = Describe("snapshot API version discovery", func() {
+	AfterEach(func() {
+		k8stest.SetFakeServedResources(nil)
+		k8stest.ResetSnapshotApiCache()
+	})
+
+	It("should assume v1 when none is served", func() {
+		k8stest.ResetSnapshotApiCache()
+		Expect(k8stest.SnapshotApiVersion()).To(Equal("snapshot.storage.k8s.io/v1"))
+	})
+
+	It("should fall back to v1beta1 on clusters which only serve it", func() {
+		k8stest.SetFakeServedResources([]*metaV1.APIResourceList{
+			{GroupVersion: "snapshot.storage.k8s.io/v1beta1", APIResources: []metaV1.APIResource{{Name: "volumesnapshots"}}},
+		})
+		k8stest.ResetSnapshotApiCache()
+		Expect(k8stest.SnapshotApiVersion()).To(Equal("snapshot.storage.k8s.io/v1beta1"))
+	})
+})
+
+var _ = Describe("replica checksum parsing", func() {
+	It("should extract the checksum from checker pod output", func() {
+		out := "connecting to 10.0.0.1\nreplica-checksum: 0123abcd\n"
+		sum, err := k8stest.ParseReplicaChecksum(out)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sum).To(Equal("0123abcd"))
+	})
+
+	It("should error when no checksum line is present", func() {
+		_, err := k8stest.ParseReplicaChecksum("nvme connect failed\n")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("workload factory", func() {
+	It("should return the implementation matching each known name", func() {
+		for _, name := range []string{"fio", "dd", "sqlite"} {
+			generator, err := workload.New(name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(generator.Name()).To(Equal(name))
+		}
+	})
+
+	It("should honour the configured default workload", func() {
+		generator, err := workload.Default()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(generator.Name()).To(Equal(e2e_config.GetConfig().DefaultWorkload))
+	})
+
+	It("should reject unknown workload names", func() {
+		_, err := workload.New("bonnie")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("bonnie"))
+	})
+
+	It("should refuse to wait for a workload that was not started", func() {
+		generator, err := workload.New("fio")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(generator.Wait()).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("per-suite test namespaces", func() {
+	It("should derive the namespace name from the suite binary", func() {
+		Expect(k8stest.SuiteNamespace("snapshot.test")).To(Equal("e2e-maya-snapshot"))
+		Expect(k8stest.SuiteNamespace("Basic_Volume_IO.test")).To(Equal("e2e-maya-basic-volume-io"))
+		Expect(k8stest.SuiteNamespace("")).To(Equal("e2e-maya-suite"))
+	})
+
+	It("should resolve the empty namespace to the test namespace", func() {
+		// The fake environment pins the test namespace to "default".
+		Expect(k8stest.TestNamespace()).To(Equal("default"))
+		_, err := k8stest.CreatePVC("harness-ns-pvc", 64, "fake-sc", "")
+		Expect(err).ToNot(HaveOccurred())
+		pvc, err := k8stest.GetPVC("harness-ns-pvc", "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Namespace).To(Equal("default"))
+		Expect(k8stest.DeletePVC("harness-ns-pvc", "")).To(Succeed())
+	})
+})
+
+var _ = Describe("fault platform factory", func() {
+	It("should error when no platform is configured", func() {
+		_, err := faults.NewPlatform()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return the implementation matching the configured type", func() {
+		for _, platformType := range []string{"libvirt", "aws", "gcp"} {
+			e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+				cfg.Platform.Type = platformType
+			})
+			platform, err := faults.NewPlatform()
+			e2e_config.PopOverrideScope()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(platform.Name()).To(Equal(platformType))
+		}
+	})
+
+	It("should reject unknown platform types", func() {
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.Platform.Type = "bare-metal"
+		})
+		defer e2e_config.PopOverrideScope()
+		_, err := faults.NewPlatform()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("bare-metal"))
+	})
+})
+
+var _ = Describe("node label and taint helpers", func() {
+	const nodeName = "harness-fake-node"
+
+	It("should round-trip labels through patches", func() {
+		Expect(k8stest.AddFakeNode(nodeName)).To(Succeed())
+		Expect(k8stest.LabelNode(nodeName, "e2e-test/role", "storage")).To(Succeed())
+		node, err := k8stest.GetNode(nodeName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(node.Labels).To(HaveKeyWithValue("e2e-test/role", "storage"))
+
+		Expect(k8stest.UnlabelNode(nodeName, "e2e-test/role")).To(Succeed())
+		node, err = k8stest.GetNode(nodeName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(node.Labels).ToNot(HaveKey("e2e-test/role"))
+	})
+
+	It("should control mayastor membership through the engine label", func() {
+		Expect(k8stest.SetMayastorNodeLabel(nodeName, true)).To(Succeed())
+		names, err := k8stest.GetMayastorNodeNames()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names).To(ContainElement(nodeName))
+
+		Expect(k8stest.SetMayastorNodeLabel(nodeName, false)).To(Succeed())
+		names, err = k8stest.GetMayastorNodeNames()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names).ToNot(ContainElement(nodeName))
+	})
+
+	It("should round-trip taints through patches", func() {
+		Expect(k8stest.TaintNode(nodeName, "e2e-test/drain", "true", coreV1.TaintEffectNoSchedule)).To(Succeed())
+		node, err := k8stest.GetNode(nodeName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(node.Spec.Taints).To(HaveLen(1))
+		Expect(node.Spec.Taints[0].Key).To(Equal("e2e-test/drain"))
+
+		// Retainting with the same key replaces, not appends.
+		Expect(k8stest.TaintNode(nodeName, "e2e-test/drain", "true", coreV1.TaintEffectNoExecute)).To(Succeed())
+		node, err = k8stest.GetNode(nodeName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(node.Spec.Taints).To(HaveLen(1))
+		Expect(node.Spec.Taints[0].Effect).To(Equal(coreV1.TaintEffectNoExecute))
+
+		Expect(k8stest.UntaintNode(nodeName, "e2e-test/drain")).To(Succeed())
+		node, err = k8stest.GetNode(nodeName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(node.Spec.Taints).To(BeEmpty())
+	})
+
+	It("should report errors for nodes which do not exist", func() {
+		Expect(k8stest.LabelNode("nonesuch", "a", "b")).ToNot(Succeed())
+		Expect(k8stest.UntaintNode("nonesuch", "a")).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("resource leak attribution", func() {
+	It("should name the spec and the resources it leaked", func() {
+		k8stest.SetCurrentSpecText("leaky spec")
+		_, err := k8stest.CreatePVC("harness-leak-pvc", 64, "fake-sc", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = k8stest.AfterEachCheck("leaky spec")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("pvc default/harness-leak-pvc"))
+
+		// With auto-clean enabled the leak is deleted instead.
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.AfterEachCheck.AutoClean = true
+		})
+		defer e2e_config.PopOverrideScope()
+		Expect(k8stest.AfterEachCheck("leaky spec")).To(Succeed())
+		_, err = k8stest.GetPVC("harness-leak-pvc", "")
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue(), "leaked PVC not cleaned")
+		Expect(k8stest.AfterEachCheck("leaky spec")).To(Succeed())
+	})
+
+	It("should not flag resources the spec cleaned up", func() {
+		k8stest.SetCurrentSpecText("tidy spec")
+		_, err := k8stest.CreatePVC("harness-tidy-pvc", 64, "fake-sc", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(k8stest.DeletePVC("harness-tidy-pvc", "")).To(Succeed())
+		Expect(k8stest.AfterEachCheck("tidy spec")).To(Succeed())
+	})
+})
+
+var _ = Describe("restart accounting", func() {
+	// mkRestartedPod creates a fake mayastor pod carrying a container
+	// restart count.
+	mkRestartedPod := func(podName string, restarts int32) {
+		_, err := k8stest.CreatePod(&coreV1.Pod{
+			ObjectMeta: metaV1.ObjectMeta{Name: podName},
+			Status: coreV1.PodStatus{
+				ContainerStatuses: []coreV1.ContainerStatus{
+					{Name: "io-engine", RestartCount: restarts},
+				},
+			},
+		}, k8stest.MayastorNamespace)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("should flag restarts no budget covers", func() {
+		mkRestartedPod("mayastor-harness-restarts", 2)
+		defer func() {
+			Expect(k8stest.DeletePod("mayastor-harness-restarts", k8stest.MayastorNamespace)).To(Succeed())
+		}()
+
+		err := k8stest.AfterEachCheck("restart probe")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("mayastor-harness-restarts/io-engine (2)"))
+
+		// A sufficient per-spec budget covers the restarts ...
+		Expect(k8stest.AllowRestarts("mayastor-.*", 2)).To(Succeed())
+		Expect(k8stest.AfterEachCheck("restart probe")).To(Succeed())
+
+		// ... an exhausted one does not, and budgets last one spec only.
+		Expect(k8stest.AllowRestarts("mayastor-.*", 1)).To(Succeed())
+		Expect(k8stest.AfterEachCheck("restart probe")).ToNot(Succeed())
+		Expect(k8stest.AfterEachCheck("restart probe")).ToNot(Succeed())
+
+		// A configured standing tolerance covers every spec.
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.AfterEachCheck.RestartTolerance = []e2e_config.RestartAllowance{
+				{Pods: "mayastor-.*", Count: 5},
+			}
+		})
+		defer e2e_config.PopOverrideScope()
+		Expect(k8stest.AfterEachCheck("restart probe")).To(Succeed())
+	})
+
+	It("should not flag restarts which predate the spec", func() {
+		mkRestartedPod("mayastor-harness-baseline", 2)
+		defer func() {
+			Expect(k8stest.DeletePod("mayastor-harness-baseline", k8stest.MayastorNamespace)).To(Succeed())
+		}()
+
+		// The baseline snapshot absorbs the pre-existing restarts.
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.BeforeEachCheck.Strictness = "warn"
+		})
+		defer e2e_config.PopOverrideScope()
+		Expect(k8stest.BeforeEachCheck()).To(Succeed())
+		Expect(k8stest.AfterEachCheck("restart probe")).To(Succeed())
+	})
+
+	It("should reject malformed budgets", func() {
+		Expect(k8stest.AllowRestarts("(", 1)).ToNot(Succeed())
+		Expect(k8stest.AllowRestarts("mayastor-.*", 0)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("preflight reporting", func() {
+	It("should report a cluster with no labelled nodes as not ready", func() {
+		report, err := k8stest.PreflightCheck()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Ok()).To(BeFalse())
+		Expect(report.Failures).To(HaveLen(1))
+		Expect(report.Failures[0].Check).To(Equal("engine-label"))
+		Expect(report.String()).To(ContainSubstring("openebs.io/engine"))
+	})
+})
+
+var _ = Describe("network fault validation", func() {
+	It("should reject invalid partition modes and loss percentages", func() {
+		var nf faults.NetworkFaults
+		Expect(nf.PartitionNode("node-1", "10.0.0.1", "LOG")).ToNot(Succeed())
+		Expect(nf.AddPacketLoss("node-1", 0)).ToNot(Succeed())
+		Expect(nf.AddPacketLoss("node-1", 101)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("event verification", func() {
+	It("should match expected events and expose leaked warnings", func() {
+		watcher, err := k8stest.StartEventWatcher("default")
+		Expect(err).ToNot(HaveOccurred())
+		defer watcher.Stop()
+
+		Expect(k8stest.AddFakeEvent("default", "harness-vol", "ProvisioningSucceeded", coreV1.EventTypeNormal)).To(Succeed())
+		Expect(watcher.ExpectEvent("ProvisioningSucceeded", "harness-vol", 5)).To(Succeed())
+		Expect(watcher.ExpectEvent("ProvisioningSucceeded", "", 5)).To(Succeed(),
+			"an empty involved object must match any object")
+		Expect(watcher.ErrorEvents()).To(BeEmpty())
+
+		Expect(k8stest.AddFakeEvent("default", "harness-vol", "ProvisioningFailed", coreV1.EventTypeWarning)).To(Succeed())
+		Expect(watcher.ExpectEvent("ProvisioningFailed", "harness-vol", 5)).To(Succeed())
+		Expect(watcher.ErrorEvents()).ToNot(BeEmpty(), "the warning event must be exposed")
+	})
+
+	It("should time out waiting for an event never emitted", func() {
+		watcher, err := k8stest.StartEventWatcher("default")
+		Expect(err).ToNot(HaveOccurred())
+		defer watcher.Stop()
+		Expect(watcher.ExpectEvent("NoSuchReason", "no-such-object", 1)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("loki log queries", func() {
+	It("should grep lines and quote forbidden matches", func() {
+		lines := []string{
+			"INFO volume published",
+			"ERROR nvmf keep-alive timeout on nqn.2019-05",
+			"INFO rebuild complete",
+		}
+		matched, err := loki.Grep(lines, "keep-alive timeout")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(matched).To(HaveLen(1))
+		Expect(loki.AssertAbsent(lines, "panic", "ENOSPC")).To(Succeed())
+		err = loki.AssertAbsent(lines, "keep-alive timeout")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("nqn.2019-05"))
+		_, err = loki.Grep(lines, "(unbalanced")
+		Expect(err).To(HaveOccurred(), "an invalid pattern must be rejected")
+	})
+
+	It("should pull the window from a loki endpoint when configured", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/loki/api/v1/query_range"))
+			Expect(r.URL.Query().Get("query")).To(Equal(`{app="mayastor"}`))
+			_, _ = w.Write([]byte(`{"data":{"result":[{"values":[["1","line one"],["2","line two"]]}]}}`))
+		}))
+		defer server.Close()
+		e2e_config.PushOverrideScope(func(cfg *e2e_config.E2EConfig) {
+			cfg.Loki.Endpoint = server.URL
+		})
+		defer e2e_config.PopOverrideScope()
+
+		lines, err := loki.LogsForWindow("io-engine", time.Now().Add(-time.Minute), time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lines).To(Equal([]string{"line one", "line two"}))
+
+		_, err = loki.LogsForWindow("no-such-component", time.Now(), time.Now())
+		Expect(err).To(HaveOccurred())
+	})
+})
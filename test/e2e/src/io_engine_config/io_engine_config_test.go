@@ -0,0 +1,83 @@
+// io-engine configuration injection test. When the configuration
+// declares extra io-engine environment variables or arguments
+// (configuration-matrix runs), the suite verifies the running
+// io-engine pods actually carry them and, for log level settings,
+// that the logs show the setting took effect. Skipped on a default
+// deployment with no injected configuration.
+package io_engine_config_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func ioEngineConfigTest() {
+	cfg := e2e_config.GetConfig().IoEngine
+	if len(cfg.Env) == 0 && len(cfg.Args) == 0 {
+		Skip("no io-engine configuration injected, default deployment")
+	}
+
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, "app=mayastor")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pods.Items).ToNot(BeEmpty(), "no io-engine pods found")
+
+	for _, pod := range pods.Items {
+		podEnv := map[string]string{}
+		var podArgs []string
+		for _, container := range pod.Spec.Containers {
+			if container.Name != "mayastor" {
+				continue
+			}
+			for _, env := range container.Env {
+				podEnv[env.Name] = env.Value
+			}
+			podArgs = container.Args
+		}
+		for name, value := range cfg.Env {
+			Expect(podEnv[name]).To(Equal(value),
+				"env var %s not applied on pod %s", name, pod.Name)
+		}
+		for _, arg := range cfg.Args {
+			Expect(podArgs).To(ContainElement(arg),
+				"argument %s not applied on pod %s", arg, pod.Name)
+		}
+
+		// A debug log level must be visible in the output; the engine
+		// logs at debug only when so configured.
+		if level, ok := cfg.Env["RUST_LOG"]; ok && strings.Contains(level, "debug") {
+			log, err := k8stest.GetPodLog(pod.Name, k8stest.MayastorNamespace)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(strings.Contains(log, "DEBUG")).To(BeTrue(),
+				fmt.Sprintf("no debug output from pod %s despite RUST_LOG=%s", pod.Name, level))
+		}
+	}
+}
+
+func TestIoEngineConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "io-engine configuration")
+}
+
+var _ = Describe("io-engine configuration injection", func() {
+	It("should apply the injected environment and arguments", func() {
+		ioEngineConfigTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,231 @@
+// IO soak test. A mix of fio workloads runs concurrently for the
+// configured duration. The mix is built from configured job classes,
+// each with its own storage class parameters (protocol, replica count,
+// fsType, thin provisioning) and volume count, so a single soak run
+// exercises mixed workloads, e.g. single replica thin volumes next to
+// multi replica thick ones. Each job is throttled by a duty cycle
+// drawn at random from the configured table, so jobs run at varied
+// rates; the seed of the draw is recorded in the run manifest and can
+// be set in the configuration to reproduce a mix. Results are reported
+// per class in the run manifest.
+package io_soak_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/chaos"
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/manifest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const volSizeMb = 256
+
+// fioSoakJob is one volume plus fio pod instantiated from a job class.
+type fioSoakJob struct {
+	class   e2e_config.IoSoakJobClass
+	volName string
+	podName string
+}
+
+// soakResult is the per-volume outcome recorded in the run manifest,
+// tagged with its class for per-class breakdowns.
+type soakResult struct {
+	Class          string  `json:"class"`
+	Volume         string  `json:"volume"`
+	BandwidthBytes float64 `json:"bandwidthBytes"`
+	ThinkTimeUsecs int     `json:"thinkTimeUsecs,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// drawDutyCycles validates the configured duty cycle table and draws
+// one cycle per job from it; the draw is seeded from the configuration
+// or, failing that, the clock, and the seed is recorded in the run
+// manifest so a mix can be reproduced.
+func drawDutyCycles(jobCount int) []e2e_config.FioDutyCycle {
+	cfg := e2e_config.GetConfig().IoSoak
+	for ix, cycle := range cfg.FioDutyCycles {
+		Expect(cycle.ThinkTimeUsecs).To(BeNumerically(">", 0),
+			"duty cycle %d has no think time", ix)
+		Expect(cycle.ThinkTimeBlocks).To(BeNumerically(">", 0),
+			"duty cycle %d has no think time block count", ix)
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	logf.Log.Info("drawing io soak duty cycles", "seed", seed, "cycles", len(cfg.FioDutyCycles))
+	Expect(manifest.AppendSection("ioSoakSeed", seed)).To(Succeed())
+	rng := rand.New(rand.NewSource(seed))
+	cycles := make([]e2e_config.FioDutyCycle, jobCount)
+	if len(cfg.FioDutyCycles) == 0 {
+		return cycles
+	}
+	for ix := range cycles {
+		cycles[ix] = cfg.FioDutyCycles[rng.Intn(len(cfg.FioDutyCycles))]
+	}
+	return cycles
+}
+
+// scName returns the storage class created for a job class.
+func scName(class e2e_config.IoSoakJobClass) string {
+	return "io-soak-" + class.Name
+}
+
+// mkClassStorageClass creates the storage class of a job class from its
+// parameters.
+func mkClassStorageClass(class e2e_config.IoSoakJobClass) error {
+	params := map[string]string{
+		"fsType": class.FsType,
+	}
+	if class.Thin {
+		params["thin"] = strconv.FormatBool(class.Thin)
+	}
+	return k8stest.MkStorageClassWithParams(scName(class), class.Replicas, class.Protocol, params)
+}
+
+// makeJobs instantiates the volumes and fio pods of all configured job
+// classes.
+func makeJobs(classes []e2e_config.IoSoakJobClass) []fioSoakJob {
+	var jobs []fioSoakJob
+	for _, class := range classes {
+		Expect(class.Name).ToNot(BeEmpty(), "job class without a name")
+		Expect(class.Replicas).To(BeNumerically(">", 0),
+			"job class %s has no replica count", class.Name)
+		Expect(mkClassStorageClass(class)).To(Succeed())
+		for ix := 0; ix < class.Volumes; ix++ {
+			job := fioSoakJob{
+				class:   class,
+				volName: fmt.Sprintf("io-soak-%s-%d", class.Name, ix),
+			}
+			job.podName = job.volName
+			_, err := k8stest.CreatePVC(job.volName, volSizeMb, scName(class), nameSpace)
+			Expect(err).ToNot(HaveOccurred())
+			jobs = append(jobs, job)
+		}
+	}
+	for _, job := range jobs {
+		Expect(k8stest.WaitPvcBound(job.volName, nameSpace, defTimeoutSecs)).To(Succeed())
+		_, err := k8stest.CreateFioPod(job.podName, job.volName, nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+	}
+	for _, job := range jobs {
+		Expect(k8stest.WaitPodRunning(job.podName, nameSpace, defTimeoutSecs)).To(Succeed())
+	}
+	return jobs
+}
+
+func destroyJobs(jobs []fioSoakJob) {
+	for _, job := range jobs {
+		Expect(k8stest.DeletePod(job.podName, nameSpace)).To(Succeed())
+		Expect(k8stest.DeletePVC(job.volName, nameSpace)).To(Succeed())
+	}
+	for _, class := range e2e_config.GetConfig().IoSoak.JobClasses {
+		Expect(k8stest.RmStorageClass(scName(class))).To(Succeed())
+	}
+}
+
+func ioSoakTest() {
+	cfg := e2e_config.GetConfig().IoSoak
+	Expect(cfg.JobClasses).ToNot(BeEmpty(), "no soak job classes configured")
+
+	jobs := makeJobs(cfg.JobClasses)
+	defer destroyJobs(jobs)
+
+	// With chaos enabled, faults from the configured schedule are
+	// injected at random intervals over the soak window and recorded in
+	// the run manifest for correlation.
+	var chaosEngine *chaos.Engine
+	if e2e_config.GetConfig().Chaos.Enabled {
+		var err error
+		chaosEngine, err = chaos.Start()
+		Expect(err).ToNot(HaveOccurred(), "failed to start the chaos engine")
+		defer func() {
+			injected := chaosEngine.Stop()
+			logf.Log.Info("chaos schedule complete", "faults", len(injected))
+			Expect(manifest.AppendSection("chaosFaults", injected)).To(Succeed())
+		}()
+	}
+
+	By(fmt.Sprintf("running %d fio jobs for %d seconds", len(jobs), cfg.DurationSecs))
+	// The draw happens before the goroutines start; the source is not
+	// safe for concurrent use and a fixed draw order keeps a seeded run
+	// reproducible.
+	cycles := drawDutyCycles(len(jobs))
+	results := make([]soakResult, len(jobs))
+	var wg sync.WaitGroup
+	for ix := range jobs {
+		wg.Add(1)
+		go func(ix int) {
+			defer wg.Done()
+			defer GinkgoRecover()
+			job := jobs[ix]
+			result := soakResult{Class: job.class.Name, Volume: job.volName}
+			var fioArgs []string
+			if cycle := cycles[ix]; cycle.ThinkTimeBlocks != 0 {
+				result.ThinkTimeUsecs = cycle.ThinkTimeUsecs
+				fioArgs = append(fioArgs,
+					fmt.Sprintf("--thinktime=%d", cycle.ThinkTimeUsecs),
+					fmt.Sprintf("--thinktime_blocks=%d", cycle.ThinkTimeBlocks))
+			}
+			out, err := k8stest.RunFio(job.podName, nameSpace, cfg.DurationSecs, fioArgs...)
+			if err != nil {
+				result.Error = err.Error()
+			} else if bw, err := k8stest.FioBandwidthBytes(out); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.BandwidthBytes = bw
+			}
+			results[ix] = result
+		}(ix)
+	}
+	wg.Wait()
+
+	// Per-class breakdowns: log the aggregate of each class and record
+	// every volume's outcome in the run manifest.
+	classBandwidth := map[string]float64{}
+	for _, result := range results {
+		classBandwidth[result.Class] += result.BandwidthBytes
+	}
+	for class, bandwidth := range classBandwidth {
+		logf.Log.Info("io soak class complete", "class", class, "aggregateBandwidthBytes", bandwidth)
+	}
+	Expect(manifest.AppendSection("ioSoak", results)).To(Succeed())
+	for _, result := range results {
+		Expect(result.Error).To(BeEmpty(),
+			"fio failed on volume %s of class %s: %s", result.Volume, result.Class, result.Error)
+	}
+}
+
+func TestIoSoak(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "IO soak")
+}
+
+var _ = Describe("io soak", func() {
+	It("should sustain the configured mixed workload", func() {
+		ioSoakTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
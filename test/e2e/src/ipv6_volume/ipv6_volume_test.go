@@ -0,0 +1,91 @@
+// IPv6 / dual-stack volume test. Gated on cluster capability: skipped
+// unless the mayastor nodes publish IPv6 internal addresses. Provisions
+// a volume, asserts the nexus children are shared over IPv6 NVMe-oF
+// targets, and verifies IO.
+package ipv6_volume_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const fioRunTimeSecs = 30
+
+func ipv6VolumeTest() {
+	nodeLocs, err := k8stest.GetNodeLocs()
+	Expect(err).ToNot(HaveOccurred())
+	ipv6Capable := false
+	for _, loc := range nodeLocs {
+		if loc.MayastorNode && loc.IPv6Address != "" {
+			ipv6Capable = true
+			break
+		}
+	}
+	if !ipv6Capable {
+		Skip("no mayastor node publishes an IPv6 internal address")
+	}
+
+	scName := "ipv6-sc"
+	volName := "ipv6-vol"
+	podName := "ipv6-fio"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	pvc, err := k8stest.CreatePVC(volName, 512, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// On an IPv6 capable cluster the replica shares must use IPv6
+	// addresses.
+	uuid := string(pvc.ObjectMeta.UID)
+	childUris, err := k8stest.GetMsvNexusChildren(uuid)
+	Expect(err).ToNot(HaveOccurred())
+	for _, uri := range childUris {
+		target, err := k8stest.ParseNvmfURI(uri)
+		if err != nil {
+			// local (bdev) children carry no address
+			continue
+		}
+		Expect(target.IsIPv6()).To(BeTrue(),
+			"nexus child %s is not shared over IPv6", uri)
+	}
+
+	// And IO over those targets must work.
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+	_, err = k8stest.RunFio(podName, nameSpace, fioRunTimeSecs)
+	Expect(err).ToNot(HaveOccurred(), "fio over IPv6 NVMe-oF failed")
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestIPv6Volume(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "IPv6 volumes")
+}
+
+var _ = Describe("Volumes over IPv6 NVMe-oF", func() {
+	It("should provision and serve IO over IPv6 targets", func() {
+		ipv6VolumeTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,127 @@
+// Kubelet restart test. Kubelet is restarted on one mayastor node and
+// the suite verifies that the CSI node plugin re-registers with kubelet,
+// that an existing volume mount on the node keeps working, and that new
+// volumes can be staged on the node afterwards.
+package kubelet_restart_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+
+// mkFioPodOnNode creates a fio pod with the given volume, pinned to the
+// given node, and waits for it to run.
+func mkFioPodOnNode(podName string, volName string, nodeName string) {
+	podDef := k8stest.NewVolumePodSpec(podName, volName, e2e_config.GetConfig().E2eFioImage,
+		[]string{"sleep", "1000000"})
+	podDef.Spec.NodeSelector = map[string]string{"kubernetes.io/hostname": nodeName}
+	_, err := k8stest.CreatePod(podDef, nameSpace)
+	Expect(err).ToNot(HaveOccurred(), "failed to create pod %s", podName)
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed(),
+		"pod %s not running on node %s", podName, nodeName)
+}
+
+func waitCsiDriverRegistered(nodeName string, timeoutSecs int) {
+	const sleepTime = 5
+	registered := false
+	for ix := 0; ix < timeoutSecs/sleepTime && !registered; ix++ {
+		driverNames, err := k8stest.GetCsiNodeDriverNames(nodeName)
+		if err == nil {
+			for _, driverName := range driverNames {
+				if driverName == k8stest.CSIProvisioner {
+					registered = true
+					break
+				}
+			}
+		}
+		if !registered {
+			time.Sleep(sleepTime * time.Second)
+		}
+	}
+	Expect(registered).To(BeTrue(),
+		"CSI driver %s not registered on node %s", k8stest.CSIProvisioner, nodeName)
+}
+
+func kubeletRestartTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(nodes).ToNot(BeEmpty(), "no mayastor nodes found")
+	testNode := nodes[0]
+
+	// The driver must be registered before we start, otherwise the
+	// re-registration check is meaningless.
+	waitCsiDriverRegistered(testNode, defTimeoutSecs)
+
+	scName := "kubelet-restart-sc"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	volName := "kubelet-restart-vol"
+	_, err = k8stest.CreatePVC(volName, 512, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	podName := "kubelet-restart-fio"
+	mkFioPodOnNode(podName, volName, testNode)
+
+	_, err = k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		"dd if=/dev/urandom of=/volume/data bs=1M count=16 && sync")
+	Expect(err).ToNot(HaveOccurred())
+	sumBefore, err := k8stest.ExecPod(podName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred())
+
+	By(fmt.Sprintf("restarting kubelet on node %s", testNode))
+	_, err = k8stest.RunCommandOnNode(testNode, "systemctl restart kubelet")
+	Expect(err).ToNot(HaveOccurred(), "failed to restart kubelet on %s", testNode)
+	Expect(k8stest.WaitNodeReady(testNode, defTimeoutSecs)).To(Succeed())
+
+	// The CSI node plugin must re-register with the restarted kubelet.
+	waitCsiDriverRegistered(testNode, defTimeoutSecs)
+
+	// The existing mount must have survived the restart.
+	sumAfter, err := k8stest.ExecPod(podName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred(), "IO on existing mount failed after kubelet restart")
+	Expect(sumAfter).To(Equal(sumBefore), "data changed across kubelet restart")
+
+	// New volumes must be stageable on the node.
+	newVolName := "kubelet-restart-vol-new"
+	_, err = k8stest.CreatePVC(newVolName, 512, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	newPodName := "kubelet-restart-fio-new"
+	mkFioPodOnNode(newPodName, newVolName, testNode)
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(newPodName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(newVolName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestKubeletRestart(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Kubelet restart")
+}
+
+var _ = Describe("CSI node plugin across kubelet restart", func() {
+	It("should re-register and continue serving volumes", func() {
+		kubeletRestartTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,96 @@
+// Control plane large-list handling. The suite creates enough bare MSV
+// records to require several list pages, verifies the listing helpers
+// follow continuation tokens without truncating the result, and logs
+// the growth of list latency with the record count so regressions in
+// control plane list handling show up in CI output.
+package list_pagination_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// msvRecordCount must exceed the 100 item page size of the listing
+// helpers by a few pages.
+const msvRecordCount = 350
+const volSizeBytes = 64 * 1024 * 1024
+
+func recordUuid(ix int) string {
+	return fmt.Sprintf("00000000-e2e0-0000-0000-%012d", ix)
+}
+
+func timedList() (int, time.Duration) {
+	start := time.Now()
+	msvs, err := k8stest.ListMsvs()
+	Expect(err).ToNot(HaveOccurred())
+	return len(msvs), time.Since(start)
+}
+
+func listPaginationTest() {
+	baselineCount, baselineLatency := timedList()
+
+	created := 0
+	defer func() {
+		for ix := 0; ix < created; ix++ {
+			_ = k8stest.DeleteMsv(recordUuid(ix))
+		}
+	}()
+
+	By(fmt.Sprintf("creating %d MSV records", msvRecordCount))
+	for ix := 0; ix < msvRecordCount; ix++ {
+		Expect(k8stest.CreateMsvRecord(recordUuid(ix), 1, volSizeBytes)).To(Succeed())
+		created++
+
+		// Measure list latency as the population grows, one sample per
+		// page worth of records.
+		if (ix+1)%100 == 0 {
+			count, latency := timedList()
+			By(fmt.Sprintf("list of %d MSVs took %v", count, latency))
+		}
+	}
+
+	count, latency := timedList()
+	Expect(count).To(Equal(baselineCount+msvRecordCount),
+		"list truncated: %d records created on top of %d, %d listed", msvRecordCount, baselineCount, count)
+	By(fmt.Sprintf("final list of %d MSVs took %v, baseline of %d took %v",
+		count, latency, baselineCount, baselineLatency))
+
+	// Every created record must be present exactly once.
+	msvs, err := k8stest.ListMsvs()
+	Expect(err).ToNot(HaveOccurred())
+	seen := map[string]int{}
+	for _, msv := range msvs {
+		seen[msv.GetName()]++
+	}
+	for ix := 0; ix < msvRecordCount; ix++ {
+		Expect(seen[recordUuid(ix)]).To(Equal(1), "record %s listed %d times", recordUuid(ix), seen[recordUuid(ix)])
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "List pagination")
+}
+
+var _ = Describe("Control plane large-list handling", func() {
+	It("should list large MSV populations without truncation", func() {
+		listPaginationTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
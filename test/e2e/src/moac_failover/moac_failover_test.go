@@ -0,0 +1,123 @@
+// Control plane crash-loop test. The moac (or agent-core) pod is killed
+// repeatedly while PVC provisioning is in flight; every volume must
+// still converge to healthy once the crash loop ends, and no nexus,
+// replica or MSV objects may be leaked by the interrupted operations.
+package moac_failover_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 300
+
+const scName = "moac-failover-sc"
+const volPrefix = "moac-failover-vol"
+const volSizeMb = 256
+const volCount = 6
+
+// moacSelector matches the control plane pod the crash loop targets.
+const moacSelector = "app=moac"
+
+// crashCount pod deletions are issued, crashIntervalSecs apart, while
+// provisioning runs.
+const crashCount = 4
+const crashIntervalSecs = 15
+
+// killMoacPod deletes the current moac pod, if any.
+func killMoacPod() {
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, moacSelector)
+	Expect(err).ToNot(HaveOccurred())
+	for _, pod := range pods.Items {
+		if pod.ObjectMeta.DeletionTimestamp == nil {
+			By(fmt.Sprintf("killing control plane pod %s", pod.Name))
+			_ = k8stest.DeletePod(pod.Name, k8stest.MayastorNamespace)
+		}
+	}
+}
+
+func moacFailoverTest() {
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, moacSelector)
+	Expect(err).ToNot(HaveOccurred())
+	if len(pods.Items) == 0 {
+		Skip("cluster has no moac pod")
+	}
+
+	baseline, err := k8stest.ListMsv()
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(k8stest.MkStorageClass(scName, k8stest.DefaultReplicaCount(), "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+	defer func() {
+		for ix := 0; ix < volCount; ix++ {
+			_ = k8stest.DeletePVC(fmt.Sprintf("%s-%d", volPrefix, ix), nameSpace)
+		}
+	}()
+
+	// Provision in the background while the crash loop runs in the
+	// foreground.
+	By("provisioning volumes while crash-looping the control plane")
+	provisioned := make(chan error, 1)
+	go func() {
+		defer GinkgoRecover()
+		_, err := k8stest.CreateVolumes(scName, volPrefix, volCount, volSizeMb, nameSpace, defTimeoutSecs)
+		provisioned <- err
+	}()
+
+	for ix := 0; ix < crashCount; ix++ {
+		killMoacPod()
+		time.Sleep(crashIntervalSecs * time.Second)
+	}
+
+	Expect(<-provisioned).ToNot(HaveOccurred(), "provisioning did not complete across the crash loop")
+
+	// With the crash loop over the control plane must settle and every
+	// volume must converge to healthy.
+	By("waiting for the control plane and the volumes to settle")
+	Expect(k8stest.WaitDeploymentReady("moac", k8stest.MayastorNamespace, defTimeoutSecs)).To(Succeed())
+	for ix := 0; ix < volCount; ix++ {
+		volName := fmt.Sprintf("%s-%d", volPrefix, ix)
+		pvc, err := k8stest.GetPVC(volName, nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(k8stest.WaitMsvState(string(pvc.ObjectMeta.UID), "healthy", defTimeoutSecs)).To(Succeed())
+	}
+
+	// Nothing may be leaked: no orphaned nexuses or replicas, and
+	// exactly the provisioned volumes on top of the baseline.
+	By("checking for leaked resources")
+	Expect(k8stest.CheckForOrphanedResources()).To(Succeed())
+	msvs, err := k8stest.ListMsv()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(len(msvs)).To(Equal(len(baseline)+volCount),
+		"unexpected MSV count after the crash loop")
+}
+
+func TestMoacFailover(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Control plane crash-loop")
+}
+
+var _ = Describe("Mayastor control plane crash-loop", func() {
+	It("should converge provisioning and leak nothing across crashes", func() {
+		moacFailoverTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,90 @@
+// MSV spec mutation test. Exercises the typed mutation helpers beyond
+// replica count — preferred and required scheduler nodes — asserting
+// the control plane applies valid mutations and that invalid ones are
+// cleanly rejected by helper validation instead of stranding the
+// volume.
+package msv_mutation_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const volSizeMb = 256
+
+func provisionVolume(scName string, volName string) string {
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	pvc, err := k8stest.GetPVC(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pv, err := k8stest.GetPV(pvc.Spec.VolumeName)
+	Expect(err).ToNot(HaveOccurred())
+	return pv.Spec.CSI.VolumeHandle
+}
+
+func msvMutationTest() {
+	scName := "msv-mutation-sc"
+	volName := "msv-mutation-vol"
+	uuid := provisionVolume(scName, volName)
+	defer func() {
+		Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+		Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+	}()
+
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(nodes).ToNot(BeEmpty())
+
+	By("growing the replica count")
+	replicas := e2e_config.GetConfig().DefaultReplicaCount
+	Expect(k8stest.UpdateNumReplicas(uuid, replicas+1)).To(Succeed())
+	applied, err := k8stest.GetMsvSpecInt(uuid, "replicaCount")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(applied).To(Equal(int64(replicas + 1)))
+
+	By("rejecting an invalid replica count")
+	Expect(k8stest.UpdateNumReplicas(uuid, 0)).ToNot(Succeed())
+
+	By("setting a preferred node")
+	Expect(k8stest.SetMsvPreferredNodes(uuid, nodes[:1])).To(Succeed())
+	By("clearing the preference")
+	Expect(k8stest.SetMsvPreferredNodes(uuid, nil)).To(Succeed())
+
+	By("restricting replicas to mayastor nodes")
+	Expect(k8stest.SetMsvRequiredNodes(uuid, nodes)).To(Succeed())
+	Expect(k8stest.SetMsvRequiredNodes(uuid, nil)).To(Succeed())
+
+	By("refusing a required node which is not a mayastor node")
+	Expect(k8stest.SetMsvRequiredNodes(uuid, []string{"no-such-node"})).ToNot(Succeed())
+}
+
+func TestMsvMutation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "MSV mutation")
+}
+
+var _ = Describe("MSV spec mutation", func() {
+	It("should apply valid mutations and reject invalid ones", func() {
+		msvMutationTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
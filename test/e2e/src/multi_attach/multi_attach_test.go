@@ -0,0 +1,194 @@
+// Multi-attach negative test. Mayastor volumes are ReadWriteOnce; a
+// second pod on a different node must be refused the attachment with a
+// clear Multi-Attach error rather than corrupting the volume. The suite
+// also covers the recovery path: when the node holding the attachment
+// dies, removing its pod must release the volumeattachment so the
+// volume can be attached elsewhere.
+package multi_attach_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 300
+const volSizeMb = 512
+
+// rejectWindowSecs is how long the second pod is given to wrongly reach
+// the running phase before the rejection is considered proven.
+const rejectWindowSecs = 90
+
+// nodeDownSecs is how long the node death test keeps kubelet stopped.
+const nodeDownSecs = 180
+
+// mkPodOnNode creates a fio pod with the given volume pinned to the
+// given node, without waiting for it to run - negative specs expect it
+// never to.
+func mkPodOnNode(podName string, volName string, nodeName string) {
+	podDef := k8stest.NewVolumePodSpec(podName, volName, e2e_config.GetConfig().E2eFioImage,
+		[]string{"sleep", "1000000"})
+	podDef.Spec.NodeSelector = map[string]string{"kubernetes.io/hostname": nodeName}
+	_, err := k8stest.CreatePod(podDef, nameSpace)
+	Expect(err).ToNot(HaveOccurred(), "failed to create pod %s", podName)
+}
+
+// pvNameOfPvc returns the name of the persistent volume bound to the
+// claim.
+func pvNameOfPvc(volName string) string {
+	pvc, err := k8stest.GetPVC(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pvc.Spec.VolumeName).ToNot(BeEmpty(), "PVC %s not bound", volName)
+	return pvc.Spec.VolumeName
+}
+
+// takeNodeDown stops kubelet on the node for nodeDownSecs through a
+// transient systemd unit. The stop must not run in the exec pod itself:
+// once kubelet is dead nothing reports the pod completed, so the unit
+// is detached and delays the stop until the pod has exited.
+func takeNodeDown(nodeName string) {
+	unit := fmt.Sprintf("e2e-node-down-%d", time.Now().UnixNano())
+	command := fmt.Sprintf(
+		"systemd-run --unit %s --collect sh -c 'sleep 5; systemctl stop kubelet; sleep %d; systemctl start kubelet'",
+		unit, nodeDownSecs)
+	_, err := k8stest.RunCommandOnNode(nodeName, command)
+	Expect(err).ToNot(HaveOccurred(), "failed to schedule kubelet stop on %s", nodeName)
+	Expect(k8stest.WaitNodeNotReady(nodeName, defTimeoutSecs)).To(Succeed())
+}
+
+func multiAttachRejectTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodes) < 2 {
+		Skip("multi-attach rejection needs at least 2 mayastor nodes")
+	}
+
+	scName := "multi-attach-sc"
+	volName := "multi-attach-vol"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	pvName := pvNameOfPvc(volName)
+
+	firstPod := "multi-attach-first"
+	mkPodOnNode(firstPod, volName, nodes[0])
+	Expect(k8stest.WaitPodRunning(firstPod, nameSpace, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.WaitVolumeAttached(pvName, nodes[0], defTimeoutSecs)).To(Succeed())
+
+	By("creating a second pod using the volume on another node")
+	since := time.Now()
+	secondPod := "multi-attach-second"
+	mkPodOnNode(secondPod, volName, nodes[1])
+
+	// The second pod must never run; poll across the whole window
+	// rather than just checking once at the end.
+	for deadline := time.Now().Add(rejectWindowSecs * time.Second); time.Now().Before(deadline); {
+		Expect(k8stest.IsPodRunning(secondPod, nameSpace)).To(BeFalse(),
+			"second pod attached an RWO volume already attached on %s", nodes[0])
+		time.Sleep(5 * time.Second)
+	}
+
+	// The rejection must be visible to the user as a Multi-Attach
+	// event, not a silent scheduling hang.
+	events, err := k8stest.EventsMatching(nameSpace, since, "Multi-Attach")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(events).ToNot(BeEmpty(), "no Multi-Attach event explaining the refused attachment")
+
+	// The volume must still only be attached where it was.
+	attachments, err := k8stest.VolumeAttachmentsOfPv(pvName)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(attachments).To(HaveLen(1), "RWO volume attached more than once")
+	Expect(attachments[0].Spec.NodeName).To(Equal(nodes[0]))
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(secondPod, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePod(firstPod, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func attachmentNodeDeathTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodes) < 2 {
+		Skip("attachment cleanup on node death needs at least 2 mayastor nodes")
+	}
+
+	scName := "multi-attach-death-sc"
+	volName := "multi-attach-death-vol"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	pvName := pvNameOfPvc(volName)
+
+	podName := "multi-attach-death-pod"
+	mkPodOnNode(podName, volName, nodes[0])
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.WaitVolumeAttached(pvName, nodes[0], defTimeoutSecs)).To(Succeed())
+	_, err = k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		"echo before-death > /volume/marker && sync")
+	Expect(err).ToNot(HaveOccurred())
+
+	By(fmt.Sprintf("taking down node %s holding the attachment", nodes[0]))
+	takeNodeDown(nodes[0])
+
+	// Kubelet cannot confirm the termination of pods on a dead node, so
+	// the pod must be force deleted before the attachment can move.
+	By("force deleting the pod and waiting for the attachment to be released")
+	Expect(k8stest.DeletePodForce(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.WaitVolumeDetached(pvName, nodes[0], defTimeoutSecs)).To(Succeed(),
+		"volumeattachment of the dead node not cleaned up")
+
+	By("attaching the volume on a surviving node")
+	replacementPod := "multi-attach-death-replacement"
+	mkPodOnNode(replacementPod, volName, nodes[1])
+	Expect(k8stest.WaitPodRunning(replacementPod, nameSpace, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.WaitVolumeAttached(pvName, nodes[1], defTimeoutSecs)).To(Succeed())
+	marker, err := k8stest.ExecPod(replacementPod, nameSpace, "cat", "/volume/marker")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(marker).To(ContainSubstring("before-death"), "data lost across the node death")
+
+	// The transient unit restarts kubelet; leave the cluster whole for
+	// the next spec.
+	Expect(k8stest.WaitNodeReady(nodes[0], nodeDownSecs+defTimeoutSecs)).To(Succeed())
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(replacementPod, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestMultiAttach(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Multi-attach rejection")
+}
+
+var _ = Describe("RWO volume attachment exclusivity", func() {
+	It("should reject a second attachment with a Multi-Attach error", func() {
+		multiAttachRejectTest()
+	})
+
+	It("should release the attachment of a dead node", func() {
+		attachmentNodeDeathTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
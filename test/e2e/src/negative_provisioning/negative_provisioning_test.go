@@ -0,0 +1,226 @@
+// Negative provisioning tests: requests the control plane cannot
+// satisfy must fail cleanly - the claim stays pending, nothing is
+// provisioned partially, and withdrawing the request leaves no residue.
+// These tests rely on the common helpers returning errors rather than
+// asserting, so an expected failure can itself be asserted on.
+package negative_provisioning_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 120
+
+// pendingWindowSecs is how long a claim must stay pending before an
+// expected provisioning failure counts as confirmed.
+const pendingWindowSecs = 60
+
+// checkNoOrphans verifies the failed request left no replica or nexus
+// behind. The check needs the control plane REST API, so it is skipped
+// quietly on clusters where moac is not deployed.
+func checkNoOrphans() {
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, "app=moac")
+	if err != nil || len(pods.Items) == 0 {
+		return
+	}
+	Expect(k8stest.CheckForOrphanedResources()).To(Succeed(),
+		"orphaned resources left behind by a failed provisioning request")
+}
+
+// expectProvisioningFailureEvent verifies the claim carries a warning
+// event explaining why provisioning failed, so the failure is diagnosable
+// from the claim alone.
+func expectProvisioningFailureEvent(volName string) {
+	events, err := k8stest.EventsForObject(nameSpace, volName)
+	Expect(err).ToNot(HaveOccurred())
+	for _, event := range events {
+		if event.Type == "Warning" && event.Reason == "ProvisioningFailed" {
+			return
+		}
+	}
+	Fail(fmt.Sprintf("no ProvisioningFailed event recorded for claim %s", volName))
+}
+
+// oversizedReplicasTest requests more replicas than there are mayastor
+// nodes; the claim must never bind.
+func oversizedReplicasTest() {
+	nodeNames, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodeNames) == 0 {
+		Skip("cluster has no mayastor nodes")
+	}
+
+	scName := "neg-replicas-sc"
+	volName := "neg-replicas-vol"
+	Expect(k8stest.MkStorageClass(scName, len(nodeNames)+1, "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err = k8stest.CreatePVC(volName, 64, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+
+	Expect(k8stest.WaitPvcStillPending(volName, nameSpace, pendingWindowSecs)).To(Succeed(),
+		"claim bound despite requesting %d replicas on %d nodes", len(nodeNames)+1, len(nodeNames))
+	expectProvisioningFailureEvent(volName)
+	checkNoOrphans()
+}
+
+// oversizedVolumeTest requests a volume larger than the total pool
+// capacity; the claim must never bind, and deleting it must leave no
+// MSV behind.
+func oversizedVolumeTest() {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	if len(pools) == 0 {
+		Skip("cluster has no mayastor pools")
+	}
+	var totalCapacity int64
+	for _, pool := range pools {
+		usage, err := k8stest.GetMsPoolUsage(pool.GetName())
+		Expect(err).ToNot(HaveOccurred())
+		totalCapacity += usage.CapacityBytes
+	}
+
+	scName := "neg-capacity-sc"
+	volName := "neg-capacity-vol"
+	Expect(k8stest.MkStorageClass(scName, 1, "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	baseline, err := k8stest.ListMsv()
+	Expect(err).ToNot(HaveOccurred())
+
+	volSizeMb := int(totalCapacity/(1024*1024)) + 1024
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+
+	Expect(k8stest.WaitPvcStillPending(volName, nameSpace, pendingWindowSecs)).To(Succeed(),
+		"claim bound despite requesting %dMB against %d bytes of pool capacity", volSizeMb, totalCapacity)
+
+	// The failed request must not have provisioned anything partially.
+	msvs, err := k8stest.ListMsv()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(len(msvs)).To(Equal(len(baseline)), "MSV left behind by a failed provisioning request")
+	expectProvisioningFailureEvent(volName)
+	checkNoOrphans()
+}
+
+// invalidProtocolTest requests a share protocol the CSI driver does not
+// implement; provisioning must fail and say so in the claim events.
+func invalidProtocolTest() {
+	scName := "neg-protocol-sc"
+	volName := "neg-protocol-vol"
+	Expect(k8stest.MkStorageClass(scName, 1, "carrier-pigeon")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err := k8stest.CreatePVC(volName, 64, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+
+	Expect(k8stest.WaitPvcStillPending(volName, nameSpace, pendingWindowSecs)).To(Succeed(),
+		"claim bound despite an invalid share protocol")
+	expectProvisioningFailureEvent(volName)
+	checkNoOrphans()
+}
+
+// nonexistentTopologyTest restricts a storage class to a node which does
+// not exist; a consumer pod must stay unschedulable and the claim must
+// never bind.
+func nonexistentTopologyTest() {
+	scName := "neg-topology-sc"
+	volName := "neg-topology-vol"
+	podName := "neg-topology-pod"
+	topologies := []coreV1.TopologySelectorTerm{{
+		MatchLabelExpressions: []coreV1.TopologySelectorLabelRequirement{{
+			Key:    "kubernetes.io/hostname",
+			Values: []string{"no-such-node"},
+		}},
+	}}
+	Expect(k8stest.MkStorageClassWithTopology(scName, 1, "nvmf", topologies)).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err := k8stest.CreatePVC(volName, 64, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+
+	// The storage class waits for a consumer, so provisioning is only
+	// attempted, and must then fail, once a pod references the claim.
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+
+	Expect(k8stest.WaitPvcStillPending(volName, nameSpace, pendingWindowSecs)).To(Succeed(),
+		"claim bound despite a topology restriction no node satisfies")
+
+	// The scheduler records why the consumer cannot be placed.
+	events, err := k8stest.EventsForObject(nameSpace, podName)
+	Expect(err).ToNot(HaveOccurred())
+	scheduleFailed := false
+	for _, event := range events {
+		if event.Reason == "FailedScheduling" {
+			scheduleFailed = true
+			break
+		}
+	}
+	Expect(scheduleFailed).To(BeTrue(), "no FailedScheduling event recorded for pod %s", podName)
+	checkNoOrphans()
+}
+
+func TestNegativeProvisioning(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Negative provisioning")
+}
+
+var _ = Describe("Mayastor provisioning failure handling", func() {
+	It("should leave a claim with too many replicas pending", func() {
+		oversizedReplicasTest()
+	})
+
+	It("should leave an oversized claim pending without partial provisioning", func() {
+		oversizedVolumeTest()
+	})
+
+	It("should leave a claim with an invalid protocol pending with a clear event", func() {
+		invalidProtocolTest()
+	})
+
+	It("should leave a claim restricted to a nonexistent topology pending", func() {
+		nonexistentTopologyTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
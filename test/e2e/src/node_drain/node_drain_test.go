@@ -0,0 +1,103 @@
+// Node drain tests: a control plane drain must move volume targets off
+// the node and rebuild the affected volumes back to health, while the
+// application pods using those volumes keep running - all without
+// touching kubernetes pod scheduling.
+package node_drain_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 240
+
+const scName = "node-drain-sc"
+const volName = "node-drain-vol"
+const volSizeMb = 512
+
+// drainTimeoutSecs bounds the wait for the drain to move the targets.
+const drainTimeoutSecs = 240
+
+// rebuildTimeoutSecs bounds the wait for the volume to rebuild after
+// its target moved away from the drained node.
+const rebuildTimeoutSecs = 360
+
+func nodeDrainTest() {
+	nodeNames, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodeNames) < 2 {
+		Skip("node drain needs at least 2 mayastor nodes")
+	}
+
+	Expect(k8stest.MkStorageClass(scName, 2, "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	podName := volName + "-client"
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	nexusNode, err := k8stest.GetMsvNexusNode(uuid)
+	Expect(err).ToNot(HaveOccurred())
+
+	By("draining the node hosting the volume target")
+	Expect(k8stest.DrainMayastorNode(nexusNode, drainTimeoutSecs)).To(Succeed())
+	defer func() {
+		_ = k8stest.UncordonMayastorNode(nexusNode)
+	}()
+
+	By("verifying the target moved and the volume rebuilt")
+	newNode, err := k8stest.GetMsvNexusNode(uuid)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(newNode).ToNot(Equal(nexusNode), "volume target still on the drained node")
+	Expect(k8stest.WaitMsvHealthy(uuid, rebuildTimeoutSecs)).To(Succeed())
+
+	By("verifying the application kept running across the drain")
+	Expect(k8stest.IsPodRunning(podName, nameSpace)).To(BeTrue(),
+		"application pod not running after the drain")
+	_, err = k8stest.RunFio(podName, nameSpace, 10)
+	Expect(err).ToNot(HaveOccurred(), "I/O failed on the drained volume")
+
+	By("uncordoning the drained node")
+	Expect(k8stest.UncordonMayastorNode(nexusNode)).To(Succeed())
+}
+
+func TestNodeDrain(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Node drain")
+}
+
+var _ = Describe("Mayastor node drain", func() {
+	It("should move volume targets away and keep applications running", func() {
+		nodeDrainTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
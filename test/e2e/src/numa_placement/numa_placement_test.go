@@ -0,0 +1,206 @@
+// NUMA placement verification for the io-engine. On multi-socket nodes
+// the suite reads the io-engine CPU affinity and per-node hugepage
+// allocation from /proc and /sys via the privileged node exec helper,
+// asserts the deployment configuration keeps cores and hugepages on one
+// socket, then deliberately breaks the affinity and records the fio
+// performance delta before restoring it. Skipped on single socket nodes.
+package numa_placement_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const fioRunTimeSecs = 30
+
+// numaNodes returns the NUMA node ids present on the given cluster node.
+func numaNodes(nodeName string) []int {
+	out, err := k8stest.RunCommandOnNode(nodeName, "ls -d /sys/devices/system/node/node* 2>/dev/null")
+	Expect(err).ToNot(HaveOccurred(), "failed to enumerate NUMA nodes on %s", nodeName)
+	var nodes []int
+	for _, line := range strings.Fields(out) {
+		id, err := strconv.Atoi(strings.TrimPrefix(line, "/sys/devices/system/node/node"))
+		if err == nil {
+			nodes = append(nodes, id)
+		}
+	}
+	return nodes
+}
+
+// cpusOfNumaNode returns the cpu list string of the given NUMA node,
+// e.g. "0-7,16-23".
+func cpusOfNumaNode(nodeName string, numaNode int) string {
+	out, err := k8stest.RunCommandOnNode(nodeName,
+		fmt.Sprintf("cat /sys/devices/system/node/node%d/cpulist", numaNode))
+	Expect(err).ToNot(HaveOccurred())
+	return strings.TrimSpace(out)
+}
+
+// expandCpuList expands a kernel cpu list, e.g. "0-2,5", into a set.
+func expandCpuList(cpuList string) map[int]bool {
+	cpus := map[int]bool{}
+	for _, field := range strings.Split(strings.TrimSpace(cpuList), ",") {
+		if bounds := strings.SplitN(field, "-", 2); len(bounds) == 2 {
+			first, err1 := strconv.Atoi(bounds[0])
+			last, err2 := strconv.Atoi(bounds[1])
+			if err1 == nil && err2 == nil {
+				for cpu := first; cpu <= last; cpu++ {
+					cpus[cpu] = true
+				}
+			}
+		} else if cpu, err := strconv.Atoi(field); err == nil {
+			cpus[cpu] = true
+		}
+	}
+	return cpus
+}
+
+// ioEnginePid returns the pid of the io-engine process on the node.
+func ioEnginePid(nodeName string) string {
+	out, err := k8stest.RunCommandOnNode(nodeName, "pidof mayastor | awk '{print $1}'")
+	Expect(err).ToNot(HaveOccurred(), "failed to find the io-engine process on %s", nodeName)
+	pid := strings.TrimSpace(out)
+	Expect(pid).ToNot(BeEmpty(), "io-engine not running on %s", nodeName)
+	return pid
+}
+
+// ioEngineAffinity returns the cpu affinity list of the io-engine on the
+// node.
+func ioEngineAffinity(nodeName string, pid string) string {
+	out, err := k8stest.RunCommandOnNode(nodeName,
+		fmt.Sprintf("awk '/Cpus_allowed_list/ {print $2}' /proc/%s/status", pid))
+	Expect(err).ToNot(HaveOccurred())
+	return strings.TrimSpace(out)
+}
+
+// homeNumaNode returns the NUMA node containing all of the given cpus,
+// or -1 if they span sockets.
+func homeNumaNode(nodeName string, cpus map[int]bool, nodes []int) int {
+	for _, numaNode := range nodes {
+		nodeCpus := expandCpuList(cpusOfNumaNode(nodeName, numaNode))
+		contained := true
+		for cpu := range cpus {
+			if !nodeCpus[cpu] {
+				contained = false
+				break
+			}
+		}
+		if contained {
+			return numaNode
+		}
+	}
+	return -1
+}
+
+func runFioBandwidth(podName string) float64 {
+	out, err := k8stest.RunFio(podName, nameSpace, fioRunTimeSecs)
+	Expect(err).ToNot(HaveOccurred(), "fio run failed")
+	bandwidth, err := k8stest.FioBandwidthBytes(out)
+	Expect(err).ToNot(HaveOccurred())
+	return bandwidth
+}
+
+func numaPlacementTest() {
+	nodes, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(nodes).ToNot(BeEmpty(), "no mayastor nodes found")
+	testNode := nodes[0]
+
+	numa := numaNodes(testNode)
+	if len(numa) < 2 {
+		Skip(fmt.Sprintf("node %s has a single NUMA node, nothing to verify", testNode))
+	}
+
+	pid := ioEnginePid(testNode)
+	affinity := expandCpuList(ioEngineAffinity(testNode, pid))
+	Expect(affinity).ToNot(BeEmpty(), "io-engine has no cpu affinity set")
+
+	// All io-engine cores must sit on one socket.
+	home := homeNumaNode(testNode, affinity, numa)
+	Expect(home).To(BeNumerically(">=", 0),
+		"io-engine cpu affinity %v spans NUMA nodes", affinity)
+
+	// The hugepages backing the io-engine must be allocated on the same
+	// socket.
+	for _, numaNode := range numa {
+		out, err := k8stest.RunCommandOnNode(testNode, fmt.Sprintf(
+			"cat /sys/devices/system/node/node%d/hugepages/hugepages-2048kB/nr_hugepages", numaNode))
+		Expect(err).ToNot(HaveOccurred())
+		pages, err := strconv.Atoi(strings.TrimSpace(out))
+		Expect(err).ToNot(HaveOccurred())
+		if numaNode == home {
+			Expect(pages).To(BeNumerically(">", 0),
+				"no hugepages allocated on the io-engine home NUMA node %d", numaNode)
+		}
+	}
+
+	// Measure the cost of breaking the affinity.
+	scName := "numa-sc"
+	volName := "numa-vol"
+	podName := "numa-fio"
+	Expect(k8stest.MkStorageClass(scName, 1, "nvmf")).To(Succeed())
+	_, err = k8stest.CreatePVC(volName, 512, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	baseline := runFioBandwidth(podName)
+
+	// Pin the io-engine to the other socket, i.e. away from its
+	// hugepages, and measure again.
+	otherNode := numa[0]
+	if otherNode == home {
+		otherNode = numa[1]
+	}
+	otherCpus := cpusOfNumaNode(testNode, otherNode)
+	originalAffinity := ioEngineAffinity(testNode, pid)
+	By(fmt.Sprintf("breaking affinity: moving io-engine from NUMA node %d to cpus %s", home, otherCpus))
+	_, err = k8stest.RunCommandOnNode(testNode, fmt.Sprintf("taskset -pc %s %s", otherCpus, pid))
+	Expect(err).ToNot(HaveOccurred(), "failed to change io-engine affinity")
+
+	broken := runFioBandwidth(podName)
+
+	// Restore the configured affinity before asserting anything.
+	_, err = k8stest.RunCommandOnNode(testNode, fmt.Sprintf("taskset -pc %s %s", originalAffinity, pid))
+	Expect(err).ToNot(HaveOccurred(), "failed to restore io-engine affinity")
+
+	delta := (baseline - broken) / baseline * 100
+	By(fmt.Sprintf("fio bandwidth: local %f, remote %f, delta %.1f%%", baseline, broken, delta))
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestNumaPlacement(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "NUMA placement")
+}
+
+var _ = Describe("io-engine NUMA placement", func() {
+	It("should keep cores and hugepages on one socket", func() {
+		numaPlacementTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,109 @@
+// Pod disruption budget verification. With a replicated volume online,
+// the suite issues simultaneous voluntary evictions against the
+// io-engine pods hosting its replicas and asserts that a PDB, shipped or
+// temporary, prevents all replicas being taken down at once. The number
+// of admitted and rejected evictions is logged so the effect of the
+// budget is measurable.
+package pdb_check_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const temporaryPdbName = "e2e-mayastor-pdb"
+
+// label carried by the io-engine daemonset pods
+var mayastorPodLabels = map[string]string{"app": "mayastor"}
+
+func pdbCheckTest() {
+	// A replicated volume must be online while evictions are attempted.
+	scName := "pdb-check-sc"
+	volName := "pdb-check-vol"
+	podName := "pdb-check-fio"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	_, err := k8stest.CreatePVC(volName, 512, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// Use the shipped PDB if there is one, otherwise create a temporary
+	// budget allowing one mayastor pod down at a time.
+	pdbs, err := k8stest.ListPodDisruptionBudgets(k8stest.MayastorNamespace)
+	Expect(err).ToNot(HaveOccurred())
+	temporary := len(pdbs.Items) == 0
+	if temporary {
+		By("no shipped PDB found, creating a temporary one")
+		Expect(k8stest.MkPodDisruptionBudget(temporaryPdbName, k8stest.MayastorNamespace,
+			mayastorPodLabels, 1)).To(Succeed())
+		defer func() {
+			Expect(k8stest.RmPodDisruptionBudget(temporaryPdbName, k8stest.MayastorNamespace)).To(Succeed())
+		}()
+	}
+
+	mayastorPods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, "app=mayastor")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(len(mayastorPods.Items)).To(BeNumerically(">", 1),
+		"need more than one mayastor pod to verify disruption limits")
+
+	// Attempt to evict every mayastor pod back to back; the budget must
+	// reject enough of them that at least one pod stays up.
+	admitted := 0
+	rejected := 0
+	for _, pod := range mayastorPods.Items {
+		ok, err := k8stest.EvictPod(pod.Name, k8stest.MayastorNamespace)
+		Expect(err).ToNot(HaveOccurred(), "eviction of pod %s errored", pod.Name)
+		if ok {
+			admitted++
+		} else {
+			rejected++
+		}
+	}
+	By(fmt.Sprintf("evictions admitted=%d rejected=%d (temporary PDB=%v)", admitted, rejected, temporary))
+	Expect(rejected).To(BeNumerically(">", 0),
+		"all simultaneous evictions of mayastor pods were admitted, no disruption budget in effect")
+	Expect(admitted).To(BeNumerically("<", len(mayastorPods.Items)),
+		"disruption budget failed to keep any mayastor pod running")
+
+	// The volume must still serve IO, i.e. the budget protected enough
+	// replicas.
+	_, err = k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		"dd if=/dev/urandom of=/volume/data bs=1M count=8 && sync")
+	Expect(err).ToNot(HaveOccurred(), "IO failed after voluntary evictions")
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestPdbCheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Pod disruption budgets")
+}
+
+var _ = Describe("Mayastor pod disruption budgets", func() {
+	It("should prevent simultaneous unavailability of all replicas", func() {
+		pdbCheckTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,88 @@
+// Scheduling conformance for installed mayastor pods. Every pod of
+// every component in the component registry is checked for the required
+// priorityClassName, tolerations and nodeSelector entries.
+package pod_conformance_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/components"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+func checkComponent(component components.Component) []string {
+	var failures []string
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, component.LabelSelector)
+	Expect(err).ToNot(HaveOccurred(), "failed to list pods of component %s", component.Name)
+	if len(pods.Items) == 0 {
+		if !component.Optional {
+			failures = append(failures, fmt.Sprintf("%s: no pods found", component.Name))
+		}
+		return failures
+	}
+	for _, pod := range pods.Items {
+		if component.PriorityClassName != "" && pod.Spec.PriorityClassName != component.PriorityClassName {
+			failures = append(failures, fmt.Sprintf("%s: pod %s has priorityClassName %q, require %q",
+				component.Name, pod.Name, pod.Spec.PriorityClassName, component.PriorityClassName))
+		}
+		for _, tolerationKey := range component.RequiredTolerationKeys {
+			if !hasToleration(pod.Spec.Tolerations, tolerationKey) {
+				failures = append(failures, fmt.Sprintf("%s: pod %s lacks toleration for %q",
+					component.Name, pod.Name, tolerationKey))
+			}
+		}
+		for key, val := range component.RequiredNodeSelector {
+			if pod.Spec.NodeSelector[key] != val {
+				failures = append(failures, fmt.Sprintf("%s: pod %s nodeSelector[%s]=%q, require %q",
+					component.Name, pod.Name, key, pod.Spec.NodeSelector[key], val))
+			}
+		}
+	}
+	return failures
+}
+
+func hasToleration(tolerations []coreV1.Toleration, key string) bool {
+	for _, toleration := range tolerations {
+		// an empty key with operator Exists tolerates everything
+		if toleration.Key == key || (toleration.Key == "" && toleration.Operator == coreV1.TolerationOpExists) {
+			return true
+		}
+	}
+	return false
+}
+
+func podConformanceTest() {
+	var failures []string
+	for _, component := range components.Registry() {
+		failures = append(failures, checkComponent(component)...)
+	}
+	Expect(failures).To(BeEmpty(), "scheduling conformance failures: %v", failures)
+}
+
+func TestPodConformance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Pod conformance")
+}
+
+var _ = Describe("Mayastor pod scheduling conformance", func() {
+	It("should run every component with its required scheduling configuration", func() {
+		podConformanceTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
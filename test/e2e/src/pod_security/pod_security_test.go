@@ -0,0 +1,121 @@
+// Pod security and mount option coverage. Production workloads rarely
+// run as root: the suite verifies a non root pod with fsGroup can write
+// to a mayastor volume - kubelet must chown the mount to the fsGroup -
+// and that storage class mount options such as noatime reach the
+// mounted filesystem.
+package pod_security_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const volSizeMb = 512
+
+const runAsUser = 1000
+const fsGroup = 2000
+
+func fsGroupTest() {
+	scName := "pod-security-sc"
+	volName := "pod-security-vol"
+	podName := "pod-security-pod"
+	Expect(k8stest.MkStorageClass(scName, k8stest.DefaultReplicaCount(), "nvmf")).To(Succeed())
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	podDef := k8stest.NewVolumePodSpec(podName, volName, e2e_config.GetConfig().E2eFioImage,
+		[]string{"sleep", "1000000"})
+	k8stest.SetPodNonRootUser(podDef, runAsUser, fsGroup)
+	_, err = k8stest.CreatePod(podDef, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// The pod must actually run unprivileged.
+	out, err := k8stest.ExecPod(podName, nameSpace, "id", "-u")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(strings.TrimSpace(out)).To(Equal(fmt.Sprintf("%d", runAsUser)))
+
+	// kubelet must have applied the fsGroup to the mounted volume.
+	out, err = k8stest.ExecPod(podName, nameSpace, "stat", "-c", "%g", "/volume")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(strings.TrimSpace(out)).To(Equal(fmt.Sprintf("%d", fsGroup)),
+		"volume mount not owned by the fsGroup")
+
+	// With the ownership in place the non root user must be able to
+	// write, and files it creates must carry the fsGroup.
+	_, err = k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		"echo non-root > /volume/non-root-file && sync")
+	Expect(err).ToNot(HaveOccurred(), "non root pod cannot write to the volume")
+	out, err = k8stest.ExecPod(podName, nameSpace, "stat", "-c", "%g", "/volume/non-root-file")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(strings.TrimSpace(out)).To(Equal(fmt.Sprintf("%d", fsGroup)))
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func mountOptionsTest() {
+	scName := "mount-options-sc"
+	volName := "mount-options-vol"
+	podName := "mount-options-pod"
+	Expect(k8stest.NewStorageClassBuilder(scName).
+		WithMountOptions([]string{"noatime"}).
+		Build()).To(Succeed())
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// The option must appear on the mount of the volume as seen by the
+	// pod, not merely on the storage class.
+	out, err := k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		"grep ' /volume ' /proc/mounts")
+	Expect(err).ToNot(HaveOccurred(), "no mount found at /volume")
+	Expect(out).To(ContainSubstring("noatime"),
+		"mount option noatime not applied, mount entry: %s", out)
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestPodSecurity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Pod security and mount options")
+}
+
+var _ = Describe("Non root pods on mayastor volumes", func() {
+	It("should apply fsGroup ownership and allow non root writes", func() {
+		fsGroupTest()
+	})
+
+	It("should apply storage class mount options to the mounted filesystem", func() {
+		mountOptionsTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
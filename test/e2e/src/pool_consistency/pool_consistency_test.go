@@ -0,0 +1,91 @@
+// Pool accounting consistency test. After a heavy volume
+// provisioning and deletion cycle the suite compares pool capacity
+// and usage as reported by the pool CRD statuses, the control plane
+// REST API and the io-engine Prometheus metrics, and fails on any
+// disagreement beyond the configured tolerance — catching accounting
+// drift which users see as a pool showing space used with no volumes
+// to account for it.
+package pool_consistency_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+
+// churnCycles alternates provisioning and deleting this many volume
+// batches before the accounting is compared.
+const churnCycles = 5
+const volumesPerCycle = 10
+const volSizeMb = 128
+
+// settleSecs allows the accounting sources to converge after churn.
+const settleSecs = 30
+
+func churn(scName string) {
+	for cycle := 0; cycle < churnCycles; cycle++ {
+		By(fmt.Sprintf("provisioning cycle %d of %d", cycle+1, churnCycles))
+		for ix := 0; ix < volumesPerCycle; ix++ {
+			volName := fmt.Sprintf("pool-consistency-vol-%d", ix)
+			_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		for ix := 0; ix < volumesPerCycle; ix++ {
+			volName := fmt.Sprintf("pool-consistency-vol-%d", ix)
+			Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+		}
+		remaining, err := k8stest.DeleteAllPvcs(nameSpace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remaining).To(BeZero(), "volumes left behind by churn cycle %d", cycle+1)
+	}
+}
+
+func poolConsistencyTest() {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pools).ToNot(BeEmpty(), "no mayastor pools found")
+
+	scName := "pool-consistency-sc"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	defer func() {
+		Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+	}()
+
+	churn(scName)
+	time.Sleep(settleSecs * time.Second)
+
+	mismatches, err := k8stest.CheckPoolConsistency()
+	Expect(err).ToNot(HaveOccurred(), "pool consistency check did not run")
+	Expect(mismatches).To(BeEmpty(), "pool accounting drift after churn")
+}
+
+func TestPoolConsistency(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Pool consistency")
+}
+
+var _ = Describe("Pool accounting consistency", func() {
+	It("should agree across CRD, REST API and metrics after churn", func() {
+		poolConsistencyTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
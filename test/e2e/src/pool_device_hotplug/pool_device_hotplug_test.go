@@ -0,0 +1,148 @@
+// Pool backing device hot-unplug test. The backing device of a pool is
+// removed via sysfs delete while a volume with data on it is online,
+// pool fault handling and volume degradation are verified, then the
+// device is replugged via a scsi host rescan and pool re-import and
+// data availability are verified.
+package pool_device_hotplug_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 180
+
+// deviceName extracts the kernel device name from a pool disk
+// specification, e.g. "aio:///dev/sdb" => "sdb".
+func deviceName(disk string) string {
+	fields := strings.Split(disk, "/")
+	return fields[len(fields)-1]
+}
+
+func waitPoolState(poolName string, wanted func(string) bool) string {
+	var state string
+	var err error
+	const sleepTime = 5
+	for ix := 0; ix < defTimeoutSecs/sleepTime; ix++ {
+		state, err = k8stest.GetMsPoolState(poolName)
+		if err == nil && wanted(state) {
+			return state
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	Expect(err).ToNot(HaveOccurred(), "failed to get state of pool %s", poolName)
+	return state
+}
+
+func waitMsvState(uuid string, wantedState string) {
+	var state string
+	var err error
+	const sleepTime = 5
+	for ix := 0; ix < defTimeoutSecs/sleepTime; ix++ {
+		state, err = k8stest.GetMsvState(uuid)
+		if err == nil && state == wantedState {
+			return
+		}
+		time.Sleep(sleepTime * time.Second)
+	}
+	Expect(err).ToNot(HaveOccurred(), "failed to get state of MSV %s", uuid)
+	Expect(state).To(Equal(wantedState), "MSV %s did not reach state %s", uuid, wantedState)
+}
+
+func poolDeviceHotplugTest() {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pools).ToNot(BeEmpty(), "no mayastor pools configured")
+
+	poolName := pools[0].GetName()
+	poolNode, poolDisk, err := k8stest.MsPoolNodeAndDisk(pools[0])
+	Expect(err).ToNot(HaveOccurred())
+	device := deviceName(poolDisk)
+
+	// Provision a replicated volume and put data with a known checksum
+	// on it.
+	scName := "hotplug-sc"
+	volName := "hotplug-vol"
+	podName := "hotplug-fio"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	pvc, err := k8stest.CreatePVC(volName, 512, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	uuid := string(pvc.ObjectMeta.UID)
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	_, err = k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		"dd if=/dev/urandom of=/volume/data bs=1M count=64 && sync")
+	Expect(err).ToNot(HaveOccurred())
+	sumBefore, err := k8stest.ExecPod(podName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred())
+
+	// Hot-unplug the backing device on the pool node.
+	By(fmt.Sprintf("unplugging device %s on node %s", device, poolNode))
+	_, err = k8stest.RunCommandOnNode(poolNode,
+		fmt.Sprintf("echo 1 > /sys/block/%s/device/delete", device))
+	Expect(err).ToNot(HaveOccurred(), "failed to unplug %s on %s", device, poolNode)
+
+	// The pool must leave the online state and the volume must degrade,
+	// not fail.
+	state := waitPoolState(poolName, func(s string) bool { return s != "online" })
+	Expect(state).ToNot(Equal("online"), "pool %s still online after device unplug", poolName)
+	waitMsvState(uuid, "degraded")
+
+	// IO must still be served by the remaining replicas.
+	sumDuring, err := k8stest.ExecPod(podName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred(), "IO failed while volume degraded")
+	Expect(sumDuring).To(Equal(sumBefore), "data changed while volume degraded")
+
+	// Replug the device by rescanning the scsi hosts and verify the
+	// pool is re-imported and the volume recovers.
+	By(fmt.Sprintf("replugging device %s on node %s", device, poolNode))
+	_, err = k8stest.RunCommandOnNode(poolNode,
+		"for h in /sys/class/scsi_host/host*; do echo '- - -' > $h/scan; done")
+	Expect(err).ToNot(HaveOccurred(), "failed to rescan scsi hosts on %s", poolNode)
+
+	state = waitPoolState(poolName, func(s string) bool { return s == "online" })
+	Expect(state).To(Equal("online"), "pool %s not re-imported after device replug", poolName)
+	waitMsvState(uuid, "healthy")
+
+	sumAfter, err := k8stest.ExecPod(podName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(sumAfter).To(Equal(sumBefore), "data changed after device replug")
+
+	// Cleanup.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestPoolDeviceHotplug(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Pool device hotplug")
+}
+
+var _ = Describe("Pool backing device hotplug", func() {
+	It("should degrade and recover a volume across device unplug and replug", func() {
+		poolDeviceHotplugTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
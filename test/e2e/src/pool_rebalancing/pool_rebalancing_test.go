@@ -0,0 +1,134 @@
+// Replica placement after adding a pool. The suite removes one pool,
+// fills the remaining pools with data so their usage is uneven, then
+// re-adds the removed pool empty and provisions a batch of single
+// replica volumes. The documented placement behaviour is asserted: the
+// control plane prefers the least used pool for new replicas, and it
+// does not move existing replicas onto the new pool by itself.
+package pool_rebalancing_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+
+// fillVolumesPerPool volumes of fillVolSizeMb each are provisioned and
+// written to while the new pool is absent, so existing pools carry real
+// usage.
+const fillVolumesPerPool = 2
+const fillVolSizeMb = 512
+
+// probeVolumes single replica volumes are provisioned once the empty
+// pool is back; the majority of them must land on it.
+const probeVolumes = 6
+const probeVolSizeMb = 64
+
+func volumeUuid(volName string) string {
+	pvc, err := k8stest.GetPVC(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pv, err := k8stest.GetPV(pvc.Spec.VolumeName)
+	Expect(err).ToNot(HaveOccurred())
+	return pv.Spec.CSI.VolumeHandle
+}
+
+func poolRebalancingTest() {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	if len(pools) < 2 {
+		Skip("test requires at least 2 mayastor pools")
+	}
+
+	// Remove one pool; it returns later as the "newly added" empty pool.
+	removedPool := pools[0].GetName()
+	removedNode, removedDisk, err := k8stest.MsPoolNodeAndDisk(pools[0])
+	Expect(err).ToNot(HaveOccurred())
+	By(fmt.Sprintf("removing pool %s on node %s", removedPool, removedNode))
+	Expect(k8stest.DeleteMsPool(removedPool)).To(Succeed())
+	defer func() {
+		// The pool must exist again whatever the outcome.
+		if _, err := k8stest.GetMsPoolState(removedPool); err != nil {
+			_ = k8stest.CreateMsPool(removedPool, removedNode, removedDisk)
+		}
+		Expect(k8stest.WaitMsPoolOnline(removedPool, defTimeoutSecs)).To(Succeed())
+	}()
+
+	scName := "pool-rebalancing-sc"
+	Expect(k8stest.MkStorageClass(scName, 1, "nvmf")).To(Succeed())
+	defer func() {
+		_, _ = k8stest.DeleteAllPvcs(nameSpace)
+		Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+	}()
+
+	By("filling the remaining pools with data")
+	fillCount := (len(pools) - 1) * fillVolumesPerPool
+	fillVols, err := k8stest.CreateVolumes(scName, "pool-rebalancing-fill", fillCount, fillVolSizeMb, nameSpace, defTimeoutSecs)
+	Expect(err).ToNot(HaveOccurred())
+	fillPools := map[string][]string{}
+	for _, volName := range fillVols {
+		Expect(k8stest.FillVolume(volName, nameSpace, fillVolSizeMb)).To(Succeed())
+		placed, err := k8stest.GetMsvReplicaPools(volumeUuid(volName))
+		Expect(err).ToNot(HaveOccurred())
+		fillPools[volName] = placed
+	}
+
+	By(fmt.Sprintf("re-adding pool %s empty", removedPool))
+	Expect(k8stest.CreateMsPool(removedPool, removedNode, removedDisk)).To(Succeed())
+	Expect(k8stest.WaitMsPoolOnline(removedPool, defTimeoutSecs)).To(Succeed())
+
+	By("provisioning probe volumes")
+	probeVols, err := k8stest.CreateVolumes(scName, "pool-rebalancing-probe", probeVolumes, probeVolSizeMb, nameSpace, defTimeoutSecs)
+	Expect(err).ToNot(HaveOccurred())
+	onNewPool := 0
+	for _, volName := range probeVols {
+		placed, err := k8stest.GetMsvReplicaPools(volumeUuid(volName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(placed).ToNot(BeEmpty(), "no replica placement reported for %s", volName)
+		for _, pool := range placed {
+			if pool == removedPool {
+				onNewPool++
+			}
+		}
+	}
+	// The scheduler prefers the least used pool, so the empty pool must
+	// receive the majority of the new replicas.
+	Expect(onNewPool).To(BeNumerically(">=", (probeVolumes+1)/2),
+		"only %d of %d probe replicas placed on the empty pool %s", onNewPool, probeVolumes, removedPool)
+
+	// Existing replicas stay where they are: the control plane prefers
+	// new pools for new replicas but does not rebalance on its own.
+	for volName, placedBefore := range fillPools {
+		placedAfter, err := k8stest.GetMsvReplicaPools(volumeUuid(volName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(placedAfter).To(Equal(placedBefore),
+			"replicas of %s moved after the pool was added", volName)
+	}
+}
+
+func TestPoolRebalancing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Pool rebalancing")
+}
+
+var _ = Describe("replica placement after adding a pool", func() {
+	It("should prefer the new empty pool without moving existing replicas", func() {
+		poolRebalancingTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
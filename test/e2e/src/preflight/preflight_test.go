@@ -0,0 +1,37 @@
+// Pre-flight cluster validation. Run before installing mayastor to
+// catch the recurring environment problems - missing hugepages, the
+// nvme_tcp module not loaded, unlabelled nodes, absent pool devices -
+// which otherwise surface later as pods stuck in Init or "Node not
+// found" provisioning failures.
+package preflight_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPreflight(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Pre-flight cluster validation")
+}
+
+var _ = Describe("Mayastor cluster prerequisites", func() {
+	It("should be satisfied by the cluster under test", func() {
+		report, err := k8stest.PreflightCheck()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Ok()).To(BeTrue(), "pre-flight failures:\n%s", report.String())
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,130 @@
+// Volume snapshot and restore. The suite populates a volume with fio,
+// snapshots it, overwrites the original, then restores a new volume
+// from the snapshot and verifies by checksum that the restored data
+// matches the volume content at snapshot time.
+package snapshot_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+
+const scName = "snapshot-sc"
+const className = "snapshot-class"
+const volName = "snapshot-vol"
+const snapName = "snapshot-snap"
+const restoreName = "snapshot-restore"
+const volSizeMb = 512
+const dataSizeMb = 256
+const dataFile = "/volume/fio-data"
+
+// seedVolume writes dataSizeMb of data to the data file on the volume
+// mounted in the given fio pod, with the given fio buffer pattern so
+// successive calls produce different content.
+func seedVolume(podName string, pattern string) {
+	_, err := k8stest.ExecPod(podName, nameSpace, "fio",
+		"--name=seed", "--rw=write", "--direct=1", "--ioengine=libaio",
+		"--bs=1m", "--iodepth=16", "--numjobs=1",
+		"--buffer_pattern="+pattern,
+		"--filename="+dataFile,
+		fmt.Sprintf("--size=%dm", dataSizeMb))
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// checksumVolume returns the checksum of the data file on the volume
+// mounted in the given fio pod.
+func checksumVolume(podName string) string {
+	out, err := k8stest.ExecPod(podName, nameSpace, "md5sum", dataFile)
+	Expect(err).ToNot(HaveOccurred())
+	fields := strings.Fields(out)
+	Expect(fields).ToNot(BeEmpty(), "unparsable md5sum output %q", out)
+	return fields[0]
+}
+
+func snapshotRestoreTest() {
+	Expect(k8stest.MkStorageClass(scName, k8stest.DefaultReplicaCount(), "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	By("populating the source volume")
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	podName := volName + "-client"
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+	seedVolume(podName, "0xaa")
+	seededSum := checksumVolume(podName)
+
+	By("snapshotting the source volume")
+	Expect(k8stest.MkSnapshotClass(className)).To(Succeed())
+	defer func() {
+		_ = k8stest.RmSnapshotClass(className)
+	}()
+	Expect(k8stest.MkSnapshot(snapName, className, volName, nameSpace)).To(Succeed())
+	defer func() {
+		_ = k8stest.RmSnapshot(snapName, nameSpace)
+	}()
+	Expect(k8stest.WaitSnapshotReady(snapName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// Overwrite the source after the snapshot; the restored volume must
+	// carry the data from snapshot time, not this.
+	seedVolume(podName, "0x55")
+	Expect(checksumVolume(podName)).ToNot(Equal(seededSum))
+
+	By("restoring a volume from the snapshot")
+	_, err = k8stest.RestorePVCFromSnapshot(restoreName, snapName, scName, volSizeMb, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(restoreName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(restoreName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	restorePod := restoreName + "-client"
+	_, err = k8stest.CreateFioPod(restorePod, restoreName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(restorePod, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(restorePod, nameSpace, defTimeoutSecs)).To(Succeed())
+	Expect(checksumVolume(restorePod)).To(Equal(seededSum),
+		"restored volume content differs from the snapshotted content")
+}
+
+func TestSnapshot(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Volume snapshot and restore")
+}
+
+var _ = Describe("Mayastor volume snapshots", func() {
+	It("should restore a volume matching the content at snapshot time", func() {
+		snapshotRestoreTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,156 @@
+// StatefulSet workload test. A statefulset claims one mayastor volume
+// per replica through its volume claim template; the suite verifies
+// each replica keeps its own data across pod deletion and scaling, and
+// that the volumes behind released claims follow the reclaim policy -
+// gone with Delete, kept with Retain - rather than leaking.
+package statefulset_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 300
+const volSizeMb = 512
+
+// stampReplica writes an ordinal specific marker to the volume of the
+// given statefulset replica.
+func stampReplica(stsName string, ordinal int) {
+	podName := fmt.Sprintf("%s-%d", stsName, ordinal)
+	_, err := k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		fmt.Sprintf("echo replica-%d > /volume/marker && sync", ordinal))
+	Expect(err).ToNot(HaveOccurred(), "failed to stamp replica %d", ordinal)
+}
+
+// verifyReplica checks the replica still holds its own marker.
+func verifyReplica(stsName string, ordinal int) {
+	podName := fmt.Sprintf("%s-%d", stsName, ordinal)
+	marker, err := k8stest.ExecPod(podName, nameSpace, "cat", "/volume/marker")
+	Expect(err).ToNot(HaveOccurred(), "failed to read back the marker of replica %d", ordinal)
+	Expect(marker).To(ContainSubstring(fmt.Sprintf("replica-%d", ordinal)),
+		"replica %d does not hold its own data", ordinal)
+}
+
+// replicaVolumeUuid returns the mayastor volume uuid behind the claim
+// of the given replica.
+func replicaVolumeUuid(stsName string, ordinal int) string {
+	uuid, err := k8stest.GetVolumeUuid(k8stest.StatefulSetPvcName(stsName, ordinal), nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	return uuid
+}
+
+func statefulSetScalingTest() {
+	scName := "sts-sc"
+	stsName := "e2e-sts"
+	Expect(k8stest.MkStorageClass(scName, e2e_config.GetConfig().DefaultReplicaCount, "nvmf")).To(Succeed())
+	_, err := k8stest.MkStatefulSet(stsName, 2, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitStatefulSetReady(stsName, nameSpace, defTimeoutSecs)).To(Succeed())
+	for ordinal := 0; ordinal < 2; ordinal++ {
+		stampReplica(stsName, ordinal)
+	}
+
+	// The controller recreates a deleted pod with the same ordinal, so
+	// it must come back attached to the same claim and data.
+	By("deleting a pod and verifying its replacement keeps the data")
+	Expect(k8stest.DeletePod(stsName+"-0", nameSpace)).To(Succeed())
+	Expect(k8stest.WaitStatefulSetReady(stsName, nameSpace, defTimeoutSecs)).To(Succeed())
+	verifyReplica(stsName, 0)
+	verifyReplica(stsName, 1)
+
+	By("scaling up and stamping the new replica")
+	Expect(k8stest.ScaleStatefulSet(stsName, nameSpace, 3)).To(Succeed())
+	Expect(k8stest.WaitStatefulSetReady(stsName, nameSpace, defTimeoutSecs)).To(Succeed())
+	stampReplica(stsName, 2)
+	scaledUuids := []string{replicaVolumeUuid(stsName, 1), replicaVolumeUuid(stsName, 2)}
+
+	// Kubernetes keeps the claims of scaled down replicas; removing
+	// them must remove the mayastor volumes too, as the class reclaim
+	// policy is Delete.
+	By("scaling down and releasing the orphaned claims")
+	Expect(k8stest.ScaleStatefulSet(stsName, nameSpace, 1)).To(Succeed())
+	Expect(k8stest.WaitStatefulSetReady(stsName, nameSpace, defTimeoutSecs)).To(Succeed())
+	verifyReplica(stsName, 0)
+	for ordinal := 1; ordinal < 3; ordinal++ {
+		pvcName := k8stest.StatefulSetPvcName(stsName, ordinal)
+		_, err := k8stest.GetPVC(pvcName, nameSpace)
+		Expect(err).ToNot(HaveOccurred(), "claim %s did not survive the scale down", pvcName)
+		Expect(k8stest.DeletePVC(pvcName, nameSpace)).To(Succeed())
+	}
+	for _, uuid := range scaledUuids {
+		Expect(k8stest.WaitMsvDeleted(uuid, defTimeoutSecs)).To(Succeed(),
+			"MSV leaked by a scaled down replica")
+	}
+
+	// Cleanup.
+	Expect(k8stest.RmStatefulSet(stsName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(k8stest.StatefulSetPvcName(stsName, 0), nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func statefulSetRetainTest() {
+	scName := "sts-retain-sc"
+	stsName := "e2e-sts-retain"
+	Expect(k8stest.MkStorageClassWithReclaimPolicy(scName, e2e_config.GetConfig().DefaultReplicaCount,
+		"nvmf", coreV1.PersistentVolumeReclaimRetain)).To(Succeed())
+	_, err := k8stest.MkStatefulSet(stsName, 1, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitStatefulSetReady(stsName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	pvcName := k8stest.StatefulSetPvcName(stsName, 0)
+	uuid := replicaVolumeUuid(stsName, 0)
+	pvc, err := k8stest.GetPVC(pvcName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pvName := pvc.Spec.VolumeName
+
+	// With Retain, releasing the claim must leave the PV and the MSV
+	// behind for manual adoption or cleanup.
+	By("scaling to zero and releasing the claim")
+	Expect(k8stest.ScaleStatefulSet(stsName, nameSpace, 0)).To(Succeed())
+	Expect(k8stest.WaitStatefulSetReady(stsName, nameSpace, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.DeletePVC(pvcName, nameSpace)).To(Succeed())
+	Expect(k8stest.WaitPvPhase(pvName, coreV1.VolumeReleased, defTimeoutSecs)).To(Succeed())
+	_, err = k8stest.GetMSV(uuid)
+	Expect(err).ToNot(HaveOccurred(), "MSV %s gone despite Retain reclaim policy", uuid)
+
+	// Cleanup: now remove the retained objects explicitly.
+	Expect(k8stest.DeletePV(pvName)).To(Succeed())
+	Expect(k8stest.DeleteMsv(uuid)).To(Succeed())
+	Expect(k8stest.RmStatefulSet(stsName, nameSpace)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestStatefulSet(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "StatefulSet workload")
+}
+
+var _ = Describe("StatefulSet backed by mayastor volumes", func() {
+	It("should keep per-replica data across pod deletion and scaling", func() {
+		statefulSetScalingTest()
+	})
+
+	It("should honour the Retain reclaim policy for released claims", func() {
+		statefulSetRetainTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
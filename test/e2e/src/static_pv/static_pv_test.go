@@ -0,0 +1,120 @@
+// Static (pre-provisioned) PV test. A mayastor volume is first created
+// dynamically on a Retain storage class and stamped with data, then its
+// claim and PV are deleted so only the MSV and replica data remain. A
+// static PV pointing at the volume handle plus a matching PVC are then
+// created and the suite verifies the volume mounts with the data
+// intact, and that deleting the static objects honours Retain semantics
+// by leaving the MSV and replica data untouched.
+package static_pv_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+const volSizeMb = 512
+
+func writeAndSum(podName string) string {
+	_, err := k8stest.ExecPod(podName, nameSpace, "sh", "-c",
+		"dd if=/dev/urandom of=/volume/data bs=1M count=32 && sync")
+	Expect(err).ToNot(HaveOccurred())
+	sum, err := k8stest.ExecPod(podName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred())
+	return sum
+}
+
+func staticPvTest() {
+	scName := "static-pv-sc"
+	Expect(k8stest.MkStorageClassWithReclaimPolicy(scName, e2e_config.GetConfig().DefaultReplicaCount,
+		"nvmf", coreV1.PersistentVolumeReclaimRetain)).To(Succeed())
+
+	// Dynamically provision a volume and stamp it with data.
+	volName := "static-pv-dynamic-vol"
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	pvc, err := k8stest.GetPVC(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	pvName := pvc.Spec.VolumeName
+	pv, err := k8stest.GetPV(pvName)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pv.Spec.CSI).ToNot(BeNil())
+	volumeHandle := pv.Spec.CSI.VolumeHandle
+
+	podName := "static-pv-fio"
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+	sumBefore := writeAndSum(podName)
+
+	// Release the volume: with Retain, the PV survives the claim and
+	// the MSV survives the PV.
+	Expect(k8stest.DeletePod(podName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(volName, nameSpace)).To(Succeed())
+	Expect(k8stest.WaitPvPhase(pvName, coreV1.VolumeReleased, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.DeletePV(pvName)).To(Succeed())
+	_, err = k8stest.GetMSV(volumeHandle)
+	Expect(err).ToNot(HaveOccurred(), "MSV %s gone despite Retain reclaim policy", volumeHandle)
+
+	// Adopt the volume through a static PV and matching PVC.
+	By("adopting the volume via a static PV")
+	staticPvName := "static-pv-adopted"
+	staticVolName := "static-pv-adopted-vol"
+	_, err = k8stest.CreateStaticPv(staticPvName, volumeHandle, volSizeMb, scName, "nvmf")
+	Expect(err).ToNot(HaveOccurred())
+	_, err = k8stest.CreateStaticPvc(staticVolName, staticPvName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPvcBound(staticVolName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	staticPodName := "static-pv-fio-adopted"
+	_, err = k8stest.CreateFioPod(staticPodName, staticVolName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitPodRunning(staticPodName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	sumAfter, err := k8stest.ExecPod(staticPodName, nameSpace, "md5sum", "/volume/data")
+	Expect(err).ToNot(HaveOccurred(), "failed to read back data on the adopted volume")
+	Expect(sumAfter).To(Equal(sumBefore), "data lost across static adoption")
+
+	// Deleting the static objects must not touch the MSV.
+	Expect(k8stest.DeletePod(staticPodName, nameSpace)).To(Succeed())
+	Expect(k8stest.DeletePVC(staticVolName, nameSpace)).To(Succeed())
+	Expect(k8stest.WaitPvPhase(staticPvName, coreV1.VolumeReleased, defTimeoutSecs)).To(Succeed())
+	Expect(k8stest.DeletePV(staticPvName)).To(Succeed())
+	_, err = k8stest.GetMSV(volumeHandle)
+	Expect(err).ToNot(HaveOccurred(), "MSV %s deleted despite Retain semantics", volumeHandle)
+
+	// Cleanup: now remove the underlying volume explicitly.
+	Expect(k8stest.DeleteMsv(volumeHandle)).To(Succeed())
+	Expect(k8stest.RmStorageClass(scName)).To(Succeed())
+}
+
+func TestStaticPv(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Static PV")
+}
+
+var _ = Describe("Static provisioning", func() {
+	It("should mount a pre-provisioned volume and honour Retain semantics", func() {
+		staticPvTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
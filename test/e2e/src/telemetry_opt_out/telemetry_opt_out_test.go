@@ -0,0 +1,129 @@
+// Telemetry/call-home opt-out verification. The telemetry component is
+// fenced off with a deny-all egress network policy so any call-home
+// attempt surfaces as a connection error in its log, giving a probe for
+// outbound attempts without real traffic leaving the cluster. The suite
+// verifies that attempts are observed while telemetry is enabled (the
+// control) and that opting out via the documented configuration
+// suppresses all attempts. Skipped unless a telemetry component is
+// identified in the e2e configuration.
+package telemetry_opt_out_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const egressPolicyName = "e2e-deny-callhome-egress"
+
+// log fragments indicating an outbound connection attempt failed against
+// the egress block
+var attemptMarkers = []string{
+	"connection refused",
+	"connection timed out",
+	"i/o timeout",
+	"no route to host",
+}
+
+// parseSelector converts a label selector of the form "k1=v1,k2=v2" into
+// a label map.
+func parseSelector(selector string) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(selector, ",") {
+		fields := strings.SplitN(pair, "=", 2)
+		if len(fields) == 2 {
+			labels[fields[0]] = fields[1]
+		}
+	}
+	return labels
+}
+
+// countCallHomeAttempts counts outbound attempt markers in the logs of
+// the telemetry pods over a probe window starting at since.
+func countCallHomeAttempts(selector string, since time.Time, probeSecs int) int {
+	time.Sleep(time.Duration(probeSecs) * time.Second)
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, selector)
+	Expect(err).ToNot(HaveOccurred())
+	attempts := 0
+	for _, pod := range pods.Items {
+		logs, err := k8stest.GetPodLogSince(pod.Name, k8stest.MayastorNamespace, since)
+		Expect(err).ToNot(HaveOccurred(), "failed to retrieve log of pod %s", pod.Name)
+		for _, line := range strings.Split(logs, "\n") {
+			for _, marker := range attemptMarkers {
+				if strings.Contains(line, marker) {
+					attempts++
+					break
+				}
+			}
+		}
+	}
+	return attempts
+}
+
+func telemetryOptOutTest() {
+	cfg := e2e_config.GetConfig()
+	selector := cfg.Telemetry.PodSelector
+
+	pods, err := k8stest.ListPodsBySelector(k8stest.MayastorNamespace, selector)
+	Expect(err).ToNot(HaveOccurred())
+	if len(pods.Items) == 0 {
+		Skip("no telemetry pods found, mayastor does not ship a telemetry component here")
+	}
+
+	// Fence the telemetry pods off so call-home attempts fail and are
+	// logged rather than leaving the cluster.
+	Expect(k8stest.ApplyDenyEgressPolicy(egressPolicyName, k8stest.MayastorNamespace, parseSelector(selector))).To(Succeed())
+	defer func() {
+		Expect(k8stest.RemoveNetworkPolicy(egressPolicyName, k8stest.MayastorNamespace)).To(Succeed())
+	}()
+
+	// Control: with telemetry enabled, attempts must be observed,
+	// otherwise the probe proves nothing.
+	attempts := countCallHomeAttempts(selector, time.Now(), cfg.Telemetry.ProbeSeconds)
+	Expect(attempts).To(BeNumerically(">", 0),
+		"no call-home attempts observed with telemetry enabled, probe is not working")
+
+	// Opt out and verify all attempts are suppressed.
+	Expect(k8stest.SetDeploymentEnv(cfg.Telemetry.Deployment, k8stest.MayastorNamespace,
+		cfg.Telemetry.DisableEnvVar, "true")).To(Succeed())
+	Expect(k8stest.WaitDeploymentReady(cfg.Telemetry.Deployment, k8stest.MayastorNamespace, 120)).To(Succeed())
+
+	attempts = countCallHomeAttempts(selector, time.Now(), cfg.Telemetry.ProbeSeconds)
+	Expect(attempts).To(BeZero(), "call-home attempts observed after opting out")
+
+	// Re-enable telemetry for subsequent suites.
+	Expect(k8stest.SetDeploymentEnv(cfg.Telemetry.Deployment, k8stest.MayastorNamespace,
+		cfg.Telemetry.DisableEnvVar, "false")).To(Succeed())
+	Expect(k8stest.WaitDeploymentReady(cfg.Telemetry.Deployment, k8stest.MayastorNamespace, 120)).To(Succeed())
+}
+
+func TestTelemetryOptOut(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Telemetry opt out")
+}
+
+var _ = Describe("Telemetry call-home opt-out", func() {
+	It("should suppress all call-home attempts when disabled", func() {
+		if e2e_config.GetConfig().Telemetry.PodSelector == "" {
+			Skip("no telemetry component configured")
+		}
+		telemetryOptOutTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
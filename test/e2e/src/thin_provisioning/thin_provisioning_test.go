@@ -0,0 +1,132 @@
+// Thin provisioning tests: a thin volume must be allocated lazily - pool
+// usage grows as the application writes, not when the volume is created -
+// and the nominal size of thin volumes may over-commit the pool.
+package thin_provisioning_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 120
+
+// fioRunTimeSecs is long enough for fio to touch the whole test file.
+const fioRunTimeSecs = 20
+
+// thinAllocationTest verifies a thin volume starts nearly unallocated
+// and that pool usage grows once data is written to it.
+func thinAllocationTest() {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	if len(pools) == 0 {
+		Skip("cluster has no mayastor pools")
+	}
+
+	scName := "thin-alloc-sc"
+	volName := "thin-alloc-vol"
+	podName := "thin-alloc-pod"
+	Expect(k8stest.NewStorageClassBuilder(scName).WithReplicas(1).WithThin(true).Build()).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err = k8stest.CreatePVC(volName, 512, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	// Nothing has been written yet, so the thin volume must not have
+	// been allocated its nominal size up front.
+	before, err := k8stest.TotalPoolUsage()
+	Expect(err).ToNot(HaveOccurred())
+
+	_, err = k8stest.RunFio(podName, nameSpace, fioRunTimeSecs)
+	Expect(err).ToNot(HaveOccurred())
+
+	// The written data must now be backed by pool allocation.
+	after, err := k8stest.TotalPoolUsage()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(after.UsedBytes).To(BeNumerically(">", before.UsedBytes),
+		"pool usage did not grow with writes to a thin volume")
+
+	// What is allocated must still be accounted for by the replicas.
+	mismatches, err := k8stest.CheckReplicaAccounting(true)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(mismatches).To(BeEmpty(), "pool usage disagrees with thin replica sizes")
+}
+
+// overCommitTest verifies a thin volume may be larger than the free
+// space of any pool - the commitment the pool cannot fully back is only
+// called in as data is written.
+func overCommitTest() {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	if len(pools) == 0 {
+		Skip("cluster has no mayastor pools")
+	}
+	var largestAvailable int64
+	for _, pool := range pools {
+		usage, err := k8stest.GetPoolUsage(pool.GetName())
+		Expect(err).ToNot(HaveOccurred())
+		if usage.AvailableBytes() > largestAvailable {
+			largestAvailable = usage.AvailableBytes()
+		}
+	}
+
+	scName := "thin-overcommit-sc"
+	volName := "thin-overcommit-vol"
+	Expect(k8stest.NewStorageClassBuilder(scName).WithReplicas(1).WithThin(true).Build()).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	// Larger than any pool can back, but acceptable as a thin commitment.
+	volSizeMb := int(largestAvailable/(1024*1024)) + 512
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed(),
+		"thin claim of %dMB refused against %d bytes of free pool space", volSizeMb, largestAvailable)
+}
+
+func TestThinProvisioning(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Thin provisioning")
+}
+
+var _ = Describe("Mayastor thin provisioning", func() {
+	It("should allocate a thin volume lazily as data is written", func() {
+		thinAllocationTest()
+	})
+
+	It("should allow a thin volume to over-commit the pool", func() {
+		overCommitTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,179 @@
+// Topology tests: storage class topology parameters must deterministically
+// restrict where replicas land - on pools or nodes carrying the selected
+// labels - and provisioning must fail cleanly, leaving the claim pending,
+// when no pool or node satisfies the topology.
+package topology_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 120
+
+// pendingWindowSecs is how long an unsatisfiable claim must stay
+// pending before the failure counts as confirmed.
+const pendingWindowSecs = 60
+
+// nodeLabelKey and poolLabelKey are the topology labels the suite
+// applies to one node and one pool.
+const nodeLabelKey = "topology.e2e/zone"
+const poolLabelKey = "topology.e2e/rack"
+
+// replicaNodes returns the nodes hosting the replicas of a volume.
+func replicaNodes(uuid string) []string {
+	msv, err := k8stest.GetMsv(uuid)
+	Expect(err).ToNot(HaveOccurred())
+	var nodes []string
+	for _, replica := range msv.Status.Replicas {
+		nodes = append(nodes, replica.Node)
+	}
+	return nodes
+}
+
+// nodeAffinityTest labels one node and verifies a storage class with
+// node affinity on that label places all replicas there.
+func nodeAffinityTest() {
+	nodeNames, err := k8stest.GetMayastorNodeNames()
+	Expect(err).ToNot(HaveOccurred())
+	if len(nodeNames) < 2 {
+		Skip("node topology needs at least 2 mayastor nodes to be conclusive")
+	}
+	chosenNode := nodeNames[0]
+	Expect(k8stest.LabelNode(chosenNode, nodeLabelKey, "a")).To(Succeed())
+	defer func() {
+		_ = k8stest.UnlabelNode(chosenNode, nodeLabelKey)
+	}()
+
+	scName := "topology-node-sc"
+	volName := "topology-node-vol"
+	Expect(k8stest.NewStorageClassBuilder(scName).
+		WithReplicas(1).
+		WithNodeAffinity(map[string]string{nodeLabelKey: "a"}).
+		Build()).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err = k8stest.CreatePVC(volName, 64, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	for _, node := range replicaNodes(uuid) {
+		Expect(node).To(Equal(chosenNode),
+			"replica landed on %s outside the selected topology", node)
+	}
+}
+
+// poolAffinityTest labels one pool and verifies a storage class with
+// pool affinity on that label places all replicas on it.
+func poolAffinityTest() {
+	pools, err := k8stest.ListMsPools()
+	Expect(err).ToNot(HaveOccurred())
+	if len(pools) < 2 {
+		Skip("pool topology needs at least 2 pools to be conclusive")
+	}
+	chosenPool := pools[0].GetName()
+	Expect(k8stest.LabelMsPool(chosenPool, poolLabelKey, "r1")).To(Succeed())
+	defer func() {
+		_ = k8stest.UnlabelMsPool(chosenPool, poolLabelKey)
+	}()
+
+	scName := "topology-pool-sc"
+	volName := "topology-pool-vol"
+	Expect(k8stest.NewStorageClassBuilder(scName).
+		WithReplicas(1).
+		WithPoolAffinity(map[string]string{poolLabelKey: "r1"}).
+		Build()).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err = k8stest.CreatePVC(volName, 64, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	replicaPools, err := k8stest.GetMsvReplicaPools(uuid)
+	Expect(err).ToNot(HaveOccurred())
+	for _, pool := range replicaPools {
+		Expect(pool).To(Equal(chosenPool),
+			"replica landed on pool %s outside the selected topology", pool)
+	}
+}
+
+// unsatisfiableTopologyTest verifies provisioning fails cleanly when no
+// node carries the selected topology label.
+func unsatisfiableTopologyTest() {
+	scName := "topology-unsat-sc"
+	volName := "topology-unsat-vol"
+	Expect(k8stest.NewStorageClassBuilder(scName).
+		WithReplicas(1).
+		WithNodeAffinity(map[string]string{nodeLabelKey: "no-such-zone"}).
+		Build()).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	_, err := k8stest.CreatePVC(volName, 64, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+
+	Expect(k8stest.WaitPvcStillPending(volName, nameSpace, pendingWindowSecs)).To(Succeed(),
+		"claim bound despite an unsatisfiable topology")
+	events, err := k8stest.EventsForObject(nameSpace, volName)
+	Expect(err).ToNot(HaveOccurred())
+	failed := false
+	for _, event := range events {
+		if event.Reason == "ProvisioningFailed" {
+			failed = true
+			break
+		}
+	}
+	Expect(failed).To(BeTrue(), "no ProvisioningFailed event recorded for claim %s", volName)
+}
+
+func TestTopology(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Storage class topology")
+}
+
+var _ = Describe("Mayastor storage class topology", func() {
+	It("should place replicas only on nodes matching the node affinity", func() {
+		nodeAffinityTest()
+	})
+
+	It("should place replicas only on pools matching the pool affinity", func() {
+		poolAffinityTest()
+	})
+
+	It("should leave a claim with unsatisfiable topology pending", func() {
+		unsatisfiableTopologyTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,97 @@
+// Upgrade test: install the starting version, put data on a volume,
+// roll the cluster to the target version and verify the data survived,
+// the components run the new images and the custom resource APIs are
+// still compatible. Runs on a cluster without mayastor installed and
+// uninstalls on the way out.
+package upgrade_test
+
+import (
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/e2e_config"
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+	"github.com/openebs/mayastor/test/e2e/common/upgrade"
+	"github.com/openebs/mayastor/test/e2e/common/workload"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The empty namespace resolves to the per-suite test namespace.
+const nameSpace = ""
+const defTimeoutSecs = 240
+
+const scName = "upgrade-sc"
+const volName = "upgrade-vol"
+const volSizeMb = 512
+const ioDurationSecs = 60
+
+// healthyTimeoutSecs bounds the wait for the volume to settle after the
+// rolling upgrade moved its target around.
+const healthyTimeoutSecs = 360
+
+func upgradeTest() {
+	if !upgrade.Configured() {
+		Skip("upgrade tags not configured")
+	}
+	cfg := e2e_config.GetConfig().Upgrade
+
+	By("installing mayastor " + cfg.FromTag)
+	Expect(upgrade.InstallFromVersion()).To(Succeed())
+	defer func() {
+		_ = upgrade.UninstallToVersion()
+	}()
+	Expect(upgrade.VerifyVersion(cfg.FromTag)).To(Succeed())
+
+	By("writing data to a volume")
+	generator, err := workload.New("fio")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.MkStorageClass(scName, 2, "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+	_, err = k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+	podName := volName + "-client"
+	Expect(generator.MakePod(podName, volName, nameSpace)).To(Succeed())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	generator.Start(ioDurationSecs)
+	Expect(generator.Wait()).To(Succeed())
+
+	By("upgrading to " + cfg.ToTag)
+	Expect(upgrade.UpgradeToVersion()).To(Succeed())
+	Expect(upgrade.VerifyVersion(cfg.ToTag)).To(Succeed())
+
+	By("verifying CRD compatibility and data integrity")
+	Expect(upgrade.VerifyCrdCompatibility()).To(Succeed())
+	uuid, err := k8stest.GetVolumeUuid(volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8stest.WaitMsvHealthy(uuid, healthyTimeoutSecs)).To(Succeed())
+	Expect(generator.Verify()).To(Succeed(), "data integrity lost across the upgrade")
+}
+
+func TestUpgrade(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Upgrade")
+}
+
+var _ = Describe("Mayastor upgrade", func() {
+	It("should upgrade between image tags preserving data and APIs", func() {
+		upgradeTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})
@@ -0,0 +1,80 @@
+package validate_install_yamls_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/locations"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Kubernetes minor versions the generated yamls are expected to install on,
+// in addition to the version of the cluster under test.
+var supportedMinorVersions = []uint{21, 22, 23, 24, 25, 26}
+
+func listYamlFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	Expect(err).ToNot(HaveOccurred(), "failed to read %s", dir)
+	var yamls []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".yaml") {
+			yamls = append(yamls, entry.Name())
+		}
+	}
+	Expect(yamls).ToNot(BeEmpty(), "no yaml files found in %s, run generate-deploy-yamls.sh first", dir)
+	return yamls
+}
+
+func validateDeployYamls() {
+	yamlsDir, err := locations.GetGeneratedYamlsDir()
+	Expect(err).ToNot(HaveOccurred(), "failed to resolve the generated yamls directory")
+	crdDir := path.Join(yamlsDir)
+
+	// Every generated manifest must pass server-side validation on the
+	// cluster under test.
+	for _, filename := range listYamlFiles(yamlsDir) {
+		err := k8stest.DryRunManifestFile(filename, crdDir, nil)
+		Expect(err).ToNot(HaveOccurred(), "server-side dry-run failed for %s", filename)
+	}
+
+	// No generated manifest may use an API version removed in any
+	// kubernetes version we claim to support, nor in the version of the
+	// cluster under test.
+	minorVersions := supportedMinorVersions
+	serverMinor, err := k8stest.GetServerMinorVersion()
+	Expect(err).ToNot(HaveOccurred(), "failed to get server version")
+	minorVersions = append(minorVersions, serverMinor)
+	for _, filename := range listYamlFiles(yamlsDir) {
+		for _, minor := range minorVersions {
+			err := k8stest.CheckYamlApiVersions(filename, yamlsDir, minor)
+			Expect(err).ToNot(HaveOccurred(), "removed API check failed for %s on kubernetes 1.%d", filename, minor)
+		}
+	}
+}
+
+func TestValidateInstallYamls(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Validate install yamls")
+}
+
+var _ = Describe("Mayastor deployment yamls", func() {
+	It("should be valid on supported kubernetes versions", func() {
+		validateDeployYamls()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	err := k8stest.SetupTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to set up test environment")
+})
+
+var _ = AfterSuite(func() {
+	err := k8stest.TeardownTestEnv()
+	Expect(err).ToNot(HaveOccurred(), "failed to tear down test environment")
+})
@@ -0,0 +1,123 @@
+// Volume cloning. A clone is a new volume provisioned from a PVC data
+// source; the suite populates a source volume, clones it, verifies by
+// checksum that the clone carries the source content, and that writes
+// to either side after the clone do not leak into the other.
+package volume_clone_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mayastor/test/e2e/common/k8stest"
+	"github.com/openebs/mayastor/test/e2e/common/reporter"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nameSpace = "default"
+const defTimeoutSecs = 240
+
+const scName = "clone-sc"
+const volName = "clone-src-vol"
+const cloneName = "clone-vol"
+const volSizeMb = 512
+const dataSizeMb = 256
+const dataFile = "/volume/fio-data"
+
+// seedVolume writes dataSizeMb of data to the data file on the volume
+// mounted in the given fio pod, with the given fio buffer pattern so
+// successive calls produce different content.
+func seedVolume(podName string, pattern string) {
+	_, err := k8stest.ExecPod(podName, nameSpace, "fio",
+		"--name=seed", "--rw=write", "--direct=1", "--ioengine=libaio",
+		"--bs=1m", "--iodepth=16", "--numjobs=1",
+		"--buffer_pattern="+pattern,
+		"--filename="+dataFile,
+		fmt.Sprintf("--size=%dm", dataSizeMb))
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// checksumVolume returns the checksum of the data file on the volume
+// mounted in the given fio pod.
+func checksumVolume(podName string) string {
+	out, err := k8stest.ExecPod(podName, nameSpace, "md5sum", dataFile)
+	Expect(err).ToNot(HaveOccurred())
+	fields := strings.Fields(out)
+	Expect(fields).ToNot(BeEmpty(), "unparsable md5sum output %q", out)
+	return fields[0]
+}
+
+func volumeCloneTest() {
+	Expect(k8stest.MkStorageClass(scName, k8stest.DefaultReplicaCount(), "nvmf")).To(Succeed())
+	defer func() {
+		_ = k8stest.RmStorageClass(scName)
+	}()
+
+	By("populating the source volume")
+	_, err := k8stest.CreatePVC(volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(volName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(volName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	podName := volName + "-client"
+	_, err = k8stest.CreateFioPod(podName, volName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(podName, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(podName, nameSpace, defTimeoutSecs)).To(Succeed())
+	seedVolume(podName, "0xaa")
+	seededSum := checksumVolume(podName)
+
+	By("cloning the source volume")
+	_, err = k8stest.ClonePVC(cloneName, volName, volSizeMb, scName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePVC(cloneName, nameSpace)
+	}()
+	Expect(k8stest.WaitPvcBound(cloneName, nameSpace, defTimeoutSecs)).To(Succeed())
+
+	clonePod := cloneName + "-client"
+	_, err = k8stest.CreateFioPod(clonePod, cloneName, nameSpace)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = k8stest.DeletePod(clonePod, nameSpace)
+	}()
+	Expect(k8stest.WaitPodRunning(clonePod, nameSpace, defTimeoutSecs)).To(Succeed())
+	Expect(checksumVolume(clonePod)).To(Equal(seededSum),
+		"clone content differs from the source content at clone time")
+
+	// The clone must be an independent volume: writes to it must not
+	// reach the source, and writes to the source must not reach it.
+	By("verifying the clone and the source are independent")
+	seedVolume(clonePod, "0x55")
+	Expect(checksumVolume(podName)).To(Equal(seededSum),
+		"writing to the clone changed the source volume")
+	seedVolume(podName, "0xff")
+	sourceSum := checksumVolume(podName)
+	Expect(checksumVolume(clonePod)).ToNot(Equal(sourceSum),
+		"writing to the source changed the clone")
+}
+
+func TestVolumeClone(t *testing.T) {
+	RegisterFailHandler(Fail)
+	reporter.RunSpecs(t, "Volume cloning")
+}
+
+var _ = Describe("Mayastor volume clones", func() {
+	It("should clone a volume and keep it independent of the source", func() {
+		volumeCloneTest()
+	})
+})
+
+var _ = BeforeSuite(func() {
+	Expect(k8stest.SetupTestEnv()).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	Expect(k8stest.TeardownTestEnv()).To(Succeed())
+})